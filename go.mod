@@ -5,10 +5,10 @@ go 1.24.2
 require (
 	go.uber.org/mock v0.5.2
 	golang.org/x/net v0.41.0
+	golang.org/x/sync v0.7.0
 )
 
 require (
 	golang.org/x/mod v0.18.0 // indirect
-	golang.org/x/sync v0.7.0 // indirect
 	golang.org/x/tools v0.22.0 // indirect
 )
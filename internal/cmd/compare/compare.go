@@ -0,0 +1,177 @@
+package compare
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"web-pages-analyzer/internal/cmd/server"
+	dmcmp "web-pages-analyzer/internal/domain/comparison"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+	utlurl "web-pages-analyzer/internal/utils/url"
+)
+
+const usage = `Usage: web-pages-analyzer compare [flags] <before> <after>
+
+Compare two analyses of a page. Each side is either a URL, analyzed now, or the ID of an analysis
+recorded in the history.
+
+Flags:
+`
+
+// Run the compare command with its arguments, returns the exit code
+func Run(args []string) int {
+	return run(args, os.Stdout, os.Stderr, server.NewComparer)
+}
+
+// Creates the comparer, opening the history only when an analysis is read from it
+type newComparer func(readHistory bool) (dmcmp.Comparer, error)
+
+func run(args []string, stdout, stderr io.Writer, newComparer newComparer) int {
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	asJSON := flags.Bool("json", false, "print the diff as JSON")
+	forceRefresh := flags.Bool("force-refresh", false, "analyze the pages again even when a cached analysis is fresh")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	before, after := target(flags.Arg(0)), target(flags.Arg(1))
+	for _, t := range []dmcmp.Target{before, after} {
+		if t.URL == "" {
+			continue
+		}
+		if err := utlurl.ValidateHTTPURL(t.URL); err != nil {
+			fmt.Fprintln(stderr, "Error:", err)
+			return 2
+		}
+	}
+
+	comparer, err := newComparer(before.HistoryID != "" || after.HistoryID != "")
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	diff, err := comparer.Compare(ctx, before, after, &dmpg.AnalyzeOptions{ForceRefresh: *forceRefresh})
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err)
+		return 1
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(diff); err != nil {
+			fmt.Fprintln(stderr, "Error:", err)
+			return 1
+		}
+		return 0
+	}
+
+	printDiff(stdout, diff)
+	return 0
+}
+
+// Arguments starting with http:// or https:// are URLs, the others history IDs
+func target(arg string) dmcmp.Target {
+	if strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") {
+		return dmcmp.Target{URL: arg}
+	}
+
+	return dmcmp.Target{HistoryID: arg}
+}
+
+// Text summary of the diff
+func printDiff(w io.Writer, diff *dmcmp.Diff) {
+	fmt.Fprintf(w, "Before: %s\n", describeSource(diff.Before))
+	fmt.Fprintf(w, "After:  %s\n", describeSource(diff.After))
+
+	if !diff.Changed {
+		fmt.Fprintln(w, "\nNo changes")
+	}
+
+	if diff.HTMLVersion != nil {
+		fmt.Fprintf(w, "\nHTML version: %q -> %q\n", diff.HTMLVersion.Before, diff.HTMLVersion.After)
+	}
+	if diff.Title != nil {
+		fmt.Fprintf(w, "\nTitle: %q -> %q\n", diff.Title.Before, diff.Title.After)
+	}
+	if diff.Headings != nil {
+		fmt.Fprintln(w, "\nHeadings:")
+		for _, level := range slices.Sorted(maps.Keys(diff.Headings.Levels)) {
+			change := diff.Headings.Levels[level]
+			fmt.Fprintf(w, "  %s: %d -> %d\n", level, change.Before, change.After)
+		}
+		printHeadings(w, "added", diff.Headings.Added)
+		printHeadings(w, "removed", diff.Headings.Removed)
+		printHeadings(w, "moved", diff.Headings.Moved)
+	}
+	if diff.Links != nil {
+		fmt.Fprintln(w, "\nLinks:")
+		printLinks(w, "added", diff.Links.Added)
+		printLinks(w, "removed", diff.Links.Removed)
+		printLinks(w, "newly broken", diff.Links.NewlyBroken)
+		printLinks(w, "fixed", diff.Links.Fixed)
+	}
+	if diff.HasLoginForm != nil {
+		fmt.Fprintf(w, "\nLogin form: %t -> %t\n", diff.HasLoginForm.Before, diff.HasLoginForm.After)
+	}
+
+	if len(diff.Missing) > 0 {
+		fmt.Fprintf(w, "\nNot compared, missing from an analysis: %s\n", strings.Join(diff.Missing, ", "))
+	}
+	if diff.BrokenLinksUnchecked {
+		fmt.Fprintln(w, "\nBroken links not compared, the link checks of an analysis are incomplete")
+	}
+}
+
+func describeSource(source dmcmp.Source) string {
+	description := source.URL
+	if source.HistoryID != "" {
+		description += " (" + source.HistoryID
+		if !source.AnalyzedAt.IsZero() {
+			description += ", analyzed " + source.AnalyzedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		description += ")"
+	}
+
+	return description
+}
+
+func printHeadings(w io.Writer, label string, headings []dmhtml.Heading) {
+	for _, heading := range headings {
+		fmt.Fprintf(w, "  %-8s %s %q\n", label+":", heading.Level, heading.Text)
+	}
+}
+
+func printLinks(w io.Writer, label string, links []string) {
+	for _, link := range links {
+		fmt.Fprintf(w, "  %-13s %s\n", label+":", link)
+	}
+}
@@ -0,0 +1,165 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+
+	dmcmp "web-pages-analyzer/internal/domain/comparison"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+	mocks "web-pages-analyzer/internal/usecases/analysis_comparer/mocks"
+)
+
+func Test_Run(t *testing.T) {
+	diff := &dmcmp.Diff{
+		Before:  dmcmp.Source{URL: "https://example.com", HistoryID: "record"},
+		After:   dmcmp.Source{URL: "https://example.com"},
+		Changed: true,
+		Title:   &dmcmp.Change[string]{Before: "Home", After: "Welcome"},
+		Headings: &dmcmp.HeadingsDiff{
+			Levels: map[string]dmcmp.Change[int]{"h2": {Before: 1, After: 2}},
+			Added:  []dmhtml.Heading{{Level: "h2", Text: "Pricing"}},
+		},
+		Links: &dmcmp.LinksDiff{NewlyBroken: []string{"https://example.com/gone"}},
+	}
+
+	tests := []struct {
+		name                string
+		args                []string
+		diff                *dmcmp.Diff
+		newComparerErr      error
+		compareErr          error
+		expectedReadHistory bool
+		expectedCode        int
+		expectedStdout      []string
+		expectedStderr      string
+	}{
+		{
+			name:                "history record and URL",
+			args:                []string{"record", "https://example.com"},
+			diff:                diff,
+			expectedReadHistory: true,
+			expectedCode:        0,
+			expectedStdout: []string{
+				"Before: https://example.com (record)\n",
+				"Title: \"Home\" -> \"Welcome\"\n",
+				"  h2: 1 -> 2\n",
+				"  added:   h2 \"Pricing\"\n",
+				"  newly broken: https://example.com/gone\n",
+			},
+		},
+		{
+			name:           "JSON output of two URLs",
+			args:           []string{"-json", "-force-refresh", "https://example.com", "https://example.org"},
+			diff:           diff,
+			expectedCode:   0,
+			expectedStdout: []string{`"title": {`, `"newly_broken": [`},
+		},
+		{
+			name:           "links of a partial analysis",
+			args:           []string{"https://example.com", "https://example.org"},
+			diff:           &dmcmp.Diff{BrokenLinksUnchecked: true},
+			expectedCode:   0,
+			expectedStdout: []string{"Broken links not compared, the link checks of an analysis are incomplete\n"},
+		},
+		{
+			name:           "no changes",
+			args:           []string{"https://example.com", "https://example.org"},
+			diff:           &dmcmp.Diff{},
+			expectedCode:   0,
+			expectedStdout: []string{"No changes\n"},
+		},
+		{
+			name:           "missing argument",
+			args:           []string{"https://example.com"},
+			expectedCode:   2,
+			expectedStderr: "Usage: web-pages-analyzer compare",
+		},
+		{
+			name:           "unknown flag",
+			args:           []string{"-unknown", "https://example.com", "https://example.org"},
+			expectedCode:   2,
+			expectedStderr: "flag provided but not defined: -unknown",
+		},
+		{
+			name:           "help",
+			args:           []string{"-h"},
+			expectedCode:   0,
+			expectedStderr: "Usage: web-pages-analyzer compare",
+		},
+		{
+			name:           "invalid URL",
+			args:           []string{"http://", "record"},
+			expectedCode:   2,
+			expectedStderr: "Error:",
+		},
+		{
+			name:                "invalid configuration",
+			args:                []string{"record", "other"},
+			newComparerErr:      errors.New("invalid history configuration"),
+			expectedReadHistory: true,
+			expectedCode:        1,
+			expectedStderr:      "Error: invalid history configuration",
+		},
+		{
+			name:           "comparison error",
+			args:           []string{"https://example.com", "https://example.org"},
+			compareErr:     errors.New("connection refused"),
+			expectedCode:   1,
+			expectedStderr: "Error: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockComparer := mocks.NewMockComparer(ctrl)
+			if tt.diff != nil || tt.compareErr != nil {
+				mockComparer.EXPECT().
+					Compare(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, _ dmcmp.Target, opts *dmpg.AnalyzeOptions) (*dmcmp.Diff, error) {
+						if opts.ForceRefresh != strings.Contains(strings.Join(tt.args, " "), "-force-refresh") {
+							t.Errorf("expected force refresh from the flags, got options %+v", opts)
+						}
+						return tt.diff, tt.compareErr
+					}).
+					Times(1)
+			}
+
+			created := false
+			newComparer := func(readHistory bool) (dmcmp.Comparer, error) {
+				created = true
+				if readHistory != tt.expectedReadHistory {
+					t.Errorf("expected readHistory %t, got %t", tt.expectedReadHistory, readHistory)
+				}
+				return mockComparer, tt.newComparerErr
+			}
+
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, &stdout, &stderr, newComparer)
+
+			// Verify results
+			if code != tt.expectedCode {
+				t.Errorf("expected exit code %d, got %d (stderr %q)", tt.expectedCode, code, stderr.String())
+			}
+			if expectCreated := tt.diff != nil || tt.compareErr != nil || tt.newComparerErr != nil; created != expectCreated {
+				t.Errorf("expected comparer created %t, got %t", expectCreated, created)
+			}
+			for _, expected := range tt.expectedStdout {
+				if !strings.Contains(stdout.String(), expected) {
+					t.Errorf("expected stdout to contain %q, got %q", expected, stdout.String())
+				}
+			}
+			if !strings.Contains(stderr.String(), tt.expectedStderr) {
+				t.Errorf("expected stderr to contain %q, got %q", tt.expectedStderr, stderr.String())
+			}
+		})
+	}
+}
@@ -1,28 +1,155 @@
 package server
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	acmpc "web-pages-analyzer/internal/controllers/analysis_comparer"
+	ahstc "web-pages-analyzer/internal/controllers/analysis_history"
+	cexc "web-pages-analyzer/internal/controllers/content_extractor"
 	wpac "web-pages-analyzer/internal/controllers/webpage_analyzer"
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+	dmcch "web-pages-analyzer/internal/domain/cache"
 	dmhttp "web-pages-analyzer/internal/domain/clients/http"
+	dmcmp "web-pages-analyzer/internal/domain/comparison"
+	dmext "web-pages-analyzer/internal/domain/extraction"
+	dmfp "web-pages-analyzer/internal/domain/fingerprint"
+	dmhst "web-pages-analyzer/internal/domain/history"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+	anlcch "web-pages-analyzer/internal/infrastructure/analysis_cache"
 	clihttp "web-pages-analyzer/internal/infrastructure/clients/http"
+	fgp "web-pages-analyzer/internal/infrastructure/fingerprinter"
+	hststr "web-pages-analyzer/internal/infrastructure/history_store"
 	htmpr "web-pages-analyzer/internal/infrastructure/html_parser"
+	secan "web-pages-analyzer/internal/infrastructure/security_analyzer"
+	trkdet "web-pages-analyzer/internal/infrastructure/tracker_detector"
+	acmp "web-pages-analyzer/internal/usecases/analysis_comparer"
+	ahst "web-pages-analyzer/internal/usecases/analysis_history"
+	cex "web-pages-analyzer/internal/usecases/content_extractor"
 	wpa "web-pages-analyzer/internal/usecases/webpage_analyzer"
 )
 
-func Start() {
+// Time limit of an analyzer unless configured otherwise
+const defaultAnalyzerTimeout = 30 * time.Second
+
+// Content-Length above which pages are streamed instead of parsed, unless configured otherwise
+const defaultStreamingThreshold = 10 << 20
+
+// Cache of the analyses unless configured otherwise
+const (
+	defaultCacheSize = 1000
+	defaultCacheTTL  = 10 * time.Minute
+	defaultCacheDir  = "cache"
+)
+
+// History of the analyses unless configured otherwise
+const (
+	defaultHistoryDir       = "history"
+	defaultHistoryMaxPerURL = 100
+	defaultHistoryMaxAge    = 30 * 24 * time.Hour
+)
+
+// Use cases served by the application
+type usecases struct {
+	Analyzer  dmpg.WebPageAnalyzer
+	Extractor dmext.ContentExtractor
+	History   dmhst.History // nil when the history is disabled
+	Comparer  dmcmp.Comparer
+}
+
+// Create the use cases from the environment configuration
+func newUsecases() (*usecases, error) {
+	historyStore, err := newHistoryStore()
+	if err != nil {
+		return nil, fmt.Errorf("invalid history configuration: %w", err)
+	}
+
+	httpclient, documentParser := newHttpClient(), htmpr.NewDocumentParser()
+
+	analyzer, err := newAnalyzer(httpclient, documentParser, historyStore)
+	if err != nil {
+		return nil, err
+	}
+
+	usecases := &usecases{
+		Analyzer:  analyzer,
+		Extractor: cex.New(httpclient, documentParser, htmpr.NewArticleAnalyzer()),
+	}
+	if historyStore != nil {
+		usecases.History = ahst.New(historyStore)
+	}
+	usecases.Comparer = acmp.New(usecases.Analyzer, usecases.History)
+
+	return usecases, nil
+}
+
+// Create a comparer from the environment configuration whose analyses are not recorded in the
+// history. The history is only opened to read recorded analyses when readHistory is set.
+func NewComparer(readHistory bool) (dmcmp.Comparer, error) {
+	analyzer, err := newAnalyzer(newHttpClient(), htmpr.NewDocumentParser(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var history dmhst.History
+	if readHistory {
+		historyStore, err := newHistoryStore()
+		if err != nil {
+			return nil, fmt.Errorf("invalid history configuration: %w", err)
+		}
+		if historyStore != nil {
+			history = ahst.New(historyStore)
+		}
+	}
 
-	cfg := &dmhttp.HttpClientCfg{
+	return acmp.New(analyzer, history), nil
+}
+
+func newHttpClient() dmhttp.HttpClient {
+	return clihttp.New(&dmhttp.HttpClientCfg{
 		Timeout:      10,
 		MaxRedirects: 5,
+	})
+}
+
+// Analyzer configured from the environment, recording its analyses in the history store unless nil
+func newAnalyzer(httpclient dmhttp.HttpClient, documentParser dmanl.DocumentParser, historyStore dmhst.Store) (dmpg.WebPageAnalyzer, error) {
+	registry, err := newRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("failed to register analyzers: %w", err)
+	}
+
+	timeouts, err := newTimeouts()
+	if err != nil {
+		return nil, fmt.Errorf("invalid analyzer timeouts: %w", err)
+	}
+
+	streamingThreshold, err := newStreamingThreshold()
+	if err != nil {
+		return nil, fmt.Errorf("invalid streaming threshold: %w", err)
+	}
+
+	cache, cacheTTL, err := newCache()
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache configuration: %w", err)
 	}
 
-	// Create singleton instances
-	httpclient := clihttp.New(cfg)
-	parserFactory := htmpr.NewParserFactory()
+	return wpa.New(httpclient, documentParser, registry, timeouts, streamingThreshold, cache, cacheTTL, historyStore), nil
+}
+
+func Start() {
+	usecases, err := newUsecases()
+	if err != nil {
+		log.Fatalf("Failed to start: %v", err)
+	}
 
-	wpaUsecase := wpa.New(httpclient, parserFactory)
-	wpaCtrler := wpac.New(wpaUsecase)
+	wpaCtrler := wpac.New(usecases.Analyzer)
+	cexCtrler := cexc.New(usecases.Extractor)
+	acmpCtrler := acmpc.New(usecases.Comparer)
 
 	http.Handle("/", http.FileServer(http.Dir("./static/")))
 
@@ -34,7 +161,212 @@ func Start() {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 	})
 
+	http.HandleFunc("/api/extract", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			cexCtrler.Extract(w, r)
+			return
+		}
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	})
+
+	http.HandleFunc("/api/compare", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			acmpCtrler.Compare(w, r)
+			return
+		}
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	})
+
+	if usecases.History != nil {
+		ahstCtrler := ahstc.New(usecases.History)
+
+		http.HandleFunc("/api/history", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				ahstCtrler.List(w, r)
+				return
+			}
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		})
+
+		http.HandleFunc("/api/history/{id}", func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				ahstCtrler.Get(w, r)
+			case http.MethodDelete:
+				ahstCtrler.Delete(w, r)
+			default:
+				http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			}
+		})
+	}
+
 	hostPort := ":8080"
 	log.Printf("Server starting on port %s\n", hostPort)
 	log.Fatal(http.ListenAndServe(hostPort, nil))
 }
+
+// Register the analyzers run for every page, each one adds the section named after it to the analysis
+func newRegistry() (*dmanl.Registry, error) {
+	fingerprinter, err := newFingerprinter()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fingerprint signatures: %w", err)
+	}
+
+	trackerDetector, err := trkdet.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tracker list: %w", err)
+	}
+
+	registry := dmanl.NewRegistry(dmpg.MetaKey)
+	if err = registry.Register(htmpr.Analyzers()...); err != nil {
+		return nil, err
+	}
+
+	err = registry.Register(
+		clihttp.NewRedirectsAnalyzer(),
+		secan.NewSectionAnalyzer(secan.New()),
+		htmpr.NewTechnologiesAnalyzer(fingerprinter),
+		htmpr.NewTrackersAnalyzer(trackerDetector),
+	)
+
+	return registry, err
+}
+
+// Time limits of the analyzers, ANALYZER_TIMEOUT sets the default and ANALYZER_TIMEOUTS overrides it
+// for single analyzers, e.g. "links=60s,resources=45s"
+func newTimeouts() (dmanl.Timeouts, error) {
+	timeouts := dmanl.Timeouts{
+		Default:     defaultAnalyzerTimeout,
+		PerAnalyzer: make(map[string]time.Duration),
+	}
+
+	if value := os.Getenv("ANALYZER_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return timeouts, fmt.Errorf("ANALYZER_TIMEOUT: %w", err)
+		}
+		timeouts.Default = timeout
+	}
+
+	for _, entry := range strings.Split(os.Getenv("ANALYZER_TIMEOUTS"), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return timeouts, fmt.Errorf("ANALYZER_TIMEOUTS: expected name=duration, got %q", entry)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return timeouts, fmt.Errorf("ANALYZER_TIMEOUTS: %w", err)
+		}
+		timeouts.PerAnalyzer[strings.TrimSpace(name)] = timeout
+	}
+
+	return timeouts, nil
+}
+
+// Load the embedded signatures, extended by the ruleset in FINGERPRINT_RULES when set
+func newFingerprinter() (dmfp.Fingerprinter, error) {
+	path := os.Getenv("FINGERPRINT_RULES")
+	if path == "" {
+		return fgp.New()
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return fgp.New(file)
+}
+
+// Size in bytes above which pages are streamed, read from STREAMING_THRESHOLD. 0 disables streaming.
+func newStreamingThreshold() (int64, error) {
+	value := os.Getenv("STREAMING_THRESHOLD")
+	if value == "" {
+		return defaultStreamingThreshold, nil
+	}
+
+	threshold, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || threshold < 0 {
+		return 0, fmt.Errorf("STREAMING_THRESHOLD: expected a number of bytes, got %q", value)
+	}
+
+	return threshold, nil
+}
+
+// Cache of the analyses and how long they stay fresh. CACHE selects "memory" (the default), "file" or
+// "none", CACHE_SIZE bounds the entries, CACHE_DIR is the directory of the file cache and CACHE_TTL the
+// time to live.
+func newCache() (dmcch.Cache, time.Duration, error) {
+	ttl := defaultCacheTTL
+	if value := os.Getenv("CACHE_TTL"); value != "" {
+		var err error
+		if ttl, err = time.ParseDuration(value); err != nil || ttl <= 0 {
+			return nil, 0, fmt.Errorf("CACHE_TTL: expected a positive duration, got %q", value)
+		}
+	}
+
+	size := defaultCacheSize
+	if value := os.Getenv("CACHE_SIZE"); value != "" {
+		var err error
+		if size, err = strconv.Atoi(value); err != nil || size <= 0 {
+			return nil, 0, fmt.Errorf("CACHE_SIZE: expected a positive number of entries, got %q", value)
+		}
+	}
+
+	switch kind := os.Getenv("CACHE"); kind {
+	case "", "memory":
+		return anlcch.NewMemory(size), ttl, nil
+	case "file":
+		dir := os.Getenv("CACHE_DIR")
+		if dir == "" {
+			dir = defaultCacheDir
+		}
+		cache, err := anlcch.NewFile(dir, size)
+		return cache, ttl, err
+	case "none":
+		return nil, 0, nil
+	default:
+		return nil, 0, fmt.Errorf("CACHE: expected memory, file or none, got %q", kind)
+	}
+}
+
+// Store of the history of the analyses. HISTORY selects "file" (the default) or "none", HISTORY_DIR is
+// the directory of the records, HISTORY_MAX_PER_URL the records kept per page and HISTORY_MAX_AGE the
+// age at which records are removed. A limit of 0 keeps the records.
+func newHistoryStore() (dmhst.Store, error) {
+	switch kind := os.Getenv("HISTORY"); kind {
+	case "", "file":
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("HISTORY: expected file or none, got %q", kind)
+	}
+
+	retention := dmhst.Retention{MaxPerURL: defaultHistoryMaxPerURL, MaxAge: defaultHistoryMaxAge}
+
+	if value := os.Getenv("HISTORY_MAX_PER_URL"); value != "" {
+		var err error
+		if retention.MaxPerURL, err = strconv.Atoi(value); err != nil || retention.MaxPerURL < 0 {
+			return nil, fmt.Errorf("HISTORY_MAX_PER_URL: expected a number of records, got %q", value)
+		}
+	}
+
+	if value := os.Getenv("HISTORY_MAX_AGE"); value != "" {
+		var err error
+		if retention.MaxAge, err = time.ParseDuration(value); err != nil || retention.MaxAge < 0 {
+			return nil, fmt.Errorf("HISTORY_MAX_AGE: expected a duration, got %q", value)
+		}
+	}
+
+	dir := os.Getenv("HISTORY_DIR")
+	if dir == "" {
+		dir = defaultHistoryDir
+	}
+
+	return hststr.NewFile(dir, retention)
+}
@@ -0,0 +1,76 @@
+package analysis_comparer
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	dmcmp "web-pages-analyzer/internal/domain/comparison"
+	dmhst "web-pages-analyzer/internal/domain/history"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+	utlurl "web-pages-analyzer/internal/utils/url"
+)
+
+type compareRequest struct {
+	Before dmcmp.Target `json:"before"`
+	After  dmcmp.Target `json:"after"`
+	// Options of the pages given by URL, their sections are always the compared ones
+	dmhtml.ParserOptions
+	ForceRefresh bool `json:"force_refresh"`
+}
+
+type analysisComparerCtrler struct {
+	comparer dmcmp.Comparer
+}
+
+func New(comparer dmcmp.Comparer) *analysisComparerCtrler {
+	return &analysisComparerCtrler{comparer: comparer}
+}
+
+func (acc *analysisComparerCtrler) Compare(w http.ResponseWriter, r *http.Request) {
+	var req compareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON request body", http.StatusBadRequest)
+		return
+	}
+
+	for _, target := range []dmcmp.Target{req.Before, req.After} {
+		if err := target.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if target.URL == "" {
+			continue
+		}
+		if err := utlurl.ValidateHTTPURL(target.URL); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	opts := &dmpg.AnalyzeOptions{ParserOptions: req.ParserOptions, ForceRefresh: req.ForceRefresh}
+	if err := opts.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	diff, err := acc.comparer.Compare(r.Context(), req.Before, req.After, opts)
+	if errors.Is(err, dmhst.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Println("[ERROR] Error comparing analyses: ", err.Error())
+		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(diff); err != nil {
+		log.Println("[ERROR] Error encoding JSON response: ", err.Error())
+		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,106 @@
+package analysis_comparer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+
+	dmcmp "web-pages-analyzer/internal/domain/comparison"
+	dmhst "web-pages-analyzer/internal/domain/history"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+	mocks "web-pages-analyzer/internal/usecases/analysis_comparer/mocks"
+)
+
+func Test_Compare(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		diff         *dmcmp.Diff
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "history record and URL",
+			body: `{"before":{"history_id":"record"},"after":{"url":"https://example.com"},"force_refresh":true}`,
+			diff: &dmcmp.Diff{
+				Before:  dmcmp.Source{URL: "https://example.com", HistoryID: "record"},
+				After:   dmcmp.Source{URL: "https://example.com"},
+				Changed: true,
+				Title:   &dmcmp.Change[string]{Before: "Home", After: "Welcome"},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"before":{"url":"https://example.com","history_id":"record"},"after":{"url":"https://example.com"},"changed":true,"title":{"before":"Home","after":"Welcome"}}`,
+		},
+		{
+			name:         "invalid JSON",
+			body:         `{`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Invalid JSON request body",
+		},
+		{
+			name:         "target without history ID or URL",
+			body:         `{"before":{"history_id":"record"},"after":{}}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: dmcmp.ErrInvalidTarget.Error(),
+		},
+		{
+			name:         "invalid URL",
+			body:         `{"before":{"url":"ftp://example.com"},"after":{"history_id":"record"}}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "only HTTP and HTTPS are supported",
+		},
+		{
+			name:         "unknown history record",
+			body:         `{"before":{"history_id":"unknown"},"after":{"history_id":"record"}}`,
+			err:          dmhst.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+			expectedBody: dmhst.ErrNotFound.Error(),
+		},
+		{
+			name:         "comparison error",
+			body:         `{"before":{"url":"https://example.com"},"after":{"url":"https://example.org"}}`,
+			err:          errors.New("connection refused"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "Internal server error: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockComparer := mocks.NewMockComparer(ctrl)
+			if tt.expectedCode != http.StatusBadRequest {
+				mockComparer.EXPECT().
+					Compare(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, _ dmcmp.Target, opts *dmpg.AnalyzeOptions) (*dmcmp.Diff, error) {
+						if opts.ForceRefresh != strings.Contains(tt.body, `"force_refresh":true`) {
+							t.Errorf("expected force_refresh from the request, got options %+v", opts)
+						}
+						return tt.diff, tt.err
+					}).
+					Times(1)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/compare", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			New(mockComparer).Compare(w, req)
+
+			// Verify results
+			if w.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if body := strings.TrimSpace(w.Body.String()); body != tt.expectedBody {
+				t.Errorf("expected body %s, got %s", tt.expectedBody, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package analysis_history
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	dmhst "web-pages-analyzer/internal/domain/history"
+	utlurl "web-pages-analyzer/internal/utils/url"
+)
+
+type listResponse struct {
+	URL     string         `json:"url"`
+	Records []dmhst.Record `json:"records"`
+}
+
+type analysisHistoryCtrler struct {
+	history dmhst.History
+}
+
+func New(history dmhst.History) *analysisHistoryCtrler {
+	return &analysisHistoryCtrler{history: history}
+}
+
+// List the past analyses of the page in the url query parameter, newest first
+func (ahc *analysisHistoryCtrler) List(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.Query().Get("url")
+	if err := utlurl.ValidateHTTPURL(url); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	records, err := ahc.history.List(url, limit)
+	if err != nil {
+		log.Println("[ERROR] Error listing history: ", err.Error())
+		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if records == nil {
+		records = []dmhst.Record{}
+	}
+
+	writeJSON(w, listResponse{URL: url, Records: records})
+}
+
+// Past analysis with the ID in the path
+func (ahc *analysisHistoryCtrler) Get(w http.ResponseWriter, r *http.Request) {
+	record, err := ahc.history.Get(r.PathValue("id"))
+	if errors.Is(err, dmhst.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Println("[ERROR] Error reading history: ", err.Error())
+		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, record)
+}
+
+func (ahc *analysisHistoryCtrler) Delete(w http.ResponseWriter, r *http.Request) {
+	err := ahc.history.Delete(r.PathValue("id"))
+	if errors.Is(err, dmhst.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Println("[ERROR] Error deleting history: ", err.Error())
+		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Println("[ERROR] Error encoding JSON response: ", err.Error())
+		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,175 @@
+package analysis_history
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	dmhst "web-pages-analyzer/internal/domain/history"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+	mocks "web-pages-analyzer/internal/usecases/analysis_history/mocks"
+)
+
+func Test_List(t *testing.T) {
+	createdAt := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedLimit int
+		records       []dmhst.Record
+		err           error
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:          "records of the page",
+			query:         "?url=https://example.com&limit=5",
+			expectedLimit: 5,
+			records:       []dmhst.Record{{ID: "record", URL: "https://example.com", CreatedAt: createdAt, Status: dmpg.StatusSuccess}},
+			expectedCode:  http.StatusOK,
+			expectedBody:  `{"url":"https://example.com","records":[{"id":"record","url":"https://example.com","options":{"check_resources":false,"check_fragments":false,"login_threshold":null,"link_classification":"","first_party_domains":null,"fields":null,"include":null,"exclude":null,"force_refresh":false},"created_at":"2025-06-01T10:00:00Z","status":"success"}]}`,
+		},
+		{
+			name:         "page without history",
+			query:        "?url=https://example.com",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"url":"https://example.com","records":[]}`,
+		},
+		{
+			name:         "missing URL",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "URL is required",
+		},
+		{
+			name:         "invalid limit",
+			query:        "?url=https://example.com&limit=-1",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "limit must be a positive number",
+		},
+		{
+			name:         "store error",
+			query:        "?url=https://example.com",
+			err:          errors.New("disk full"),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "Internal server error: disk full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockHistory := mocks.NewMockHistory(ctrl)
+			if tt.expectedCode != http.StatusBadRequest {
+				mockHistory.EXPECT().
+					List("https://example.com", tt.expectedLimit).
+					Return(tt.records, tt.err).
+					Times(1)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/history"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			New(mockHistory).List(w, req)
+
+			// Verify results
+			if w.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if body := strings.TrimSpace(w.Body.String()); body != tt.expectedBody {
+				t.Errorf("expected body %s, got %s", tt.expectedBody, body)
+			}
+		})
+	}
+}
+
+func Test_Get(t *testing.T) {
+	tests := []struct {
+		name         string
+		record       *dmhst.Record
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "past analysis",
+			record: func() *dmhst.Record {
+				analysis := dmpg.NewWebPageAnalysis()
+				analysis.Set(dmpg.TitleSection, "Example Domain")
+				return &dmhst.Record{ID: "record", URL: "https://example.com", Status: dmpg.StatusSuccess, Analysis: analysis}
+			}(),
+			expectedCode: http.StatusOK,
+			expectedBody: `"analysis":{"title":"Example Domain"}`,
+		},
+		{
+			name:         "unknown record",
+			err:          dmhst.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+			expectedBody: dmhst.ErrNotFound.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockHistory := mocks.NewMockHistory(ctrl)
+			mockHistory.EXPECT().Get("record").Return(tt.record, tt.err).Times(1)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/history/record", nil)
+			req.SetPathValue("id", "record")
+			w := httptest.NewRecorder()
+
+			New(mockHistory).Get(w, req)
+
+			// Verify results
+			if w.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func Test_Delete(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{name: "deleted record", expectedCode: http.StatusNoContent},
+		{name: "unknown record", err: dmhst.ErrNotFound, expectedCode: http.StatusNotFound},
+		{name: "store error", err: errors.New("read-only file system"), expectedCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockHistory := mocks.NewMockHistory(ctrl)
+			mockHistory.EXPECT().Delete("record").Return(tt.err).Times(1)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/history/record", nil)
+			req.SetPathValue("id", "record")
+			w := httptest.NewRecorder()
+
+			New(mockHistory).Delete(w, req)
+
+			// Verify results
+			if w.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package content_extractor
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	dmext "web-pages-analyzer/internal/domain/extraction"
+	utlurl "web-pages-analyzer/internal/utils/url"
+)
+
+type extractRequest struct {
+	URL string `json:"url"`
+}
+
+type contentExtractorCtrler struct {
+	extractor dmext.ContentExtractor
+}
+
+func New(ce dmext.ContentExtractor) *contentExtractorCtrler {
+	return &contentExtractorCtrler{extractor: ce}
+}
+
+func (cec *contentExtractorCtrler) Extract(w http.ResponseWriter, r *http.Request) {
+	var req extractRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := utlurl.ValidateHTTPURL(req.URL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	article, err := cec.extractor.Extract(r.Context(), req.URL)
+	if errors.Is(err, dmext.ErrNoMainContent) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		log.Println("[ERROR] Error extracting content: ", err.Error())
+		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(article); err != nil {
+		log.Println("[ERROR] Error encoding JSON response: ", err.Error())
+		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,106 @@
+package content_extractor
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+
+	dmext "web-pages-analyzer/internal/domain/extraction"
+	mocks "web-pages-analyzer/internal/usecases/content_extractor/mocks"
+)
+
+func Test_Extract_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	article := &dmext.Article{
+		URL:      "https://example.com/post",
+		Title:    "Brewing green tea",
+		Byline:   "Jane Doe",
+		Markdown: "# Brewing green tea",
+	}
+
+	mockExtractor := mocks.NewMockContentExtractor(ctrl)
+	mockExtractor.EXPECT().Extract(gomock.Any(), "https://example.com/post").Return(article, nil).Times(1)
+
+	controller := New(mockExtractor)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/extract", strings.NewReader(`{"url": "https://example.com/post"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	controller.Extract(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var result dmext.Article
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if result != *article {
+		t.Errorf("expected article %+v, got %+v", *article, result)
+	}
+}
+
+func Test_Extract_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    string
+		extractErr     error
+		expectedStatus int
+	}{
+		{
+			name:           "invalid JSON",
+			requestBody:    `{"url": }`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unsupported scheme",
+			requestBody:    `{"url": "ftp://example.com"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "no main content",
+			requestBody:    `{"url": "https://example.com"}`,
+			extractErr:     dmext.ErrNoMainContent,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "extraction failure",
+			requestBody:    `{"url": "https://example.com"}`,
+			extractErr:     errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockExtractor := mocks.NewMockContentExtractor(ctrl)
+			if tt.extractErr != nil {
+				mockExtractor.EXPECT().Extract(gomock.Any(), gomock.Any()).Return(nil, tt.extractErr).Times(1)
+			}
+
+			controller := New(mockExtractor)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/extract", strings.NewReader(tt.requestBody))
+			w := httptest.NewRecorder()
+
+			controller.Extract(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
@@ -1,18 +1,21 @@
 package webpage_analyzer
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"net/url"
-	"strings"
+	"time"
 
 	dmpg "web-pages-analyzer/internal/domain/webpage"
+	utlurl "web-pages-analyzer/internal/utils/url"
 )
 
 type analyzeRequest struct {
 	URL string `json:"url"`
+	dmpg.AnalyzeOptions
 }
 
 type webPageAnalyzerCtrler struct {
@@ -30,44 +33,51 @@ func (wpac *webPageAnalyzerCtrler) Analyze(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := validateURL(req.URL); err != nil {
+	if err := utlurl.ValidateHTTPURL(req.URL); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	result, err := wpac.analyzer.Analyze(req.URL)
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := wpac.analyzer.Analyze(r.Context(), req.URL, &req.AnalyzeOptions)
+	if errors.Is(err, dmpg.ErrUnknownSection) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Println("[ERROR] Error analyzing webpage: ", err.Error())
 		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err = json.NewEncoder(w).Encode(result); err != nil {
+	// Encoded before the status is written, so that an encoding error can still be answered
+	var body bytes.Buffer
+	if err = json.NewEncoder(&body).Encode(result); err != nil {
 		log.Println("[ERROR] Error encoding JSON response: ", err.Error())
 		http.Error(w, "Internal server error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-}
-
-func validateURL(urlStr string) error {
-	if urlStr == "" {
-		return fmt.Errorf("URL is required")
-	}
 
-	parsedURL, err := url.Parse(urlStr)
-	if err != nil {
-		return fmt.Errorf("invalid URL format: %s", err.Error())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", cacheControl(result.Meta))
+	// Partial results are a success, a failure still reports the error of every section
+	if result.Meta.Status == dmpg.StatusFailure {
+		w.WriteHeader(http.StatusInternalServerError)
 	}
-
-	scheme := strings.ToLower(parsedURL.Scheme)
-	if scheme != "http" && scheme != "https" {
-		return fmt.Errorf("only HTTP and HTTPS are supported")
+	if _, err = body.WriteTo(w); err != nil {
+		log.Println("[ERROR] Error writing JSON response: ", err.Error())
 	}
+}
 
-	if parsedURL.Host == "" {
-		return fmt.Errorf("host cannot be empty")
+// Clients may reuse a cached analysis as long as the server would serve it, the others are never reused
+func cacheControl(meta dmpg.AnalysisMeta) string {
+	if meta.Status != dmpg.StatusSuccess || meta.ExpiresAt.IsZero() {
+		return "no-store"
 	}
 
-	return nil
+	return fmt.Sprintf("private, max-age=%d", max(int(time.Until(meta.ExpiresAt).Seconds()), 0))
 }
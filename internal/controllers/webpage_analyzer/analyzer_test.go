@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"go.uber.org/mock/gomock"
 
@@ -28,47 +31,65 @@ func equalHeadings(a, b map[string]int) bool {
 	return true
 }
 
+type expectedAnalysis struct {
+	htmlVersion  string
+	title        string
+	headings     map[string]int
+	links        dmhtml.LinkAnalysis
+	hasLoginForm bool
+}
+
+func (e expectedAnalysis) toWebPageAnalysis() *dmpg.WebPageAnalysis {
+	analysis := dmpg.NewWebPageAnalysis()
+	analysis.Set(dmpg.HTMLVersionSection, e.htmlVersion)
+	analysis.Set(dmpg.TitleSection, e.title)
+	analysis.Set(dmpg.HeadingsSection, e.headings)
+	analysis.Set(dmpg.LinksSection, e.links)
+	analysis.Set(dmpg.HasLoginFormSection, e.hasLoginForm)
+	return analysis
+}
+
 func Test_Analyze_Success(t *testing.T) {
 	tests := []struct {
-		name           string
-		requestBody    string
-		url            string
-		analysisResult *dmpg.WebPageAnalysis
+		name        string
+		requestBody string
+		url         string
+		expected    expectedAnalysis
 	}{
 		{
 			name:        "HTML5 page-1 Analysis",
 			requestBody: `{"url": "https://example.com"}`,
 			url:         "https://example.com",
-			analysisResult: &dmpg.WebPageAnalysis{
-				HTMLVersion:  "HTML5",
-				Title:        "Title-1",
-				Headings:     map[string]int{"h1": 2, "h2": 3, "h3": 1, "h4": 0, "h5": 0, "h6": 0},
-				Links:        dmhtml.LinkAnalysis{Internal: 5, External: 3, Inaccessible: 1},
-				HasLoginForm: true,
+			expected: expectedAnalysis{
+				htmlVersion:  "HTML5",
+				title:        "Title-1",
+				headings:     map[string]int{"h1": 2, "h2": 3, "h3": 1, "h4": 0, "h5": 0, "h6": 0},
+				links:        dmhtml.LinkAnalysis{Internal: 5, External: 3, Inaccessible: 1},
+				hasLoginForm: true,
 			},
 		},
 		{
 			name:        "HTML5 page-2 Analysis",
 			requestBody: `{"url": "https://example2.com"}`,
 			url:         "https://example2.com",
-			analysisResult: &dmpg.WebPageAnalysis{
-				HTMLVersion:  "HTML 4.01",
-				Title:        "Title-2",
-				Headings:     map[string]int{"h1": 1, "h2": 0, "h3": 0, "h4": 0, "h5": 0, "h6": 0},
-				Links:        dmhtml.LinkAnalysis{Internal: 0, External: 0, Inaccessible: 0},
-				HasLoginForm: false,
+			expected: expectedAnalysis{
+				htmlVersion:  "HTML 4.01",
+				title:        "Title-2",
+				headings:     map[string]int{"h1": 1, "h2": 0, "h3": 0, "h4": 0, "h5": 0, "h6": 0},
+				links:        dmhtml.LinkAnalysis{Internal: 0, External: 0, Inaccessible: 0},
+				hasLoginForm: false,
 			},
 		},
 		{
 			name:        "HTML5 page-3 Analysis",
 			requestBody: `{"url": "https://example3.com"}`,
 			url:         "https://example3.com",
-			analysisResult: &dmpg.WebPageAnalysis{
-				HTMLVersion:  "XHTML",
-				Title:        "Title-3",
-				Headings:     map[string]int{"h1": 1, "h2": 2, "h3": 3, "h4": 1, "h5": 0, "h6": 1},
-				Links:        dmhtml.LinkAnalysis{Internal: 10, External: 5, Inaccessible: 3},
-				HasLoginForm: true,
+			expected: expectedAnalysis{
+				htmlVersion:  "XHTML",
+				title:        "Title-3",
+				headings:     map[string]int{"h1": 1, "h2": 2, "h3": 3, "h4": 1, "h5": 0, "h6": 1},
+				links:        dmhtml.LinkAnalysis{Internal: 10, External: 5, Inaccessible: 3},
+				hasLoginForm: true,
 			},
 		},
 	}
@@ -80,8 +101,8 @@ func Test_Analyze_Success(t *testing.T) {
 
 			mockAnalyzer := mocks.NewMockWebPageAnalyzer(ctrl)
 			mockAnalyzer.EXPECT().
-				Analyze(tt.url).
-				Return(tt.analysisResult, nil).
+				Analyze(gomock.Any(), tt.url, gomock.Any()).
+				Return(tt.expected.toWebPageAnalysis(), nil).
 				Times(1)
 
 			controller := New(mockAnalyzer)
@@ -102,32 +123,46 @@ func Test_Analyze_Success(t *testing.T) {
 				t.Fatalf("failed to decode response: %v", err)
 			}
 
-			if result.HTMLVersion != tt.analysisResult.HTMLVersion {
-				t.Errorf("expected HTML version %q, got %q", tt.analysisResult.HTMLVersion, result.HTMLVersion)
+			expectedNames := []string{dmpg.HTMLVersionSection, dmpg.TitleSection, dmpg.HeadingsSection, dmpg.LinksSection, dmpg.HasLoginFormSection}
+			if !reflect.DeepEqual(result.Names(), expectedNames) {
+				t.Errorf("expected sections %v, got %v", expectedNames, result.Names())
 			}
 
-			if result.Title != tt.analysisResult.Title {
-				t.Errorf("expected title %q, got %q", tt.analysisResult.Title, result.Title)
+			htmlVersion, _ := dmpg.DecodeSection[string](&result, dmpg.HTMLVersionSection)
+			if htmlVersion != tt.expected.htmlVersion {
+				t.Errorf("expected HTML version %q, got %q", tt.expected.htmlVersion, htmlVersion)
 			}
 
-			if !equalHeadings(result.Headings, tt.analysisResult.Headings) {
-				t.Errorf("expected headings %v, got %v", tt.analysisResult.Headings, result.Headings)
+			title, _ := dmpg.DecodeSection[string](&result, dmpg.TitleSection)
+			if title != tt.expected.title {
+				t.Errorf("expected title %q, got %q", tt.expected.title, title)
 			}
 
-			if result.Links.Internal != tt.analysisResult.Links.Internal {
-				t.Errorf("expected %d internal links, got %d", tt.analysisResult.Links.Internal, result.Links.Internal)
+			headings, _ := dmpg.DecodeSection[map[string]int](&result, dmpg.HeadingsSection)
+			if !equalHeadings(headings, tt.expected.headings) {
+				t.Errorf("expected headings %v, got %v", tt.expected.headings, headings)
 			}
 
-			if result.Links.External != tt.analysisResult.Links.External {
-				t.Errorf("expected %d external links, got %d", tt.analysisResult.Links.External, result.Links.External)
+			links, err := dmpg.DecodeSection[dmhtml.LinkAnalysis](&result, dmpg.LinksSection)
+			if err != nil {
+				t.Fatalf("failed to decode links: %v", err)
 			}
 
-			if result.Links.Inaccessible != tt.analysisResult.Links.Inaccessible {
-				t.Errorf("expected %d inaccessible links, got %d", tt.analysisResult.Links.Inaccessible, result.Links.Inaccessible)
+			if links.Internal != tt.expected.links.Internal {
+				t.Errorf("expected %d internal links, got %d", tt.expected.links.Internal, links.Internal)
 			}
 
-			if result.HasLoginForm != tt.analysisResult.HasLoginForm {
-				t.Errorf("expected a login form %v, got %v", tt.analysisResult.HasLoginForm, result.HasLoginForm)
+			if links.External != tt.expected.links.External {
+				t.Errorf("expected %d external links, got %d", tt.expected.links.External, links.External)
+			}
+
+			if links.Inaccessible != tt.expected.links.Inaccessible {
+				t.Errorf("expected %d inaccessible links, got %d", tt.expected.links.Inaccessible, links.Inaccessible)
+			}
+
+			hasLoginForm, _ := dmpg.DecodeSection[bool](&result, dmpg.HasLoginFormSection)
+			if hasLoginForm != tt.expected.hasLoginForm {
+				t.Errorf("expected a login form %v, got %v", tt.expected.hasLoginForm, hasLoginForm)
 			}
 		})
 	}
@@ -251,7 +286,7 @@ func TestAnalyze_AnalyzerError(t *testing.T) {
 
 			mockAnalyzer := mocks.NewMockWebPageAnalyzer(ctrl)
 			mockAnalyzer.EXPECT().
-				Analyze(tt.url).
+				Analyze(gomock.Any(), tt.url, gomock.Any()).
 				Return(nil, tt.analyzerError).
 				Times(1)
 
@@ -277,3 +312,311 @@ func TestAnalyze_AnalyzerError(t *testing.T) {
 		})
 	}
 }
+
+func Test_Analyze_Options(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expectedOpts := &dmpg.AnalyzeOptions{
+		ParserOptions: dmhtml.ParserOptions{CheckResources: true},
+		ForceRefresh:  true,
+	}
+
+	mockAnalyzer := mocks.NewMockWebPageAnalyzer(ctrl)
+	mockAnalyzer.EXPECT().
+		Analyze(gomock.Any(), "https://example.com", expectedOpts).
+		Return(dmpg.NewWebPageAnalysis(), nil).
+		Times(1)
+
+	controller := New(mockAnalyzer)
+
+	requestBody := `{"url": "https://example.com", "check_resources": true, "force_refresh": true}`
+	req := httptest.NewRequest(http.MethodPost, "/api/analyze", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	controller.Analyze(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func Test_Analyze_ErrInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		requestBody   string
+		expectedError string
+	}{
+		{
+			name:          "unknown link classification",
+			requestBody:   `{"url": "https://example.com", "link_classification": "same_planet"}`,
+			expectedError: `unsupported link classification "same_planet"`,
+		},
+		{
+			name:          "custom classification without domains",
+			requestBody:   `{"url": "https://example.com", "link_classification": "custom"}`,
+			expectedError: "first_party_domains is required",
+		},
+		{
+			name:          "login threshold out of range",
+			requestBody:   `{"url": "https://example.com", "login_threshold": 150}`,
+			expectedError: "login_threshold must be between 1 and 100",
+		},
+		{
+			name:          "login threshold of zero",
+			requestBody:   `{"url": "https://example.com", "login_threshold": 0}`,
+			expectedError: "login_threshold must be between 1 and 100",
+		},
+		{
+			name:          "exclude combined with fields",
+			requestBody:   `{"url": "https://example.com", "fields": ["title"], "exclude": ["links"]}`,
+			expectedError: "exclude cannot be combined with fields or include",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockAnalyzer := mocks.NewMockWebPageAnalyzer(ctrl)
+
+			controller := New(mockAnalyzer)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/analyze", strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			controller.Analyze(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+
+			responseBody := strings.TrimSpace(w.Body.String())
+			if !strings.Contains(responseBody, tt.expectedError) {
+				t.Errorf("expected error message should contain %q, got %q", tt.expectedError, responseBody)
+			}
+		})
+	}
+}
+
+func Test_Analyze_SelectedFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expectedOpts := &dmpg.AnalyzeOptions{
+		Fields:  []string{dmpg.TitleSection},
+		Include: []string{dmpg.HTMLVersionSection},
+	}
+
+	analysis := dmpg.NewWebPageAnalysis()
+	analysis.Set(dmpg.HTMLVersionSection, "HTML5")
+	analysis.Set(dmpg.TitleSection, "Title-1")
+	analysis.Meta.Skipped = []string{dmpg.HeadingsSection, dmpg.LinksSection}
+
+	mockAnalyzer := mocks.NewMockWebPageAnalyzer(ctrl)
+	mockAnalyzer.EXPECT().
+		Analyze(gomock.Any(), "https://example.com", expectedOpts).
+		Return(analysis, nil).
+		Times(1)
+
+	controller := New(mockAnalyzer)
+
+	requestBody := `{"url": "https://example.com", "fields": ["title"], "include": ["html_version"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/analyze", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	controller.Analyze(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	expectedBody := `{"html_version":"HTML5","title":"Title-1","meta":{"skipped":["headings","links"]}}`
+	if body := strings.TrimSpace(w.Body.String()); body != expectedBody {
+		t.Errorf("expected body %s, got %s", expectedBody, body)
+	}
+}
+
+func Test_Analyze_ErrUnknownSection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAnalyzer := mocks.NewMockWebPageAnalyzer(ctrl)
+	mockAnalyzer.EXPECT().
+		Analyze(gomock.Any(), "https://example.com", gomock.Any()).
+		Return(nil, fmt.Errorf("%w %q", dmpg.ErrUnknownSection, "favicon")).
+		Times(1)
+
+	controller := New(mockAnalyzer)
+
+	requestBody := `{"url": "https://example.com", "fields": ["favicon"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/analyze", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	controller.Analyze(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if body := strings.TrimSpace(w.Body.String()); body != `unknown section "favicon"` {
+		t.Errorf("expected an unknown section error, got %q", body)
+	}
+}
+
+func Test_Analyze_Status(t *testing.T) {
+	tests := []struct {
+		name                string
+		sections            map[string]dmpg.SectionStatus
+		unencodable         bool
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "success",
+			sections:            map[string]dmpg.SectionStatus{dmpg.TitleSection: {Status: dmpg.SectionOK}},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `{"title":"Example Domain","meta":{"status":"success","sections":{"title":{"status":"ok"}}}}`,
+		},
+		{
+			name: "partial success",
+			sections: map[string]dmpg.SectionStatus{
+				dmpg.TitleSection: {Status: dmpg.SectionOK},
+				dmpg.LinksSection: {Status: dmpg.SectionFailed, Error: "timed out after 30s"},
+			},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `{"title":"Example Domain","meta":{"status":"partial","sections":{"links":{"status":"failed","error":"timed out after 30s"},"title":{"status":"ok"}}}}`,
+		},
+		{
+			name:                "failure",
+			sections:            map[string]dmpg.SectionStatus{dmpg.LinksSection: {Status: dmpg.SectionFailed, Error: "timed out after 30s"}},
+			expectedCode:        http.StatusInternalServerError,
+			expectedContentType: "application/json",
+			expectedBody:        `{"meta":{"status":"failure","sections":{"links":{"status":"failed","error":"timed out after 30s"}}}}`,
+		},
+		{
+			name:                "failure which cannot be encoded",
+			sections:            map[string]dmpg.SectionStatus{dmpg.LinksSection: {Status: dmpg.SectionFailed, Error: "timed out after 30s"}},
+			unencodable:         true,
+			expectedCode:        http.StatusInternalServerError,
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBody:        `Internal server error: json: error calling MarshalJSON for type *webpage.WebPageAnalysis: failed to encode section "links": json: unsupported type: chan int`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			analysis := dmpg.NewWebPageAnalysis()
+			if tt.sections[dmpg.TitleSection].Status == dmpg.SectionOK {
+				analysis.Set(dmpg.TitleSection, "Example Domain")
+			}
+			if tt.unencodable {
+				analysis.Set(dmpg.LinksSection, make(chan int))
+			}
+			analysis.Meta.Sections = tt.sections
+			analysis.Meta.Status = dmpg.OverallStatus(tt.sections)
+
+			mockAnalyzer := mocks.NewMockWebPageAnalyzer(ctrl)
+			mockAnalyzer.EXPECT().
+				Analyze(gomock.Any(), "https://example.com", gomock.Any()).
+				Return(analysis, nil).
+				Times(1)
+
+			controller := New(mockAnalyzer)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/analyze", strings.NewReader(`{"url": "https://example.com"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			controller.Analyze(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+
+			if contentType := w.Result().Header.Get("Content-Type"); contentType != tt.expectedContentType {
+				t.Errorf("expected content type %s, got %s", tt.expectedContentType, contentType)
+			}
+
+			if body := strings.TrimSpace(w.Body.String()); body != tt.expectedBody {
+				t.Errorf("expected body %s, got %s", tt.expectedBody, body)
+			}
+		})
+	}
+}
+
+func Test_Analyze_CacheControl(t *testing.T) {
+	tests := []struct {
+		name                 string
+		status               string
+		expiresIn            time.Duration
+		expectedCacheControl string
+	}{
+		{
+			name:                 "cached analysis is reused until it goes stale",
+			status:               dmpg.StatusSuccess,
+			expiresIn:            10*time.Minute + 30*time.Second,
+			expectedCacheControl: "private, max-age=630",
+		},
+		{
+			name:                 "analysis which went stale meanwhile",
+			status:               dmpg.StatusSuccess,
+			expiresIn:            -time.Minute,
+			expectedCacheControl: "private, max-age=0",
+		},
+		{
+			name:                 "analysis which was not cached",
+			status:               dmpg.StatusSuccess,
+			expectedCacheControl: "no-store",
+		},
+		{
+			name:                 "partial results",
+			status:               dmpg.StatusPartial,
+			expectedCacheControl: "no-store",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			analysis := dmpg.NewWebPageAnalysis()
+			analysis.Set(dmpg.TitleSection, "Example Domain")
+			analysis.Meta.Status = tt.status
+			if tt.expiresIn != 0 {
+				// Leave a moment for the request to be handled
+				analysis.Meta.ExpiresAt = time.Now().Add(tt.expiresIn + 500*time.Millisecond)
+			}
+
+			mockAnalyzer := mocks.NewMockWebPageAnalyzer(ctrl)
+			mockAnalyzer.EXPECT().
+				Analyze(gomock.Any(), "https://example.com", gomock.Any()).
+				Return(analysis, nil).
+				Times(1)
+
+			controller := New(mockAnalyzer)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/analyze", strings.NewReader(`{"url": "https://example.com"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			controller.Analyze(w, req)
+
+			if cacheControl := w.Header().Get("Cache-Control"); cacheControl != tt.expectedCacheControl {
+				t.Errorf("expected Cache-Control %q, got %q", tt.expectedCacheControl, cacheControl)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+
+	clihttp "web-pages-analyzer/internal/domain/clients/http"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+)
+
+// Fetched and parsed page handed to every analyzer
+type Document struct {
+	URL       string // Final URL of the page after redirects
+	Root      *html.Node
+	Size      int64 // Size of the HTML document in bytes
+	Response  *http.Response
+	Redirects *clihttp.RedirectChain
+	Options   dmhtml.ParserOptions
+	Client    clihttp.HttpClient // For analyzers which make follow-up requests, e.g. link checks
+
+	// Set instead of Root for documents too large to hold in memory. Streams the body to the visitors
+	// without building its tree, the body can only be read once so the document is walked by Traverse alone.
+	Stream func(visitors ...Visitor) error
+
+	mu   sync.Mutex
+	memo map[string]*memoEntry
+}
+
+type memoEntry struct {
+	once  sync.Once
+	value any
+}
+
+// Compute a value once per document and share it between analyzers, e.g. the form analysis
+// behind both the "forms" and "has_login_form" sections
+func (d *Document) Memo(key string, compute func() any) any {
+	d.mu.Lock()
+	if d.memo == nil {
+		d.memo = make(map[string]*memoEntry)
+	}
+	entry, ok := d.memo[key]
+	if !ok {
+		entry = &memoEntry{}
+		d.memo[key] = entry
+	}
+	d.mu.Unlock()
+
+	entry.once.Do(func() { entry.value = compute() })
+
+	return entry.value
+}
+
+// An analyzer produces one section of the web page analysis, keyed by its name in the response.
+// An analyzer interrupted through its context may return the part of its section computed so far
+// along with the error, the section is then reported incomplete.
+type Analyzer interface {
+	Name() string
+	Analyze(ctx context.Context, doc *Document) (any, error)
+}
+
+// Time limits of the analyzers, zero means no limit
+type Timeouts struct {
+	Default     time.Duration            // Limit of the analyzers without one of their own
+	PerAnalyzer map[string]time.Duration // Limits by analyzer name
+}
+
+func (t Timeouts) For(name string) time.Duration {
+	if timeout, ok := t.PerAnalyzer[name]; ok {
+		return timeout
+	}
+
+	return t.Default
+}
+
+type DocumentParser interface {
+	Parse(body io.Reader, pageURL string, client clihttp.HttpClient, opts *dmhtml.ParserOptions) (*Document, error)
+	// Prepare a streamed document, the body is read when the document is traversed
+	Stream(body io.Reader, pageURL string, client clihttp.HttpClient, opts *dmhtml.ParserOptions) (*Document, error)
+}
+
+// Implemented by analyzers which can analyze streamed documents
+type StreamingAnalyzer interface {
+	Analyzer
+	Streaming() bool
+}
+
+// Report whether the analyzer computes its section of a streamed document
+func Streams(analyzer Analyzer) bool {
+	streaming, ok := analyzer.(StreamingAnalyzer)
+	return ok && streaming.Streaming()
+}
+
+// Computes the section of an analyzer
+type AnalyzeFunc func(ctx context.Context, doc *Document) (any, error)
+
+type analyzerFunc struct {
+	name    string
+	analyze AnalyzeFunc
+}
+
+// Create an analyzer from a function
+func NewAnalyzer(name string, analyze AnalyzeFunc) Analyzer {
+	return &analyzerFunc{name: name, analyze: analyze}
+}
+
+func (a *analyzerFunc) Name() string {
+	return a.name
+}
+
+func (a *analyzerFunc) Analyze(ctx context.Context, doc *Document) (any, error) {
+	return a.analyze(ctx, doc)
+}
+
+type responseAnalyzerFunc struct {
+	analyzerFunc
+}
+
+// Create an analyzer which only reads the HTTP response and the redirects, never the HTML document,
+// so it also analyzes streamed documents
+func NewResponseAnalyzer(name string, analyze AnalyzeFunc) StreamingAnalyzer {
+	return &responseAnalyzerFunc{analyzerFunc: analyzerFunc{name: name, analyze: analyze}}
+}
+
+func (a *responseAnalyzerFunc) Streaming() bool {
+	return true
+}
+
+// Registry of the analyzers run for every page, in registration order
+type Registry struct {
+	analyzers []Analyzer
+	names     map[string]bool
+	reserved  map[string]bool
+}
+
+// Create a registry, analyzers may not use the reserved names, e.g. keys the response uses for itself
+func NewRegistry(reserved ...string) *Registry {
+	r := &Registry{names: make(map[string]bool), reserved: make(map[string]bool)}
+	for _, name := range reserved {
+		r.reserved[name] = true
+	}
+
+	return r
+}
+
+func (r *Registry) Register(analyzers ...Analyzer) error {
+	for _, analyzer := range analyzers {
+		name := analyzer.Name()
+		if name == "" {
+			return fmt.Errorf("analyzer name is required")
+		}
+		if r.reserved[name] {
+			return fmt.Errorf("analyzer name %q is reserved", name)
+		}
+		if r.names[name] {
+			return fmt.Errorf("analyzer %q is already registered", name)
+		}
+
+		r.names[name] = true
+		r.analyzers = append(r.analyzers, analyzer)
+	}
+
+	return nil
+}
+
+func (r *Registry) Analyzers() []Analyzer {
+	return r.analyzers
+}
+
+func (r *Registry) Has(name string) bool {
+	return r.names[name]
+}
@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"golang.org/x/net/html"
+)
+
+// Receives the nodes of a document in document order
+type Visitor interface {
+	Enter(node *html.Node) // Before the children of the node
+	Leave(node *html.Node) // After the children of the node
+}
+
+// Creates the visitor of a document, analyzers which share what they collect use the same key
+type VisitorSpec struct {
+	Key string
+	New func(doc *Document) Visitor
+	// The visitor also works on streamed documents, whose nodes are detached from the tree apart from
+	// the subtrees the stream keeps whole
+	Streaming bool
+}
+
+// An analyzer which collects what it needs during a traversal of the document shared with the other
+// analyzers instead of walking the document itself, it reads its visitors back with Document.Visitor
+type VisitingAnalyzer interface {
+	Analyzer
+	Visitors() []VisitorSpec
+}
+
+// Walk the tree once, handing every node to each visitor
+func Walk(root *html.Node, visitors ...Visitor) {
+	for _, visitor := range visitors {
+		visitor.Enter(root)
+	}
+
+	for child := root.FirstChild; child != nil; child = child.NextSibling {
+		Walk(child, visitors...)
+	}
+
+	for _, visitor := range visitors {
+		visitor.Leave(root)
+	}
+}
+
+// Walk the document once with the visitors of every visiting analyzer, each distinct key gets one visitor.
+// The error is the one of a streamed document whose body could not be read.
+func (d *Document) Traverse(analyzers ...Analyzer) error {
+	var specs []VisitorSpec
+	seen := make(map[string]bool)
+
+	for _, analyzer := range analyzers {
+		visiting, ok := analyzer.(VisitingAnalyzer)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range visiting.Visitors() {
+			if !seen[spec.Key] {
+				seen[spec.Key] = true
+				specs = append(specs, spec)
+			}
+		}
+	}
+
+	if len(specs) == 0 || (d.Root == nil && d.Stream == nil) {
+		return nil
+	}
+
+	visitors := make([]Visitor, len(specs))
+	for i, spec := range specs {
+		visitors[i] = spec.New(d)
+	}
+
+	if d.Root != nil {
+		Walk(d.Root, visitors...)
+	} else if err := d.Stream(visitors...); err != nil {
+		return err
+	}
+
+	for i, spec := range specs {
+		visitor := visitors[i]
+		d.Memo(visitorMemoKey(spec.Key), func() any { return visitor })
+	}
+
+	return nil
+}
+
+// Visitor of the document which has seen every node, the document is walked for this visitor alone
+// when no traversal included it. A streamed document is not walked again, the visitor is left empty.
+func (d *Document) Visitor(spec VisitorSpec) Visitor {
+	return d.Memo(visitorMemoKey(spec.Key), func() any {
+		visitor := spec.New(d)
+		if d.Root != nil {
+			Walk(d.Root, visitor)
+		}
+		return visitor
+	}).(Visitor)
+}
+
+func visitorMemoKey(key string) string {
+	return "visitor:" + key
+}
+
+type visitingAnalyzerFunc struct {
+	analyzerFunc
+	visitors []VisitorSpec
+}
+
+// Create a visiting analyzer from the visitors it reads and a function computing its section from them
+func NewVisitingAnalyzer(name string, visitors []VisitorSpec, analyze AnalyzeFunc) VisitingAnalyzer {
+	return &visitingAnalyzerFunc{
+		analyzerFunc: analyzerFunc{name: name, analyze: analyze},
+		visitors:     visitors,
+	}
+}
+
+func (a *visitingAnalyzerFunc) Visitors() []VisitorSpec {
+	return a.visitors
+}
+
+// A visiting analyzer streams when all of its visitors do
+func (a *visitingAnalyzerFunc) Streaming() bool {
+	for _, spec := range a.visitors {
+		if !spec.Streaming {
+			return false
+		}
+	}
+
+	return len(a.visitors) > 0
+}
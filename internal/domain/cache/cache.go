@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"time"
+
+	clihttp "web-pages-analyzer/internal/domain/clients/http"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+)
+
+// An analysis kept for the page and options it was computed for
+type Entry struct {
+	Analysis   *dmpg.WebPageAnalysis `json:"analysis"`
+	Validators clihttp.Validators    `json:"validators"` // Validators of the analyzed response, to revalidate the entry once it is stale
+	StoredAt   time.Time             `json:"stored_at"`  // When the entry was computed or last revalidated
+}
+
+// Age of the entry at the given time
+func (e *Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.StoredAt)
+}
+
+// Stores analyses by key. Entries are returned whatever their age, the caller decides whether they are
+// still fresh. Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry) error
+}
@@ -5,6 +5,8 @@ import "fmt"
 type httpError struct {
 	StatusCode int
 	Message    string
+	// Redirects followed before the failing response, when a response was received
+	Redirects *RedirectChain
 }
 
 func NewHttpError(statusCode int, message string) *httpError {
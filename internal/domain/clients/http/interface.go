@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"net/http"
 )
 
@@ -10,7 +11,27 @@ type HttpClientCfg struct {
 	Transport    http.RoundTripper
 }
 
+// Validators of a response fetched before, sent back to get the page again only when it changed
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Read the validators of a response
+func ValidatorsOf(resp *http.Response) Validators {
+	return Validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+}
+
+func (v Validators) IsEmpty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
 type HttpClient interface {
-	Get(url string) (*http.Response, error)
-	Head(url string) (*http.Response, error)
+	// The request and the reading of the body are abandoned once the context is done
+	Get(ctx context.Context, url string) (*http.Response, error)
+	// Same as Get, but a page which did not change since the validators were read comes back as a
+	// 304 Not Modified response without a body
+	GetConditional(ctx context.Context, url string, validators Validators) (*http.Response, error)
+	// The request is abandoned once the context is done
+	Head(ctx context.Context, url string) (*http.Response, error)
 }
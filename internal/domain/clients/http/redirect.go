@@ -0,0 +1,114 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+)
+
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+}
+
+type RedirectChain struct {
+	URL       string        `json:"url"`
+	FinalURL  string        `json:"final_url"`
+	Hops      []RedirectHop `json:"hops"`
+	Loop      bool          `json:"loop"`
+	Downgrade bool          `json:"https_downgrade"`
+	Excessive bool          `json:"excessive"`
+}
+
+// Build the redirect chain that led to the given response by walking back
+// through the requests recorded by the http client
+func NewRedirectChain(requestURL string, resp *http.Response) *RedirectChain {
+	chain := &RedirectChain{
+		URL:      requestURL,
+		FinalURL: requestURL,
+		Hops:     []RedirectHop{},
+	}
+
+	if resp == nil || resp.Request == nil {
+		return chain
+	}
+
+	chain.FinalURL = resp.Request.URL.String()
+
+	var hops []RedirectHop
+	var targets []*url.URL
+
+	// The client stopped following redirects, so the final response is a hop as well
+	stopped := isRedirect(resp)
+	if stopped {
+		hop, target := newRedirectHop(resp)
+		hops = append(hops, hop)
+		targets = append(targets, target)
+	}
+
+	for req := resp.Request; req.Response != nil && req.Response.Request != nil; req = req.Response.Request {
+		hop, target := newRedirectHop(req.Response)
+		hops = append(hops, hop)
+		targets = append(targets, target)
+	}
+
+	// Hops were collected from the last to the first
+	for i := len(hops) - 1; i >= 0; i-- {
+		chain.Hops = append(chain.Hops, hops[i])
+
+		if target := targets[i]; target != nil && target.Scheme == "http" && isHttps(hops[i].URL) {
+			chain.Downgrade = true
+		}
+	}
+
+	if stopped {
+		if target := targets[0]; target != nil && chain.visited(target.String()) {
+			chain.Loop = true
+		} else {
+			chain.Excessive = true
+		}
+	}
+
+	return chain
+}
+
+func (c *RedirectChain) visited(targetURL string) bool {
+	for _, hop := range c.Hops {
+		if hop.URL == targetURL {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newRedirectHop(resp *http.Response) (RedirectHop, *url.URL) {
+	hop := RedirectHop{
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Location:   resp.Header.Get("Location"),
+	}
+
+	target, err := resp.Request.URL.Parse(hop.Location)
+	if err != nil || hop.Location == "" {
+		return hop, nil
+	}
+
+	return hop, target
+}
+
+// Check whether the response is a redirect the http client would have followed
+func isRedirect(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return resp.Header.Get("Location") != ""
+	}
+
+	return false
+}
+
+func isHttps(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && parsed.Scheme == "https"
+}
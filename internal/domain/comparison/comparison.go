@@ -0,0 +1,88 @@
+package comparison
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	dmhtml "web-pages-analyzer/internal/domain/html"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+)
+
+// Returned for a target which names neither or both of a history record and a URL
+var ErrInvalidTarget = errors.New("a comparison target needs either a history_id or a url")
+
+// Sections compared by a diff, the pages analyzed for a comparison only compute these
+var ComparedSections = []string{
+	dmpg.HTMLVersionSection,
+	dmpg.TitleSection,
+	dmpg.HeadingsSection,
+	dmpg.HeadingOutlineSection,
+	dmpg.LinksSection,
+	dmpg.HasLoginFormSection,
+}
+
+// One side of a comparison: a past analysis from the history, or the page at a URL analyzed now
+type Target struct {
+	HistoryID string `json:"history_id,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+func (t Target) Validate() error {
+	if (t.HistoryID == "") == (t.URL == "") {
+		return ErrInvalidTarget
+	}
+
+	return nil
+}
+
+// The analysis a side of the comparison ended up with
+type Source struct {
+	URL        string    `json:"url"`
+	HistoryID  string    `json:"history_id,omitempty"` // Record of the analysis, when it was recorded
+	AnalyzedAt time.Time `json:"analyzed_at,omitzero"` // When the recorded analysis was computed
+}
+
+// A value which differs between the two analyses
+type Change[T any] struct {
+	Before T `json:"before"`
+	After  T `json:"after"`
+}
+
+// Headings of the two analyses. Headings are identified by their level and text, a heading whose level or
+// text changed is removed and another one added.
+type HeadingsDiff struct {
+	Levels  map[string]Change[int] `json:"levels"`  // Heading levels whose count changed, e.g. "h2"
+	Added   []dmhtml.Heading       `json:"added"`   // In the order of the after outline
+	Removed []dmhtml.Heading       `json:"removed"` // In the order of the before outline
+	Moved   []dmhtml.Heading       `json:"moved"`   // Kept but placed elsewhere among the other kept headings
+}
+
+// Links of the two analyses. Internal links are compared by path and query, so that the pages of two
+// hosts of the same site, such as staging and production, can be compared.
+type LinksDiff struct {
+	Added       []string `json:"added"`
+	Removed     []string `json:"removed"`
+	NewlyBroken []string `json:"newly_broken"` // Broken after and not before, including added links
+	Fixed       []string `json:"fixed"`        // Broken before and not after, excluding removed links
+}
+
+// What changed from one analysis of a page to the other, unchanged sections are left out
+type Diff struct {
+	Before       Source          `json:"before"`
+	After        Source          `json:"after"`
+	Changed      bool            `json:"changed"`
+	HTMLVersion  *Change[string] `json:"html_version,omitempty"`
+	Title        *Change[string] `json:"title,omitempty"`
+	Headings     *HeadingsDiff   `json:"headings,omitempty"`
+	Links        *LinksDiff      `json:"links,omitempty"`
+	HasLoginForm *Change[bool]   `json:"has_login_form,omitempty"`
+	Missing      []string        `json:"missing,omitempty"` // Compared sections absent from either analysis
+	// The link checks of either analysis are incomplete, links are compared without newly broken or fixed ones
+	BrokenLinksUnchecked bool `json:"broken_links_unchecked,omitempty"`
+}
+
+type Comparer interface {
+	// Compare two analyses, the options apply to the targets given by URL
+	Compare(ctx context.Context, before, after Target, opts *dmpg.AnalyzeOptions) (*Diff, error)
+}
@@ -0,0 +1,29 @@
+package extraction
+
+import (
+	"context"
+	"errors"
+)
+
+// Name of the analyzer extracting the article of a page
+const ArticleSection = "article"
+
+// Returned when the page has no visible text to extract
+var ErrNoMainContent = errors.New("no main content found on the page")
+
+// Main content of a page stripped of navigation, ads and other boilerplate
+type Article struct {
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	Byline        string `json:"byline"`
+	PublishedDate string `json:"published_date"`
+	LeadImage     string `json:"lead_image"`
+	HTML          string `json:"html"`
+	Markdown      string `json:"markdown"`
+	Text          string `json:"text"`
+	WordCount     int    `json:"word_count"`
+}
+
+type ContentExtractor interface {
+	Extract(ctx context.Context, url string) (*Article, error)
+}
@@ -0,0 +1,36 @@
+package fingerprint
+
+import "net/http"
+
+// Categories of the technologies shipped in the default ruleset
+const (
+	CMSCategory         = "cms"
+	JSFrameworkCategory = "javascript-framework"
+	UIFrameworkCategory = "ui-framework"
+	AnalyticsCategory   = "analytics"
+	CDNCategory         = "cdn"
+	WebServerCategory   = "web-server"
+	LanguageCategory    = "programming-language"
+	EcommerceCategory   = "ecommerce"
+)
+
+// Everything a signature can match against, collected from the response and the parsed document
+type Evidence struct {
+	Header        http.Header
+	Meta          map[string][]string
+	ScriptSrcs    []string
+	InlineScripts []string
+	Stylesheets   []string
+	Classes       []string
+}
+
+type Technology struct {
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Version  string   `json:"version,omitempty"`
+	Evidence []string `json:"evidence"`
+}
+
+type Fingerprinter interface {
+	Fingerprint(evidence *Evidence) []Technology
+}
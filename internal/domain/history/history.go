@@ -0,0 +1,28 @@
+package history
+
+import (
+	"errors"
+	"time"
+
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+)
+
+// Returned for an ID which names no record, including the records removed by the retention limits
+var ErrNotFound = errors.New("history record not found")
+
+// An analysis as it was computed, with the request which computed it
+type Record struct {
+	ID        string                `json:"id"`
+	URL       string                `json:"url"`
+	Options   dmpg.AnalyzeOptions   `json:"options"`
+	CreatedAt time.Time             `json:"created_at"`
+	Status    string                `json:"status"`             // Overall status of the analysis
+	Analysis  *dmpg.WebPageAnalysis `json:"analysis,omitempty"` // Left out of listings, kept last so that they can skip it
+}
+
+// Past analyses of the pages
+type History interface {
+	List(url string, limit int) ([]Record, error) // Records of the page, newest first, without their analyses
+	Get(id string) (*Record, error)
+	Delete(id string) error
+}
@@ -0,0 +1,18 @@
+package history
+
+import "time"
+
+// How long records are kept, a zero limit keeps them
+type Retention struct {
+	MaxPerURL int           // Records kept for each page, the oldest are removed first
+	MaxAge    time.Duration // Age at which a record is removed
+}
+
+// Keeps the records of the analyses. Save sets the ID of the record, the other methods identify pages
+// by their normalized URL. Implementations must be safe for concurrent use.
+type Store interface {
+	Save(record *Record) error
+	List(url string, limit int) ([]Record, error)
+	Get(id string) (*Record, error)
+	Delete(id string) error
+}
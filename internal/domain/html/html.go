@@ -1,15 +1,295 @@
 package html
 
+import (
+	"fmt"
+
+	clihttp "web-pages-analyzer/internal/domain/clients/http"
+)
+
+// Rendering modes a browser selects based on the document's DOCTYPE
+const (
+	StandardsMode       = "standards"
+	AlmostStandardsMode = "almost-standards"
+	QuirksMode          = "quirks"
+)
+
+// Modes for deciding whether a link or resource belongs to the analyzed site
+const (
+	ExactHostClassification  = "exact_host"
+	SameSiteClassification   = "same_site"
+	SameDomainClassification = "same_domain"
+	CustomClassification     = "custom"
+)
+
+// Categories of links which do not navigate to an HTTP(S) page
+const (
+	MailtoScheme     = "mailto"
+	TelScheme        = "tel"
+	JavascriptScheme = "javascript"
+	DataScheme       = "data"
+	OtherScheme      = "other"
+)
+
+// Purposes detected for a form
+const (
+	LoginForm      = "login"
+	SignupForm     = "signup"
+	SearchForm     = "search"
+	NewsletterForm = "newsletter"
+	PaymentForm    = "payment"
+	ContactForm    = "contact"
+	OtherForm      = "other"
+)
+
+// Login detection score at or above which the page is considered to have a login form
+const DefaultLoginThreshold = 50
+
+// Reading speed used to estimate the reading time of the visible text
+const ReadingWordsPerMinute = 200
+
+// Resource types reported by the resource inventory
+const (
+	ScriptResource     = "script"
+	StylesheetResource = "stylesheet"
+	ImageResource      = "image"
+	IframeResource     = "iframe"
+	FontResource       = "font"
+	MediaResource      = "media"
+)
+
+// Mixed content categories, active content can take over the page when tampered with
+const (
+	ActiveMixedContent  = "active"
+	PassiveMixedContent = "passive"
+)
+
+// A heading of the page outline, in document order
+type Heading struct {
+	Level string `json:"level"` // e.g. "h2"
+	Text  string `json:"text"`
+}
+
 type LinkAnalysis struct {
-	Internal     int `json:"internal"`
-	External     int `json:"external"`
-	Inaccessible int `json:"inaccessible"`
+	Classification string                  `json:"classification"`
+	BaseURL        string                  `json:"base_url"`
+	Internal       int                     `json:"internal"`
+	External       int                     `json:"external"`
+	Inaccessible   int                     `json:"inaccessible"`
+	Unchecked      int                     `json:"unchecked,omitempty"` // Links whose check was interrupted
+	Redirected     int                     `json:"redirected"`
+	Redirects      []clihttp.RedirectChain `json:"redirects"`
+	Hygiene        LinkHygiene             `json:"hygiene"`
+	Fragments      FragmentAnalysis        `json:"fragments"`
+	NonHTTP        SchemeLinkAnalysis      `json:"non_http"`
+	Details        []Link                  `json:"details"`
+	Incomplete     bool                    `json:"incomplete,omitempty"` // Link or fragment checks were interrupted
+}
+
+// Fragment link such as "#pricing" whose target id or name does not exist
+type BrokenAnchor struct {
+	Href     string `json:"href"`
+	URL      string `json:"url"`
+	Fragment string `json:"fragment"`
+	SamePage bool   `json:"same_page"`
+	Location string `json:"location"`
+}
+
+// Link with a mailto:, tel:, javascript:, data: or another non-HTTP scheme
+type SchemeLink struct {
+	Href     string `json:"href"`
+	Scheme   string `json:"scheme"`
+	Category string `json:"category"`
+	Valid    bool   `json:"valid"`
+	Issue    string `json:"issue,omitempty"`
+	Location string `json:"location"`
+}
+
+type SchemeLinkAnalysis struct {
+	Counts  map[string]int `json:"counts"`
+	Invalid int            `json:"invalid"`
+	Issues  int            `json:"issues"`
+	Links   []SchemeLink   `json:"links"`
+}
+
+type FragmentAnalysis struct {
+	Checked        int            `json:"checked"`
+	Broken         int            `json:"broken"`
+	Unverified     int            `json:"unverified"`
+	TargetsFetched bool           `json:"targets_fetched"`
+	BrokenAnchors  []BrokenAnchor `json:"broken_anchors"`
+}
+
+// Attributes of an anchor which matter for SEO and link hygiene
+type Link struct {
+	URL               string   `json:"url"`
+	Internal          bool     `json:"internal"`
+	Rel               []string `json:"rel"`
+	NoFollow          bool     `json:"nofollow"`
+	Sponsored         bool     `json:"sponsored"`
+	UGC               bool     `json:"ugc"`
+	NoOpener          bool     `json:"noopener"`
+	NoReferrer        bool     `json:"noreferrer"`
+	UnsafeTargetBlank bool     `json:"unsafe_target_blank"`
+	Download          bool     `json:"download"`
+	Hreflang          string   `json:"hreflang,omitempty"`
+	Inaccessible      bool     `json:"inaccessible,omitempty"` // The link check failed
+}
+
+// Number of links carrying each attribute
+type LinkHygiene struct {
+	NoFollow          int `json:"nofollow"`
+	Sponsored         int `json:"sponsored"`
+	UGC               int `json:"ugc"`
+	NoOpener          int `json:"noopener"`
+	NoReferrer        int `json:"noreferrer"`
+	UnsafeTargetBlank int `json:"unsafe_target_blank"`
+	Download          int `json:"download"`
+	Hreflang          int `json:"hreflang"`
+}
+
+type DoctypeAnalysis struct {
+	Name          string `json:"name"`
+	PublicID      string `json:"public_id"`
+	SystemID      string `json:"system_id"`
+	DTD           string `json:"dtd"`
+	RenderingMode string `json:"rendering_mode"`
+}
+
+type MixedContentItem struct {
+	URL       string `json:"url"`
+	Element   string `json:"element"`
+	Attribute string `json:"attribute"`
+	Type      string `json:"type"`
+	Location  string `json:"location"`
+}
+
+type MixedContentAnalysis struct {
+	Applicable bool               `json:"applicable"`
+	Active     int                `json:"active"`
+	Passive    int                `json:"passive"`
+	Items      []MixedContentItem `json:"items"`
+}
+
+type Resource struct {
+	URL        string `json:"url"`
+	Type       string `json:"type"`
+	Element    string `json:"element"`
+	Inline     bool   `json:"inline"`
+	Async      bool   `json:"async"`
+	Defer      bool   `json:"defer"`
+	Integrity  bool   `json:"integrity"`
+	FirstParty bool   `json:"first_party"`
+	Accessible *bool  `json:"accessible,omitempty"`
+}
+
+type HostResources struct {
+	Host       string         `json:"host"`
+	FirstParty bool           `json:"first_party"`
+	Count      int            `json:"count"`
+	Types      map[string]int `json:"types"`
 }
 
-type HtmlParser interface {
-	GetHtmlVersion() string
-	GetTitle() string
-	CountHeadingLevels() map[string]int
-	HasLoginForm() bool
-	AnalyzeLinks() *LinkAnalysis
+type ResourceInventory struct {
+	Scripts      []Resource      `json:"scripts"`
+	Stylesheets  []Resource      `json:"stylesheets"`
+	Images       []Resource      `json:"images"`
+	Iframes      []Resource      `json:"iframes"`
+	Fonts        []Resource      `json:"fonts"`
+	Media        []Resource      `json:"media"`
+	Hosts        []HostResources `json:"hosts"`
+	FirstParty   int             `json:"first_party"`
+	ThirdParty   int             `json:"third_party"`
+	Checked      bool            `json:"checked"`
+	Inaccessible int             `json:"inaccessible"`
+	Incomplete   bool            `json:"incomplete,omitempty"` // Checks were interrupted, unchecked resources have no accessibility
+}
+
+type FormField struct {
+	Element      string `json:"element"`
+	Type         string `json:"type"`
+	Name         string `json:"name"`
+	ID           string `json:"id"`
+	Required     bool   `json:"required"`
+	Autocomplete string `json:"autocomplete"`
+}
+
+type Form struct {
+	Method         string      `json:"method"`
+	Action         string      `json:"action"`
+	InsecureAction bool        `json:"insecure_action"`
+	ThirdParty     bool        `json:"third_party"`
+	Purpose        string      `json:"purpose"`
+	Fields         []FormField `json:"fields"`
+	HasCSRFToken   bool        `json:"has_csrf_token"`
+	HasCaptcha     bool        `json:"has_captcha"`
+	LoginScore     int         `json:"login_score"`
+	Location       string      `json:"location"`
+}
+
+// Evidence of a login UI and how much it adds to the confidence score
+type LoginSignal struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+	Detail string `json:"detail"`
+}
+
+type LoginDetection struct {
+	Score     int           `json:"score"`
+	Threshold int           `json:"threshold"`
+	Detected  bool          `json:"detected"`
+	Signals   []LoginSignal `json:"signals"`
+}
+
+type FormAnalysis struct {
+	Count        int            `json:"count"`
+	HasLoginForm bool           `json:"has_login_form"`
+	Login        LoginDetection `json:"login"`
+	Purposes     map[string]int `json:"purposes"`
+	Forms        []Form         `json:"forms"`
+}
+
+// A keyword or phrase of the visible text, density is the percentage of all words it accounts for
+type Keyword struct {
+	Term    string  `json:"term"`
+	Count   int     `json:"count"`
+	Density float64 `json:"density"`
+}
+
+type ContentAnalysis struct {
+	WordCount          int       `json:"word_count"`
+	SentenceCount      int       `json:"sentence_count"`
+	ReadingTimeSeconds int       `json:"reading_time_seconds"`
+	FleschReadingEase  float64   `json:"flesch_reading_ease"`
+	TextBytes          int       `json:"text_bytes"`
+	HTMLBytes          int       `json:"html_bytes"`
+	TextToHTMLRatio    float64   `json:"text_to_html_ratio"`
+	Keywords           []Keyword `json:"keywords"`
+	Bigrams            []Keyword `json:"bigrams"`
+	Trigrams           []Keyword `json:"trigrams"`
+}
+
+type ParserOptions struct {
+	CheckResources     bool     `json:"check_resources"`
+	CheckFragments     bool     `json:"check_fragments"`
+	LoginThreshold     *int     `json:"login_threshold"` // nil for DefaultLoginThreshold
+	LinkClassification string   `json:"link_classification"`
+	FirstPartyDomains  []string `json:"first_party_domains"`
+}
+
+func (o ParserOptions) Validate() error {
+	if o.LoginThreshold != nil && (*o.LoginThreshold < 1 || *o.LoginThreshold > 100) {
+		return fmt.Errorf("login_threshold must be between 1 and 100")
+	}
+
+	switch o.LinkClassification {
+	case "", ExactHostClassification, SameSiteClassification, SameDomainClassification:
+		return nil
+	case CustomClassification:
+		if len(o.FirstPartyDomains) == 0 {
+			return fmt.Errorf("first_party_domains is required for the %q link classification", CustomClassification)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported link classification %q", o.LinkClassification)
 }
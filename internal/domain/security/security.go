@@ -0,0 +1,52 @@
+package security
+
+import (
+	"net/http"
+	"time"
+)
+
+// Outcome of a single security check
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+type HeaderCheck struct {
+	Name    string `json:"name"`
+	Present bool   `json:"present"`
+	Value   string `json:"value"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type CookieCheck struct {
+	Name     string   `json:"name"`
+	Secure   bool     `json:"secure"`
+	HttpOnly bool     `json:"http_only"`
+	SameSite string   `json:"same_site"`
+	Issues   []string `json:"issues"`
+}
+
+type TLSAnalysis struct {
+	Version         string    `json:"version"`
+	CipherSuite     string    `json:"cipher_suite"`
+	Issuer          string    `json:"issuer"`
+	Subject         string    `json:"subject"`
+	NotAfter        time.Time `json:"not_after"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+	Status          string    `json:"status"`
+	Issues          []string  `json:"issues"`
+}
+
+type SecurityAnalysis struct {
+	Score   int           `json:"score"`
+	Grade   string        `json:"grade"`
+	Headers []HeaderCheck `json:"headers"`
+	Cookies []CookieCheck `json:"cookies"`
+	TLS     *TLSAnalysis  `json:"tls"`
+}
+
+type SecurityAnalyzer interface {
+	Analyze(resp *http.Response) *SecurityAnalysis
+}
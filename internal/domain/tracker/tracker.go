@@ -0,0 +1,58 @@
+package tracker
+
+// Categories of the third parties in the tracker list
+const (
+	AnalyticsCategory     = "analytics"
+	AdvertisingCategory   = "advertising"
+	SocialCategory        = "social"
+	SessionReplayCategory = "session-replay"
+	TagManagerCategory    = "tag-manager"
+	ConsentCategory       = "consent"
+)
+
+// How the page contacts a third party
+const (
+	ScriptRequest = "script"
+	IframeRequest = "iframe"
+	PixelRequest  = "pixel"
+	ImageRequest  = "image"
+	LinkRequest   = "link"
+)
+
+type Request struct {
+	URL  string
+	Host string
+	Kind string
+}
+
+// Third-party requests made by the page and the elements which look like a consent banner
+type Evidence struct {
+	Requests        []Request
+	ConsentElements []string
+}
+
+type ThirdParty struct {
+	Host     string   `json:"host"`
+	Name     string   `json:"name,omitempty"`
+	Company  string   `json:"company,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Kinds    []string `json:"kinds"`
+	Requests int      `json:"requests"`
+}
+
+type ConsentBanner struct {
+	Detected bool     `json:"detected"`
+	Provider string   `json:"provider,omitempty"`
+	Evidence []string `json:"evidence"`
+}
+
+type TrackerAnalysis struct {
+	ThirdParties []ThirdParty   `json:"third_parties"`
+	Trackers     int            `json:"trackers"`
+	Categories   map[string]int `json:"categories"`
+	Consent      ConsentBanner  `json:"consent"`
+}
+
+type TrackerDetector interface {
+	Detect(evidence *Evidence) *TrackerAnalysis
+}
@@ -1,17 +1,289 @@
 package webpage
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
 	dmhtml "web-pages-analyzer/internal/domain/html"
 )
 
+// Sections of the built-in analyzers
+const (
+	HTMLVersionSection    = "html_version"
+	DoctypeSection        = "doctype"
+	TitleSection          = "title"
+	HeadingsSection       = "headings"
+	HeadingOutlineSection = "heading_outline"
+	LinksSection          = "links"
+	HasLoginFormSection   = "has_login_form"
+	FormsSection          = "forms"
+	RedirectsSection      = "redirects"
+	SecuritySection       = "security"
+	MixedContentSection   = "mixed_content"
+	ResourcesSection      = "resources"
+	TechnologiesSection   = "technologies"
+	TrackersSection       = "trackers"
+	ContentSection        = "content"
+)
+
+// Key of the analysis metadata in the response, no analyzer may use it as its section name
+const MetaKey = "meta"
+
+// Returned when the requested fields name a section no analyzer produces
+var ErrUnknownSection = errors.New("unknown section")
+
+// Overall status of an analysis
+const (
+	StatusSuccess = "success" // Every section was computed
+	StatusPartial = "partial" // Some sections failed or are incomplete
+	StatusFailure = "failure" // Every section failed
+)
+
+// Status of a section
+const (
+	SectionOK         = "ok"
+	SectionIncomplete = "incomplete" // Computed in part before its analyzer failed, e.g. when link checks timed out
+	SectionFailed     = "failed"     // Left out of the analysis
+)
+
+type SectionStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Overall status of an analysis from the status of the sections which were run
+func OverallStatus(sections map[string]SectionStatus) string {
+	ok, failed := 0, 0
+	for _, section := range sections {
+		switch section.Status {
+		case SectionOK:
+			ok++
+		case SectionFailed:
+			failed++
+		}
+	}
+
+	switch {
+	case ok == len(sections):
+		return StatusSuccess
+	case failed == len(sections):
+		return StatusFailure
+	default:
+		return StatusPartial
+	}
+}
+
+// How the analysis was served by the cache
+const (
+	CacheMiss        = "miss"        // Computed, no cached analysis was fresh or could be revalidated
+	CacheHit         = "hit"         // Served from the cache while still fresh
+	CacheRevalidated = "revalidated" // Served from the cache after the page was found unchanged
+	CacheRefreshed   = "refreshed"   // Computed again as requested by force_refresh
+)
+
+// Information about how the analysis was run, as opposed to what was found on the page
+type AnalysisMeta struct {
+	Status      string                   `json:"status,omitempty"`       // Overall status, see StatusSuccess
+	Sections    map[string]SectionStatus `json:"sections,omitempty"`     // Status of each section that was run
+	Skipped     []string                 `json:"skipped,omitempty"`      // Sections left out by the requested fields or by streaming
+	Streamed    bool                     `json:"streamed,omitempty"`     // The page was too large to parse and was streamed
+	FetchMs     float64                  `json:"fetch_ms,omitempty"`     // Time to fetch the page
+	ParseMs     float64                  `json:"parse_ms,omitempty"`     // Time to parse the HTML document
+	TraversalMs float64                  `json:"traversal_ms,omitempty"` // Time of the walk shared by the visiting analyzers
+	TimingsMs   map[string]float64       `json:"timings_ms,omitempty"`   // Time spent in each analyzer that ran
+	Cache       string                   `json:"cache,omitempty"`        // How the cache served the analysis, see CacheHit
+	CachedAt    time.Time                `json:"cached_at,omitzero"`     // When the served analysis was computed or last revalidated
+	ExpiresAt   time.Time                `json:"expires_at,omitzero"`    // When the cached analysis goes stale
+	Shared      bool                     `json:"shared,omitempty"`       // Concurrent identical requests waited for this analysis
+	HistoryID   string                   `json:"history_id,omitempty"`   // ID of the analysis in the history of the page
+}
+
+func (m AnalysisMeta) isEmpty() bool {
+	return m.Status == "" && len(m.Sections) == 0 && len(m.Skipped) == 0 && !m.Streamed &&
+		m.FetchMs == 0 && m.ParseMs == 0 && m.TraversalMs == 0 && len(m.TimingsMs) == 0 &&
+		m.Cache == "" && m.CachedAt.IsZero() && m.ExpiresAt.IsZero() && !m.Shared && m.HistoryID == ""
+}
+
+// Duration in milliseconds, rounded to microseconds
+func Milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// Result of the analyzers run for a page, one section per analyzer in the order they ran.
+// Sections decoded from JSON are kept raw until they are read with DecodeSection.
 type WebPageAnalysis struct {
-	HTMLVersion  string              `json:"html_version"`
-	Title        string              `json:"title"`
-	Headings     map[string]int      `json:"headings"`
-	Links        dmhtml.LinkAnalysis `json:"links"`
-	HasLoginForm bool                `json:"has_login_form"`
+	Meta AnalysisMeta
+
+	names    []string
+	sections map[string]any
+}
+
+func NewWebPageAnalysis() *WebPageAnalysis {
+	return &WebPageAnalysis{sections: make(map[string]any)}
+}
+
+func (a *WebPageAnalysis) Set(name string, section any) {
+	if a.sections == nil {
+		a.sections = make(map[string]any)
+	}
+	if _, ok := a.sections[name]; !ok {
+		a.names = append(a.names, name)
+	}
+	a.sections[name] = section
+}
+
+func (a *WebPageAnalysis) Section(name string) (any, bool) {
+	section, ok := a.sections[name]
+	return section, ok
+}
+
+func (a *WebPageAnalysis) Names() []string {
+	return a.names
+}
+
+// Read a section as its typed value, e.g. DecodeSection[dmhtml.LinkAnalysis](analysis, LinksSection)
+func DecodeSection[T any](a *WebPageAnalysis, name string) (T, error) {
+	var value T
+
+	section, ok := a.sections[name]
+	if !ok {
+		return value, fmt.Errorf("section %q not found", name)
+	}
+
+	switch typed := section.(type) {
+	case T:
+		return typed, nil
+	case *T:
+		return *typed, nil
+	case json.RawMessage:
+		err := json.Unmarshal(typed, &value)
+		return value, err
+	}
+
+	// A section of another type with the same JSON shape
+	raw, err := json.Marshal(section)
+	if err != nil {
+		return value, err
+	}
+	err = json.Unmarshal(raw, &value)
+
+	return value, err
+}
+
+// Encode the sections as one JSON object keyed by section name, in the order the analyzers ran,
+// followed by the metadata when there is any
+func (a WebPageAnalysis) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	write := func(name string, section any) error {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(section)
+		if err != nil {
+			return fmt.Errorf("failed to encode section %q: %w", name, err)
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+		return nil
+	}
+
+	for _, name := range a.names {
+		if err := write(name, a.sections[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if !a.Meta.isEmpty() {
+		if err := write(MetaKey, a.Meta); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (a *WebPageAnalysis) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("web page analysis must be a JSON object")
+	}
+
+	*a = WebPageAnalysis{sections: make(map[string]any)}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		if token == MetaKey {
+			if err = decoder.Decode(&a.Meta); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var raw json.RawMessage
+		if err = decoder.Decode(&raw); err != nil {
+			return err
+		}
+		a.Set(token.(string), raw)
+	}
+
+	_, err := decoder.Token()
+	return err
+}
+
+type AnalyzeOptions struct {
+	dmhtml.ParserOptions
+	Fields  []string `json:"fields"`  // Compute only these sections
+	Include []string `json:"include"` // Same as fields, both lists are merged
+	Exclude []string `json:"exclude"` // Compute every section except these
+	// Analyze the page again even when a cached analysis is still fresh, the new analysis replaces it
+	ForceRefresh bool `json:"force_refresh"`
+}
+
+func (o AnalyzeOptions) Validate() error {
+	if err := o.ParserOptions.Validate(); err != nil {
+		return err
+	}
+
+	if len(o.Exclude) > 0 && len(o.Fields)+len(o.Include) > 0 {
+		return fmt.Errorf("exclude cannot be combined with fields or include")
+	}
+
+	return nil
+}
+
+// Names of every section listed in the options
+func (o AnalyzeOptions) RequestedSections() []string {
+	return slices.Concat(o.Fields, o.Include, o.Exclude)
+}
+
+// Whether the section is computed, every section is when no fields are requested
+func (o AnalyzeOptions) Selects(name string) bool {
+	if len(o.Fields)+len(o.Include) > 0 {
+		return slices.Contains(o.Fields, name) || slices.Contains(o.Include, name)
+	}
+
+	return !slices.Contains(o.Exclude, name)
 }
 
 type WebPageAnalyzer interface {
-	Analyze(url string) (*WebPageAnalysis, error)
+	Analyze(ctx context.Context, url string, opts *AnalyzeOptions) (*WebPageAnalysis, error)
 }
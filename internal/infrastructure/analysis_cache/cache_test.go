@@ -0,0 +1,311 @@
+package analysis_cache
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	dmcch "web-pages-analyzer/internal/domain/cache"
+	clihttp "web-pages-analyzer/internal/domain/clients/http"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+)
+
+func newEntry(title string) *dmcch.Entry {
+	analysis := dmpg.NewWebPageAnalysis()
+	analysis.Set(dmpg.TitleSection, title)
+	analysis.Meta.Status = dmpg.StatusSuccess
+
+	return &dmcch.Entry{
+		Analysis:   analysis,
+		Validators: clihttp.Validators{ETag: `"v1"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"},
+		StoredAt:   time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func Test_MemoryCache(t *testing.T) {
+	tests := []struct {
+		name         string
+		operations   func(cache dmcch.Cache)
+		expectedKeys []string
+		evictedKeys  []string
+	}{
+		{
+			name: "entries within the capacity are kept",
+			operations: func(cache dmcch.Cache) {
+				cache.Set("a", newEntry("a"))
+				cache.Set("b", newEntry("b"))
+			},
+			expectedKeys: []string{"a", "b"},
+		},
+		{
+			name: "least recently set entry is evicted",
+			operations: func(cache dmcch.Cache) {
+				cache.Set("a", newEntry("a"))
+				cache.Set("b", newEntry("b"))
+				cache.Set("c", newEntry("c"))
+			},
+			expectedKeys: []string{"b", "c"},
+			evictedKeys:  []string{"a"},
+		},
+		{
+			name: "read entry is used recently",
+			operations: func(cache dmcch.Cache) {
+				cache.Set("a", newEntry("a"))
+				cache.Set("b", newEntry("b"))
+				cache.Get("a")
+				cache.Set("c", newEntry("c"))
+			},
+			expectedKeys: []string{"a", "c"},
+			evictedKeys:  []string{"b"},
+		},
+		{
+			name: "replaced entry is used recently",
+			operations: func(cache dmcch.Cache) {
+				cache.Set("a", newEntry("a"))
+				cache.Set("b", newEntry("b"))
+				cache.Set("a", newEntry("a"))
+				cache.Set("c", newEntry("c"))
+			},
+			expectedKeys: []string{"a", "c"},
+			evictedKeys:  []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewMemory(2)
+			tt.operations(cache)
+
+			// Verify results
+			for _, key := range tt.evictedKeys {
+				if _, ok := cache.Get(key); ok {
+					t.Errorf("expected %q to be evicted", key)
+				}
+			}
+			for _, key := range tt.expectedKeys {
+				entry, ok := cache.Get(key)
+				if !ok {
+					t.Errorf("expected %q to be cached", key)
+					continue
+				}
+				if title, _ := entry.Analysis.Section(dmpg.TitleSection); title != key {
+					t.Errorf("expected the entry of %q, got title %v", key, title)
+				}
+			}
+		})
+	}
+}
+
+func Test_FileCache(t *testing.T) {
+	tests := []struct {
+		name          string
+		setup         func(t *testing.T, cache dmcch.Cache)
+		key           string
+		expectedFound bool
+		expectedTitle string
+	}{
+		{
+			name: "stored entry is read back",
+			setup: func(t *testing.T, cache dmcch.Cache) {
+				if err := cache.Set("https://example.com/", newEntry("Example")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			key:           "https://example.com/",
+			expectedFound: true,
+			expectedTitle: "Example",
+		},
+		{
+			name: "replaced entry is read back",
+			setup: func(t *testing.T, cache dmcch.Cache) {
+				for _, title := range []string{"First", "Second"} {
+					if err := cache.Set("https://example.com/", newEntry(title)); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			key:           "https://example.com/",
+			expectedFound: true,
+			expectedTitle: "Second",
+		},
+		{
+			name:  "missing entry is a miss",
+			setup: func(t *testing.T, cache dmcch.Cache) {},
+			key:   "https://example.com/",
+		},
+		{
+			name: "corrupted entry is a miss",
+			setup: func(t *testing.T, cache dmcch.Cache) {
+				if err := os.WriteFile(cache.(*fileCache).path("https://example.com/"), []byte("{"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			key: "https://example.com/",
+		},
+		{
+			name: "entry of another key is a miss",
+			setup: func(t *testing.T, cache dmcch.Cache) {
+				if err := cache.Set("https://example.org/", newEntry("Other")); err != nil {
+					t.Fatal(err)
+				}
+				data, err := os.ReadFile(cache.(*fileCache).path("https://example.org/"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = os.WriteFile(cache.(*fileCache).path("https://example.com/"), data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			key: "https://example.com/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "cache")
+			cache, err := NewFile(dir, 10)
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+			tt.setup(t, cache)
+
+			// Entries outlive the cache which wrote them
+			reopened, err := NewFile(dir, 10)
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+			entry, ok := reopened.Get(tt.key)
+
+			// Verify results
+			if ok != tt.expectedFound {
+				t.Fatalf("expected found to be %v, got %v", tt.expectedFound, ok)
+			}
+			if !ok {
+				return
+			}
+
+			// Sections come back as raw JSON
+			title, err := dmpg.DecodeSection[string](entry.Analysis, dmpg.TitleSection)
+			if err != nil || title != tt.expectedTitle {
+				t.Errorf("expected title %q, got %q (%v)", tt.expectedTitle, title, err)
+			}
+
+			expected := newEntry("")
+			if entry.Validators != expected.Validators || !entry.StoredAt.Equal(expected.StoredAt) {
+				t.Errorf("expected validators %+v stored at %v, got %+v stored at %v",
+					expected.Validators, expected.StoredAt, entry.Validators, entry.StoredAt)
+			}
+			if entry.Analysis.Meta.Status != dmpg.StatusSuccess {
+				t.Errorf("expected the metadata to be kept, got %+v", entry.Analysis.Meta)
+			}
+
+			files, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+			if len(files) != 0 {
+				t.Errorf("expected no temporary files left, got %v", files)
+			}
+		})
+	}
+}
+
+func Test_FileCache_Eviction(t *testing.T) {
+	storedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		capacity     int
+		storedAt     map[string]time.Time // Entries written in key order
+		expectedKeys []string
+	}{
+		{
+			name:         "fresh entries are kept",
+			capacity:     10,
+			storedAt:     map[string]time.Time{"a": storedAt, "b": storedAt.Add(time.Minute)},
+			expectedKeys: []string{"a", "b"},
+		},
+		{
+			name:         "stale entries are kept for revalidation",
+			capacity:     10,
+			storedAt:     map[string]time.Time{"a": storedAt.Add(-2 * time.Hour), "b": storedAt},
+			expectedKeys: []string{"a", "b"},
+		},
+		{
+			name:     "oldest entries beyond the capacity are removed",
+			capacity: 2,
+			storedAt: map[string]time.Time{
+				"a": storedAt.Add(2 * time.Minute), "b": storedAt, "c": storedAt.Add(time.Minute),
+			},
+			expectedKeys: []string{"a", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := NewFile(t.TempDir(), tt.capacity)
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+
+			for _, key := range slices.Sorted(maps.Keys(tt.storedAt)) {
+				entry := newEntry(key)
+				entry.StoredAt = tt.storedAt[key]
+				if err = cache.Set(key, entry); err != nil {
+					t.Fatalf("expected nil error: got %v", err)
+				}
+			}
+
+			// Verify results
+			var keys []string
+			for _, key := range []string{"a", "b", "c"} {
+				if _, ok := cache.Get(key); ok {
+					keys = append(keys, key)
+				}
+			}
+			if !slices.Equal(keys, tt.expectedKeys) {
+				t.Errorf("expected keys %v, got %v", tt.expectedKeys, keys)
+			}
+
+			files, err := os.ReadDir(cache.(*fileCache).dir)
+			if err != nil || len(files) != len(tt.expectedKeys) {
+				t.Errorf("expected %d files, got %d (%v)", len(tt.expectedKeys), len(files), err)
+			}
+		})
+	}
+}
+
+func Test_FileCache_StaleEntry(t *testing.T) {
+	const ttl = 10 * time.Minute
+
+	cache, err := NewFile(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("expected nil error: got %v", err)
+	}
+
+	stale := newEntry("Stale")
+	if err = cache.Set("https://example.com/", stale); err != nil {
+		t.Fatalf("expected nil error: got %v", err)
+	}
+
+	// Another page is analyzed once the first entry is past the TTL
+	now := stale.StoredAt.Add(2 * ttl)
+	fresh := newEntry("Fresh")
+	fresh.StoredAt = now
+	if err = cache.Set("https://example.org/", fresh); err != nil {
+		t.Fatalf("expected nil error: got %v", err)
+	}
+
+	entry, ok := cache.Get("https://example.com/")
+
+	// Verify results
+	if !ok {
+		t.Fatal("expected the stale entry to be kept")
+	}
+	if entry.Age(now) <= ttl {
+		t.Errorf("expected the entry to be stale, got age %v", entry.Age(now))
+	}
+	if entry.Validators != stale.Validators {
+		t.Errorf("expected validators %+v to revalidate the entry, got %+v", stale.Validators, entry.Validators)
+	}
+}
@@ -0,0 +1,129 @@
+package analysis_cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	dmcch "web-pages-analyzer/internal/domain/cache"
+)
+
+// An entry as written to its file, with its key to tell apart keys whose hashes collide
+type fileEntry struct {
+	Key string `json:"key"`
+	dmcch.Entry
+}
+
+// Cache keeping one JSON file per entry in a directory, it outlives restarts and can be shared by the
+// instances which mount the same directory. The modification time of a file is when its entry was stored.
+// Every write removes the oldest entries beyond the capacity. Stale entries are kept, so that the caller can
+// revalidate them.
+type fileCache struct {
+	dir      string
+	capacity int
+}
+
+func NewFile(dir string, capacity int) (dmcch.Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &fileCache{dir: dir, capacity: capacity}, nil
+}
+
+// A missing, unreadable or corrupted file is a miss, the entry is written again once the page is analyzed
+func (c *fileCache) Get(key string) (*dmcch.Entry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry fileEntry
+	if err = json.Unmarshal(data, &entry); err != nil || entry.Key != key || entry.Analysis == nil {
+		return nil, false
+	}
+
+	return &entry.Entry, true
+}
+
+// The entry is written to a temporary file first and renamed, so that readers never see half of it
+func (c *fileCache) Set(key string, entry *dmcch.Entry) error {
+	data, err := json.Marshal(fileEntry{Key: key, Entry: *entry})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err = os.Chtimes(tmp.Name(), entry.StoredAt, entry.StoredAt); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	c.evict()
+	return nil
+}
+
+// Remove the oldest entries beyond the capacity. Files another instance removed in the meantime are
+// skipped, a file which cannot be removed is left for the next write.
+func (c *fileCache) evict() {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type storedFile struct {
+		path     string
+		storedAt time.Time
+	}
+
+	var files []storedFile
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), ".json") {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+
+		files = append(files, storedFile{path: filepath.Join(c.dir, dirEntry.Name()), storedAt: info.ModTime()})
+	}
+
+	if len(files) <= c.capacity {
+		return
+	}
+
+	// Newest first, the files past the capacity are the oldest
+	slices.SortFunc(files, func(a, b storedFile) int {
+		return b.storedAt.Compare(a.storedAt)
+	})
+	for _, file := range files[c.capacity:] {
+		os.Remove(file.path)
+	}
+}
+
+func (c *fileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
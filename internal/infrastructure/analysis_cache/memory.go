@@ -0,0 +1,63 @@
+package analysis_cache
+
+import (
+	"container/list"
+	"sync"
+
+	dmcch "web-pages-analyzer/internal/domain/cache"
+)
+
+type memoryEntry struct {
+	key   string
+	entry *dmcch.Entry
+}
+
+// In-memory cache which evicts the least recently used entry once it holds its capacity
+type memoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Most recently used first
+	elements map[string]*list.Element
+}
+
+func NewMemory(capacity int) dmcch.Cache {
+	return &memoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+func (c *memoryCache) Get(key string) (*dmcch.Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.elements[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+
+	return element.Value.(*memoryEntry).entry, true
+}
+
+func (c *memoryCache) Set(key string, entry *dmcch.Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.elements[key]; ok {
+		element.Value.(*memoryEntry).entry = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.elements[key] = c.order.PushFront(&memoryEntry{key: key, entry: entry})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.elements, oldest.Value.(*memoryEntry).key)
+	}
+
+	return nil
+}
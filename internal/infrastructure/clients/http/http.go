@@ -1,8 +1,10 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	clihttp "web-pages-analyzer/internal/domain/clients/http"
@@ -17,7 +19,9 @@ func New(cfg *clihttp.HttpClientCfg) clihttp.HttpClient {
 		httpClient: &http.Client{
 			Timeout: time.Duration(cfg.Timeout) * time.Second,
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				if len(via) >= cfg.MaxRedirects {
+				// Stop on loops and long chains, and hand back the last redirect so that
+				// the chain can be reported to the caller
+				if len(via) >= cfg.MaxRedirects || isVisited(req, via) {
 					return http.ErrUseLastResponse
 				}
 				return nil
@@ -27,8 +31,16 @@ func New(cfg *clihttp.HttpClientCfg) clihttp.HttpClient {
 	}
 }
 
-func (c *httpClient) Get(url string) (*http.Response, error) {
-	resp, err := c.httpClient.Get(url)
+func (c *httpClient) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, clihttp.NewHttpError(
+			http.StatusBadGateway,
+			fmt.Sprintf("error in GET call: %s", err.Error()),
+		)
+	}
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, clihttp.NewHttpError(
 			http.StatusBadGateway,
@@ -37,17 +49,53 @@ func (c *httpClient) Get(url string) (*http.Response, error) {
 	}
 
 	if !isSucceed(resp.StatusCode) {
+		resp.Body.Close()
+		return nil, clihttp.NewHttpError(
+			resp.StatusCode,
+			fmt.Sprintf("faliure in GET call: %s%s", resp.Status, describeRedirects(url, resp)),
+		)
+	}
+
+	return resp, nil
+}
+
+func (c *httpClient) GetConditional(ctx context.Context, url string, validators clihttp.Validators) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, clihttp.NewHttpError(
+			http.StatusBadRequest,
+			fmt.Sprintf("error in GET call: %s", err.Error()),
+		)
+	}
+
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, clihttp.NewHttpError(
+			http.StatusBadGateway,
+			fmt.Sprintf("error in GET call: %s", err.Error()),
+		)
+	}
+
+	if resp.StatusCode != http.StatusNotModified && !isSucceed(resp.StatusCode) {
+		resp.Body.Close()
 		return nil, clihttp.NewHttpError(
 			resp.StatusCode,
-			fmt.Sprintf("faliure in GET call: %s", resp.Status),
+			fmt.Sprintf("faliure in GET call: %s%s", resp.Status, describeRedirects(url, resp)),
 		)
 	}
 
 	return resp, nil
 }
 
-func (c *httpClient) Head(url string) (*http.Response, error) {
-	resp, err := c.httpClient.Head(url)
+func (c *httpClient) Head(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
 	if err != nil {
 		return nil, clihttp.NewHttpError(
 			http.StatusBadGateway,
@@ -55,16 +103,56 @@ func (c *httpClient) Head(url string) (*http.Response, error) {
 		)
 	}
 
-	if !isHeadSucceed(resp.StatusCode) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
 		return nil, clihttp.NewHttpError(
+			http.StatusBadGateway,
+			fmt.Sprintf("error in HEAD call: %s", err.Error()),
+		)
+	}
+
+	if !isHeadSucceed(resp.StatusCode) {
+		resp.Body.Close()
+		httpErr := clihttp.NewHttpError(
 			resp.StatusCode,
 			fmt.Sprintf("faliure in HEAD call: %s", resp.Status),
 		)
+		httpErr.Redirects = clihttp.NewRedirectChain(url, resp)
+		return nil, httpErr
 	}
 
 	return resp, nil
 }
 
+// Explain a failure caused by the client giving up on a redirect chain
+func describeRedirects(url string, resp *http.Response) string {
+	chain := clihttp.NewRedirectChain(url, resp)
+	if !chain.Loop && !chain.Excessive {
+		return ""
+	}
+
+	hops := make([]string, 0, len(chain.Hops)+1)
+	for _, hop := range chain.Hops {
+		hops = append(hops, hop.URL)
+	}
+	hops = append(hops, chain.Hops[len(chain.Hops)-1].Location)
+
+	if chain.Loop {
+		return fmt.Sprintf(", redirect loop detected: %s", strings.Join(hops, " -> "))
+	}
+
+	return fmt.Sprintf(", too many redirects (%d): %s", len(chain.Hops), strings.Join(hops, " -> "))
+}
+
+func isVisited(req *http.Request, via []*http.Request) bool {
+	for _, prev := range via {
+		if prev.URL.String() == req.URL.String() {
+			return true
+		}
+	}
+	return false
+}
+
 func isSucceed(statusCode int) bool {
 	return statusCode >= 200 && statusCode < 300
 }
@@ -1,10 +1,13 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	clihttp "web-pages-analyzer/internal/domain/clients/http"
 )
@@ -92,7 +95,7 @@ func Test_HttpClient_Get(t *testing.T) {
 			}
 			client := New(cfg)
 
-			resp, err := client.Get(server.URL)
+			resp, err := client.Get(context.Background(), server.URL)
 
 			// Verify results
 			validateResults(t, resp, err, tt.expectedError, tt.statusCode)
@@ -113,7 +116,7 @@ func Test_HttpClient_Get_Error(t *testing.T) {
 	client := New(cfg)
 
 	// Make request to invalid URL
-	resp, err := client.Get("http://non-existing-url.com")
+	resp, err := client.Get(context.Background(), "http://non-existing-url.com")
 
 	// Verify results
 	if err == nil {
@@ -177,7 +180,7 @@ func TestHttpClient_Head_Success(t *testing.T) {
 			}
 			client := New(cfg)
 
-			resp, err := client.Head(server.URL)
+			resp, err := client.Head(context.Background(), server.URL)
 
 			// Verify results
 			validateResults(t, resp, err, tt.expectedError, tt.statusCode)
@@ -198,7 +201,7 @@ func Test_HttpClient_Head_Error(t *testing.T) {
 	client := New(cfg)
 
 	// Make request to invalid URL
-	resp, err := client.Head("http://non-existing-url.com")
+	resp, err := client.Head(context.Background(), "http://non-existing-url.com")
 
 	// Verify results
 	if err == nil {
@@ -220,3 +223,212 @@ func Test_HttpClient_Head_Error(t *testing.T) {
 		t.Errorf("expected status code %d, got %d", http.StatusBadGateway, httpErr.StatusCode)
 	}
 }
+
+func Test_HttpClient_Head_Cancelled(t *testing.T) {
+	// The server answers once the test is over, long after the request was abandoned
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	cfg := &clihttp.HttpClientCfg{
+		Timeout:      10,
+		MaxRedirects: 5,
+	}
+	client := New(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := client.Head(ctx, server.URL)
+
+	// Verify results
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected error but got none")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the request to be abandoned with the context, returned after %v", elapsed)
+	}
+}
+
+func Test_HttpClient_Get_Redirects(t *testing.T) {
+	tests := []struct {
+		name              string
+		redirects         map[string]string
+		expectedError     string
+		expectedHops      int
+		expectedFinalPath string
+	}{
+		{
+			name:              "redirect chain is followed",
+			redirects:         map[string]string{"/start": "/middle", "/middle": "/final"},
+			expectedHops:      2,
+			expectedFinalPath: "/final",
+		},
+		{
+			name:          "redirect loop is reported",
+			redirects:     map[string]string{"/start": "/middle", "/middle": "/start"},
+			expectedError: "redirect loop detected",
+		},
+		{
+			name: "excessive redirects are reported",
+			redirects: map[string]string{
+				"/start": "/r1", "/r1": "/r2", "/r2": "/r3", "/r3": "/r4", "/r4": "/r5", "/r5": "/final",
+			},
+			expectedError: "too many redirects (5)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create mock server
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if location, ok := tt.redirects[r.URL.Path]; ok {
+					http.Redirect(w, r, location, http.StatusFound)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			// Create client
+			cfg := &clihttp.HttpClientCfg{
+				Timeout:      10,
+				MaxRedirects: 5,
+			}
+			client := New(cfg)
+
+			resp, err := client.Get(context.Background(), server.URL+"/start")
+
+			// Verify results
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("expected error to contain %q, got %q", tt.expectedError, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got an error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			chain := clihttp.NewRedirectChain(server.URL+"/start", resp)
+			if len(chain.Hops) != tt.expectedHops {
+				t.Errorf("expected %d hops, got %d", tt.expectedHops, len(chain.Hops))
+			}
+			if chain.FinalURL != server.URL+tt.expectedFinalPath {
+				t.Errorf("expected final URL %s, got %s", server.URL+tt.expectedFinalPath, chain.FinalURL)
+			}
+			if chain.Loop || chain.Excessive || chain.Downgrade {
+				t.Errorf("expected no redirect flags, got %+v", chain)
+			}
+		})
+	}
+}
+
+func Test_HttpClient_Head_RedirectsOnError(t *testing.T) {
+	// Create mock server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/gone", http.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	// Create client
+	cfg := &clihttp.HttpClientCfg{
+		Timeout:      10,
+		MaxRedirects: 5,
+	}
+	client := New(cfg)
+
+	resp, err := client.Head(context.Background(), server.URL+"/old")
+
+	// Verify results
+	if resp != nil {
+		t.Error("expected nil response: got non-nil response")
+		resp.Body.Close()
+	}
+
+	httpErr, ok := clihttp.NewHttpErrorFromErr(err)
+	if !ok {
+		t.Fatalf("expected an HTTP error, got %v", err)
+	}
+	if httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status code %d, got %d", http.StatusNotFound, httpErr.StatusCode)
+	}
+	if httpErr.Redirects == nil || len(httpErr.Redirects.Hops) != 1 || httpErr.Redirects.FinalURL != server.URL+"/gone" {
+		t.Errorf("expected the redirect to /gone, got %+v", httpErr.Redirects)
+	}
+}
+
+func Test_HttpClient_GetConditional(t *testing.T) {
+	tests := []struct {
+		name               string
+		validators         clihttp.Validators
+		expectedStatusCode int
+	}{
+		{
+			name:               "matching ETag is not modified",
+			validators:         clihttp.Validators{ETag: `"v1"`},
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "matching Last-Modified is not modified",
+			validators:         clihttp.Validators{LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"},
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "changed page is fetched again",
+			validators:         clihttp.Validators{ETag: `"v0"`},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "no validators fetch the page",
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create mock server
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-None-Match") == `"v1"` || r.Header.Get("If-Modified-Since") == "Mon, 02 Jan 2006 15:04:05 GMT" {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", `"v1"`)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			// Create client
+			cfg := &clihttp.HttpClientCfg{
+				Timeout:      10,
+				MaxRedirects: 5,
+			}
+			client := New(cfg)
+
+			resp, err := client.GetConditional(context.Background(), server.URL, tt.validators)
+
+			// Verify results
+			validateResults(t, resp, err, false, tt.expectedStatusCode)
+			if resp != nil {
+				resp.Body.Close()
+			}
+		})
+	}
+}
@@ -10,8 +10,10 @@
 package mocks
 
 import (
+	context "context"
 	http "net/http"
 	reflect "reflect"
+	http0 "web-pages-analyzer/internal/domain/clients/http"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,31 +43,46 @@ func (m *MockHttpClient) EXPECT() *MockHttpClientMockRecorder {
 }
 
 // Get mocks base method.
-func (m *MockHttpClient) Get(url string) (*http.Response, error) {
+func (m *MockHttpClient) Get(ctx context.Context, url string) (*http.Response, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", url)
+	ret := m.ctrl.Call(m, "Get", ctx, url)
 	ret0, _ := ret[0].(*http.Response)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockHttpClientMockRecorder) Get(url any) *gomock.Call {
+func (mr *MockHttpClientMockRecorder) Get(ctx, url any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockHttpClient)(nil).Get), url)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockHttpClient)(nil).Get), ctx, url)
+}
+
+// GetConditional mocks base method.
+func (m *MockHttpClient) GetConditional(ctx context.Context, url string, validators http0.Validators) (*http.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConditional", ctx, url, validators)
+	ret0, _ := ret[0].(*http.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConditional indicates an expected call of GetConditional.
+func (mr *MockHttpClientMockRecorder) GetConditional(ctx, url, validators any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConditional", reflect.TypeOf((*MockHttpClient)(nil).GetConditional), ctx, url, validators)
 }
 
 // Head mocks base method.
-func (m *MockHttpClient) Head(url string) (*http.Response, error) {
+func (m *MockHttpClient) Head(ctx context.Context, url string) (*http.Response, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Head", url)
+	ret := m.ctrl.Call(m, "Head", ctx, url)
 	ret0, _ := ret[0].(*http.Response)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Head indicates an expected call of Head.
-func (mr *MockHttpClientMockRecorder) Head(url any) *gomock.Call {
+func (mr *MockHttpClientMockRecorder) Head(ctx, url any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Head", reflect.TypeOf((*MockHttpClient)(nil).Head), url)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Head", reflect.TypeOf((*MockHttpClient)(nil).Head), ctx, url)
 }
@@ -0,0 +1,15 @@
+package http
+
+import (
+	"context"
+
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+)
+
+// Report the redirect chain which led to the analyzed page as the "redirects" section
+func NewRedirectsAnalyzer() dmanl.Analyzer {
+	return dmanl.NewResponseAnalyzer(dmpg.RedirectsSection, func(_ context.Context, doc *dmanl.Document) (any, error) {
+		return *doc.Redirects, nil
+	})
+}
@@ -0,0 +1,322 @@
+package fingerprinter
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	dmfp "web-pages-analyzer/internal/domain/fingerprint"
+)
+
+//go:embed signatures.json
+var defaultSignatures []byte
+
+// JSON ruleset, every pattern is a case-insensitive regular expression whose first capture group is the version
+type ruleset struct {
+	Technologies []signature `json:"technologies"`
+}
+
+type signature struct {
+	Name        string            `json:"name"`
+	Category    string            `json:"category"`
+	Meta        map[string]string `json:"meta"`
+	Scripts     []string          `json:"scripts"`
+	Globals     []string          `json:"globals"`
+	Stylesheets []string          `json:"stylesheets"`
+	Classes     []string          `json:"classes"`
+	Headers     map[string]string `json:"headers"`
+	Cookies     map[string]string `json:"cookies"`
+	Implies     []string          `json:"implies"`
+}
+
+type rule struct {
+	name        string
+	category    string
+	meta        map[string]*regexp.Regexp
+	scripts     []*regexp.Regexp
+	globals     []*regexp.Regexp
+	stylesheets []*regexp.Regexp
+	classes     []*regexp.Regexp
+	headers     map[string]*regexp.Regexp
+	cookies     map[string]*regexp.Regexp
+	implies     []string
+}
+
+type fingerprinter struct {
+	rules []*rule
+}
+
+// Create a fingerprinter from the embedded ruleset, extra rulesets add technologies or replace them by name
+func New(extra ...io.Reader) (dmfp.Fingerprinter, error) {
+	fp := &fingerprinter{}
+
+	if err := fp.load(bytes.NewReader(defaultSignatures)); err != nil {
+		return nil, fmt.Errorf("invalid embedded ruleset: %w", err)
+	}
+
+	for _, r := range extra {
+		if err := fp.load(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return fp, nil
+}
+
+func (fp *fingerprinter) load(r io.Reader) error {
+	var set ruleset
+	if err := json.NewDecoder(r).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode ruleset: %w", err)
+	}
+
+	for _, sig := range set.Technologies {
+		compiled, err := compileSignature(sig)
+		if err != nil {
+			return err
+		}
+
+		replaced := false
+		for i, existing := range fp.rules {
+			if existing.name == compiled.name {
+				fp.rules[i], replaced = compiled, true
+				break
+			}
+		}
+		if !replaced {
+			fp.rules = append(fp.rules, compiled)
+		}
+	}
+
+	return nil
+}
+
+func compileSignature(sig signature) (*rule, error) {
+	if sig.Name == "" || sig.Category == "" {
+		return nil, fmt.Errorf("technology name and category are required")
+	}
+
+	var err error
+	r := &rule{name: sig.Name, category: sig.Category, implies: sig.Implies}
+
+	compileList := func(patterns []string) []*regexp.Regexp {
+		var compiled []*regexp.Regexp
+		for _, pattern := range patterns {
+			var re *regexp.Regexp
+			if re, err = compilePattern(sig.Name, pattern); err != nil {
+				return nil
+			}
+			compiled = append(compiled, re)
+		}
+		return compiled
+	}
+
+	// Meta, header and cookie names are case-insensitive
+	compileMap := func(patterns map[string]string, canonical func(string) string) map[string]*regexp.Regexp {
+		compiled := make(map[string]*regexp.Regexp, len(patterns))
+		for key, pattern := range patterns {
+			var re *regexp.Regexp
+			if re, err = compilePattern(sig.Name, pattern); err != nil {
+				return nil
+			}
+			compiled[canonical(key)] = re
+		}
+		return compiled
+	}
+
+	if r.scripts = compileList(sig.Scripts); err != nil {
+		return nil, err
+	}
+	if r.globals = compileList(sig.Globals); err != nil {
+		return nil, err
+	}
+	if r.stylesheets = compileList(sig.Stylesheets); err != nil {
+		return nil, err
+	}
+	if r.classes = compileList(sig.Classes); err != nil {
+		return nil, err
+	}
+	if r.meta = compileMap(sig.Meta, strings.ToLower); err != nil {
+		return nil, err
+	}
+	if r.headers = compileMap(sig.Headers, http.CanonicalHeaderKey); err != nil {
+		return nil, err
+	}
+	if r.cookies = compileMap(sig.Cookies, strings.ToLower); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func compilePattern(name string, pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q for %s: %w", pattern, name, err)
+	}
+
+	return re, nil
+}
+
+func (fp *fingerprinter) Fingerprint(evidence *dmfp.Evidence) []dmfp.Technology {
+	detected := make(map[string]*dmfp.Technology)
+	cookies := (&http.Response{Header: evidence.Header}).Cookies()
+
+	for _, r := range fp.rules {
+		if tech := r.match(evidence, cookies); tech != nil {
+			detected[r.name] = tech
+		}
+	}
+
+	fp.addImplied(detected)
+
+	technologies := []dmfp.Technology{}
+	for _, tech := range detected {
+		technologies = append(technologies, *tech)
+	}
+
+	sort.Slice(technologies, func(i, j int) bool {
+		if technologies[i].Category != technologies[j].Category {
+			return technologies[i].Category < technologies[j].Category
+		}
+		return technologies[i].Name < technologies[j].Name
+	})
+
+	return technologies
+}
+
+// Add the technologies implied by detected ones, e.g. WordPress implies PHP
+func (fp *fingerprinter) addImplied(detected map[string]*dmfp.Technology) {
+	pending := make([]string, 0, len(detected))
+	for name := range detected {
+		pending = append(pending, name)
+	}
+
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+
+		for _, implied := range fp.rule(name).implies {
+			impliedRule := fp.rule(implied)
+			if detected[implied] != nil || impliedRule == nil {
+				continue
+			}
+
+			detected[implied] = &dmfp.Technology{
+				Name:     implied,
+				Category: impliedRule.category,
+				Evidence: []string{"implied by " + name},
+			}
+			pending = append(pending, implied)
+		}
+	}
+}
+
+func (fp *fingerprinter) rule(name string) *rule {
+	for _, r := range fp.rules {
+		if r.name == name {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// The version is read from the first source that has one, in the order they are matched: meta tags,
+// scripts, stylesheets, classes, inline scripts, headers and cookies, each one by name
+func (r *rule) match(evidence *dmfp.Evidence, cookies []*http.Cookie) *dmfp.Technology {
+	tech := &dmfp.Technology{Name: r.name, Category: r.category}
+	found := func(source string, version string) {
+		if tech.Version == "" {
+			tech.Version = version
+		}
+		for _, existing := range tech.Evidence {
+			if existing == source {
+				return
+			}
+		}
+		tech.Evidence = append(tech.Evidence, source)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(r.meta)) {
+		re := r.meta[name]
+		for _, content := range evidence.Meta[name] {
+			if ok, version := matchVersion(re, content); ok {
+				found(fmt.Sprintf("meta %s: %s", name, content), version)
+			}
+		}
+	}
+
+	matchAll(r.scripts, evidence.ScriptSrcs, "script", found)
+	matchAll(r.stylesheets, evidence.Stylesheets, "stylesheet", found)
+	matchAll(r.classes, evidence.Classes, "class", found)
+
+	for _, re := range r.globals {
+		for _, script := range evidence.InlineScripts {
+			if ok, version := matchVersion(re, script); ok {
+				found("inline script matches "+strings.TrimPrefix(re.String(), "(?i)"), version)
+				break
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(r.headers)) {
+		re := r.headers[name]
+		for _, value := range evidence.Header.Values(name) {
+			if ok, version := matchVersion(re, value); ok {
+				found(fmt.Sprintf("header %s: %s", name, value), version)
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(r.cookies)) {
+		re := r.cookies[name]
+		for _, cookie := range cookies {
+			if strings.HasPrefix(strings.ToLower(cookie.Name), name) {
+				if ok, version := matchVersion(re, cookie.Value); ok {
+					found("cookie "+cookie.Name, version)
+				}
+			}
+		}
+	}
+
+	if len(tech.Evidence) == 0 {
+		return nil
+	}
+
+	sort.Strings(tech.Evidence)
+	return tech
+}
+
+func matchAll(patterns []*regexp.Regexp, values []string, source string, found func(string, string)) {
+	for _, re := range patterns {
+		for _, value := range values {
+			if ok, version := matchVersion(re, value); ok {
+				found(source+" "+value, version)
+			}
+		}
+	}
+}
+
+// Match a pattern and return its first non-empty capture group as the version
+func matchVersion(re *regexp.Regexp, value string) (bool, string) {
+	match := re.FindStringSubmatch(value)
+	if match == nil {
+		return false, ""
+	}
+
+	for _, group := range match[1:] {
+		if group != "" {
+			return true, group
+		}
+	}
+
+	return true, ""
+}
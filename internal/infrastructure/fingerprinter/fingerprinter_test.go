@@ -0,0 +1,185 @@
+package fingerprinter
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	dmfp "web-pages-analyzer/internal/domain/fingerprint"
+)
+
+func findTechnology(technologies []dmfp.Technology, name string) *dmfp.Technology {
+	for i := range technologies {
+		if technologies[i].Name == name {
+			return &technologies[i]
+		}
+	}
+	return nil
+}
+
+func Test_Fingerprint(t *testing.T) {
+	tests := []struct {
+		name             string
+		evidence         *dmfp.Evidence
+		expectedVersions map[string]string
+		notExpected      []string
+	}{
+		{
+			name: "WordPress site behind nginx",
+			evidence: &dmfp.Evidence{
+				Header: http.Header{
+					"Server":     []string{"nginx/1.25.3"},
+					"Set-Cookie": []string{"wp-settings-1=abc; Path=/"},
+				},
+				Meta:       map[string][]string{"generator": {"WordPress 6.4.2"}},
+				ScriptSrcs: []string{"https://example.com/wp-includes/js/jquery/jquery.min.js?ver=3.7.1"},
+			},
+			expectedVersions: map[string]string{
+				"WordPress": "6.4.2",
+				"jQuery":    "3.7.1",
+				"Nginx":     "1.25.3",
+				"PHP":       "",
+			},
+		},
+		{
+			name: "Next.js app with analytics on a CDN",
+			evidence: &dmfp.Evidence{
+				Header: http.Header{
+					"X-Powered-By": []string{"Next.js"},
+					"X-Vercel-Id":  []string{"fra1::abc"},
+				},
+				ScriptSrcs:    []string{"https://example.com/_next/static/chunks/main.js", "https://www.googletagmanager.com/gtag/js?id=G-1"},
+				InlineScripts: []string{`self.__NEXT_DATA__ = {}`},
+			},
+			expectedVersions: map[string]string{
+				"Next.js":          "",
+				"React":            "",
+				"Vercel":           "",
+				"Google Analytics": "",
+			},
+			notExpected: []string{"WordPress", "Nginx"},
+		},
+		{
+			name: "CSS classes and stylesheets",
+			evidence: &dmfp.Evidence{
+				Stylesheets: []string{"https://cdn.jsdelivr.net/npm/bootstrap@5.3.2/dist/css/bootstrap.min.css"},
+				Classes:     []string{"container", "svelte-1x2y3z"},
+			},
+			expectedVersions: map[string]string{
+				"Bootstrap": "5.3.2",
+				"jsDelivr":  "",
+				"Svelte":    "",
+			},
+		},
+		{
+			name:             "no evidence",
+			evidence:         &dmfp.Evidence{},
+			expectedVersions: map[string]string{},
+		},
+	}
+
+	fp, err := New()
+	if err != nil {
+		t.Fatalf("failed to load the embedded ruleset: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fp.Fingerprint(tt.evidence)
+
+			if len(result) != len(tt.expectedVersions) {
+				t.Errorf("expected %d technologies, got %+v", len(tt.expectedVersions), result)
+			}
+
+			for name, version := range tt.expectedVersions {
+				tech := findTechnology(result, name)
+				if tech == nil {
+					t.Errorf("expected %s to be detected", name)
+					continue
+				}
+				if tech.Version != version {
+					t.Errorf("expected %s version %q, got %q", name, version, tech.Version)
+				}
+				if len(tech.Evidence) == 0 {
+					t.Errorf("expected evidence for %s", name)
+				}
+			}
+
+			for _, name := range tt.notExpected {
+				if findTechnology(result, name) != nil {
+					t.Errorf("did not expect %s to be detected", name)
+				}
+			}
+		})
+	}
+}
+
+func Test_New_ExtraRuleset(t *testing.T) {
+	extra := `{"technologies": [
+		{"name": "Acme CMS", "category": "cms", "meta": {"generator": "^Acme ([\\d.]+)"}},
+		{"name": "Nginx", "category": "web-server", "headers": {"X-Custom-Server": "^nginx"}}
+	]}`
+
+	fp, err := New(strings.NewReader(extra))
+	if err != nil {
+		t.Fatalf("failed to load the extra ruleset: %v", err)
+	}
+
+	result := fp.Fingerprint(&dmfp.Evidence{
+		Header: http.Header{"Server": []string{"nginx"}},
+		Meta:   map[string][]string{"generator": {"Acme 2.1"}},
+	})
+
+	if tech := findTechnology(result, "Acme CMS"); tech == nil || tech.Version != "2.1" {
+		t.Errorf("expected Acme CMS 2.1 from the extra ruleset, got %+v", result)
+	}
+
+	// The extra Nginx signature replaces the embedded one
+	if findTechnology(result, "Nginx") != nil {
+		t.Errorf("expected the embedded Nginx signature to be replaced, got %+v", result)
+	}
+}
+
+func Test_Fingerprint_StableVersion(t *testing.T) {
+	extra := `{"technologies": [
+		{"name": "Acme CMS", "category": "cms",
+		 "meta": {"generator": "^Acme ([\\d.]+)", "application-name": "^Acme ([\\d.]+)"},
+		 "headers": {"X-Powered-By": "^Acme/([\\d.]+)", "X-Acme-Version": "^([\\d.]+)"}}
+	]}`
+
+	fp, err := New(strings.NewReader(extra))
+	if err != nil {
+		t.Fatalf("failed to load the extra ruleset: %v", err)
+	}
+
+	evidence := &dmfp.Evidence{
+		Header: http.Header{"X-Powered-By": []string{"Acme/1.0"}, "X-Acme-Version": []string{"1.1"}},
+		Meta:   map[string][]string{"generator": {"Acme 2.0"}, "application-name": {"Acme 2.1"}},
+	}
+
+	// Meta tags win over headers, and the meta tags are read by name whatever the map order
+	for range 20 {
+		if tech := findTechnology(fp.Fingerprint(evidence), "Acme CMS"); tech == nil || tech.Version != "2.1" {
+			t.Fatalf("expected Acme CMS 2.1 from the application-name meta tag, got %+v", tech)
+		}
+	}
+}
+
+func Test_New_InvalidRuleset(t *testing.T) {
+	tests := []struct {
+		name    string
+		ruleset string
+	}{
+		{name: "malformed JSON", ruleset: `{"technologies": [`},
+		{name: "missing category", ruleset: `{"technologies": [{"name": "Acme"}]}`},
+		{name: "invalid pattern", ruleset: `{"technologies": [{"name": "Acme", "category": "cms", "scripts": ["(unclosed"]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(strings.NewReader(tt.ruleset)); err == nil {
+				t.Errorf("expected an error for %s", tt.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package history_store
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dmhst "web-pages-analyzer/internal/domain/history"
+	utlurl "web-pages-analyzer/internal/utils/url"
+)
+
+// IDs are made of the key of the page, the creation time in nanoseconds and a random suffix, all in hex,
+// so that the IDs of a page sort by creation time and locate their file
+var idPattern = regexp.MustCompile(`^([0-9a-f]{16})-([0-9a-f]{16})-[0-9a-f]{8}$`)
+
+// Store keeping one JSON file per record, in one directory per page
+type fileStore struct {
+	dir       string
+	retention dmhst.Retention
+	mu        sync.Mutex // Keeps pruning from removing a record being read
+}
+
+func NewFile(dir string, retention dmhst.Retention) (dmhst.Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	return &fileStore{dir: dir, retention: retention}, nil
+}
+
+// Write the record and remove the records of the page beyond the retention limits
+func (s *fileStore) Save(record *dmhst.Record) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to create history ID: %w", err)
+	}
+
+	page := pageKey(record.URL)
+	record.ID = fmt.Sprintf("%s-%016x-%s", page, record.CreatedAt.UnixNano(), hex.EncodeToString(suffix))
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode history record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = os.MkdirAll(filepath.Join(s.dir, page), 0o755); err != nil {
+		return fmt.Errorf("failed to write history record: %w", err)
+	}
+	if err = writeFile(filepath.Join(s.dir, page), s.path(record.ID), data); err != nil {
+		return fmt.Errorf("failed to write history record: %w", err)
+	}
+
+	return s.prune(page)
+}
+
+func (s *fileStore) List(url string, limit int) ([]dmhst.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	page := pageKey(url)
+	if err := s.prune(page); err != nil {
+		return nil, err
+	}
+
+	ids, err := s.ids(page)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	// An unreadable record only leaves the listing, it is still reported by Get
+	records := make([]dmhst.Record, 0, len(ids))
+	for _, id := range ids {
+		record, err := s.readSummary(id)
+		if errors.Is(err, dmhst.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Println("[ERROR] Error listing history record: ", err.Error())
+			continue
+		}
+		records = append(records, *record)
+	}
+
+	return records, nil
+}
+
+func (s *fileStore) Get(id string) (*dmhst.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !idPattern.MatchString(id) {
+		return nil, dmhst.ErrNotFound
+	}
+
+	if s.expired(id) {
+		if err := s.remove(id); err != nil && !errors.Is(err, dmhst.ErrNotFound) {
+			return nil, err
+		}
+		return nil, dmhst.ErrNotFound
+	}
+
+	return s.read(id)
+}
+
+func (s *fileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !idPattern.MatchString(id) {
+		return dmhst.ErrNotFound
+	}
+
+	return s.remove(id)
+}
+
+// Remove the records of the page which are older than the maximum age or beyond the maximum count
+func (s *fileStore) prune(page string) error {
+	ids, err := s.ids(page)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		if (s.retention.MaxPerURL > 0 && i >= s.retention.MaxPerURL) || s.expired(id) {
+			if err = s.remove(id); err != nil && !errors.Is(err, dmhst.ErrNotFound) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// IDs of the records of the page, newest first
+func (s *fileStore) ids(page string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, page))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history records: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if id, ok := strings.CutSuffix(entry.Name(), ".json"); ok && idPattern.MatchString(id) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	slices.Reverse(ids)
+
+	return ids, nil
+}
+
+func (s *fileStore) read(id string) (*dmhst.Record, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, dmhst.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history record: %w", err)
+	}
+
+	var record dmhst.Record
+	if err = json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode history record %s: %w", id, err)
+	}
+
+	return &record, nil
+}
+
+// Read the record without its analysis. Records are written with their analysis last, so decoding stops
+// where it starts and the rest of the file is never read.
+func (s *fileStore) readSummary(id string) (*dmhst.Record, error) {
+	file, err := os.Open(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, dmhst.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history record: %w", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("failed to decode history record %s: expected an object", id)
+	}
+
+	fields := make(map[string]json.RawMessage)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode history record %s: %w", id, err)
+		}
+		if token == "analysis" {
+			break
+		}
+
+		var value json.RawMessage
+		if err = decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("failed to decode history record %s: %w", id, err)
+		}
+		fields[token.(string)] = value
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode history record %s: %w", id, err)
+	}
+
+	var record dmhst.Record
+	if err = json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode history record %s: %w", id, err)
+	}
+
+	return &record, nil
+}
+
+func (s *fileStore) remove(id string) error {
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return dmhst.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete history record: %w", err)
+	}
+
+	return nil
+}
+
+// Whether the record is older than the maximum age, read from its ID
+func (s *fileStore) expired(id string) bool {
+	if s.retention.MaxAge <= 0 {
+		return false
+	}
+
+	nanos, err := strconv.ParseInt(idPattern.FindStringSubmatch(id)[2], 16, 64)
+	if err != nil {
+		return false
+	}
+
+	return time.Since(time.Unix(0, nanos)) > s.retention.MaxAge
+}
+
+func (s *fileStore) path(id string) string {
+	return filepath.Join(s.dir, id[:16], id+".json")
+}
+
+// Key of the page, the directory of its records
+func pageKey(url string) string {
+	sum := sha256.Sum256([]byte(utlurl.Normalize(url)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Write the file through a temporary file in the same directory, so that readers never see half of it
+func writeFile(dir, path string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, "record-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,286 @@
+package history_store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	dmhst "web-pages-analyzer/internal/domain/history"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+)
+
+func newRecord(url, title string, age time.Duration) *dmhst.Record {
+	analysis := dmpg.NewWebPageAnalysis()
+	analysis.Set(dmpg.TitleSection, title)
+	analysis.Meta.Status = dmpg.StatusSuccess
+
+	return &dmhst.Record{
+		URL:       url,
+		Options:   dmpg.AnalyzeOptions{Fields: []string{dmpg.TitleSection}},
+		CreatedAt: time.Now().Add(-age),
+		Status:    dmpg.StatusSuccess,
+		Analysis:  analysis,
+	}
+}
+
+func newStore(t *testing.T, retention dmhst.Retention, records ...*dmhst.Record) dmhst.Store {
+	store, err := NewFile(filepath.Join(t.TempDir(), "history"), retention)
+	if err != nil {
+		t.Fatalf("expected nil error: got %v", err)
+	}
+
+	for _, record := range records {
+		if err = store.Save(record); err != nil {
+			t.Fatalf("expected nil error: got %v", err)
+		}
+	}
+
+	return store
+}
+
+func titles(t *testing.T, store dmhst.Store, records []dmhst.Record) []string {
+	var titles []string
+	for _, record := range records {
+		if record.Analysis != nil {
+			t.Errorf("expected listed records without analysis, got %+v", record)
+		}
+		if record.URL == "" || record.Status != dmpg.StatusSuccess || record.CreatedAt.IsZero() {
+			t.Errorf("expected listed records with their summary, got %+v", record)
+		}
+
+		full, err := store.Get(record.ID)
+		if err != nil {
+			t.Fatalf("expected nil error: got %v", err)
+		}
+		title, err := dmpg.DecodeSection[string](full.Analysis, dmpg.TitleSection)
+		if err != nil {
+			t.Fatalf("expected nil error: got %v", err)
+		}
+		titles = append(titles, title)
+	}
+
+	return titles
+}
+
+func Test_FileStore_List(t *testing.T) {
+	tests := []struct {
+		name           string
+		retention      dmhst.Retention
+		records        []*dmhst.Record
+		url            string
+		limit          int
+		corrupted      []int // Records whose file is overwritten once saved
+		expectedTitles []string
+	}{
+		{
+			name: "records of the page newest first",
+			records: []*dmhst.Record{
+				newRecord("https://example.com", "first", 3*time.Hour),
+				newRecord("https://example.org", "other", 2*time.Hour),
+				newRecord("https://example.com", "second", time.Hour),
+			},
+			url:            "https://example.com",
+			expectedTitles: []string{"second", "first"},
+		},
+		{
+			name: "spellings of the same URL share the history",
+			records: []*dmhst.Record{
+				newRecord("https://Example.com:443/#top", "first", 2*time.Hour),
+				newRecord("https://example.com/", "second", time.Hour),
+			},
+			url:            "HTTPS://example.com",
+			expectedTitles: []string{"second", "first"},
+		},
+		{
+			name: "limit keeps the newest",
+			records: []*dmhst.Record{
+				newRecord("https://example.com", "first", 3*time.Hour),
+				newRecord("https://example.com", "second", 2*time.Hour),
+				newRecord("https://example.com", "third", time.Hour),
+			},
+			url:            "https://example.com",
+			limit:          2,
+			expectedTitles: []string{"third", "second"},
+		},
+		{
+			name:      "oldest records beyond the maximum count are removed",
+			retention: dmhst.Retention{MaxPerURL: 2},
+			records: []*dmhst.Record{
+				newRecord("https://example.com", "first", 3*time.Hour),
+				newRecord("https://example.com", "second", 2*time.Hour),
+				newRecord("https://example.com", "third", time.Hour),
+			},
+			url:            "https://example.com",
+			expectedTitles: []string{"third", "second"},
+		},
+		{
+			name:      "records older than the maximum age are removed",
+			retention: dmhst.Retention{MaxAge: 90 * time.Minute},
+			records: []*dmhst.Record{
+				newRecord("https://example.com", "first", 2*time.Hour),
+				newRecord("https://example.com", "second", time.Hour),
+			},
+			url:            "https://example.com",
+			expectedTitles: []string{"second"},
+		},
+		{
+			name: "unreadable records are left out",
+			records: []*dmhst.Record{
+				newRecord("https://example.com", "first", 3*time.Hour),
+				newRecord("https://example.com", "second", 2*time.Hour),
+				newRecord("https://example.com", "third", time.Hour),
+			},
+			url:            "https://example.com",
+			corrupted:      []int{1},
+			expectedTitles: []string{"third", "first"},
+		},
+		{
+			name:    "page without history",
+			records: []*dmhst.Record{newRecord("https://example.org", "other", time.Hour)},
+			url:     "https://example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStore(t, tt.retention, tt.records...)
+			for _, i := range tt.corrupted {
+				if err := os.WriteFile(store.(*fileStore).path(tt.records[i].ID), []byte("{"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			records, err := store.List(tt.url, tt.limit)
+
+			// Verify results
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+
+			got := titles(t, store, records)
+			if len(got) != len(tt.expectedTitles) {
+				t.Fatalf("expected titles %v, got %v", tt.expectedTitles, got)
+			}
+			for i := range got {
+				if got[i] != tt.expectedTitles[i] {
+					t.Errorf("expected titles %v, got %v", tt.expectedTitles, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+func Test_FileStore_List_SkipsAnalysis(t *testing.T) {
+	record := newRecord("https://example.com", "Example", time.Hour)
+	store := newStore(t, dmhst.Retention{}, record)
+
+	// The analysis is cut short, the summary before it is still listed
+	path := store.(*fileStore).path(record.ID)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cut := strings.Index(string(data), `"analysis":`) + len(`"analysis":{`)
+	if err = os.WriteFile(path, data[:cut], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := store.List(record.URL, 0)
+
+	// Verify results
+	if err != nil {
+		t.Fatalf("expected nil error: got %v", err)
+	}
+	if len(records) != 1 || records[0].ID != record.ID || records[0].URL != record.URL || records[0].Analysis != nil {
+		t.Errorf("expected the summary of record %s, got %+v", record.ID, records)
+	}
+	if !slices.Equal(records[0].Options.Fields, record.Options.Fields) || !records[0].CreatedAt.Equal(record.CreatedAt) {
+		t.Errorf("expected options %+v created at %v, got %+v", record.Options, record.CreatedAt, records[0])
+	}
+}
+
+func Test_FileStore_Get(t *testing.T) {
+	record := newRecord("https://example.com", "Example", time.Hour)
+	expired := newRecord("https://example.com", "Expired", 3*time.Hour)
+	store := newStore(t, dmhst.Retention{MaxAge: 2 * time.Hour}, record)
+
+	// A record which expired since it was saved, the store prunes on save
+	dir := store.(*fileStore).dir
+	expiredStore := &fileStore{dir: dir}
+	if err := expiredStore.Save(expired); err != nil {
+		t.Fatalf("expected nil error: got %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		id            string
+		expectedError error
+	}{
+		{name: "saved record", id: record.ID},
+		{name: "expired record", id: expired.ID, expectedError: dmhst.ErrNotFound},
+		{name: "unknown record", id: "0123456789abcdef-0123456789abcdef-01234567", expectedError: dmhst.ErrNotFound},
+		{name: "malformed ID", id: "../../etc/passwd", expectedError: dmhst.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Get(tt.id)
+
+			// Verify results
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+
+			if got.ID != record.ID || got.URL != record.URL || !got.CreatedAt.Equal(record.CreatedAt) || got.Status != record.Status {
+				t.Errorf("expected record %+v, got %+v", record, got)
+			}
+			if len(got.Options.Fields) != 1 || got.Options.Fields[0] != dmpg.TitleSection {
+				t.Errorf("expected the options to be kept, got %+v", got.Options)
+			}
+		})
+	}
+
+	if _, err := os.Stat(expiredStore.path(expired.ID)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the expired record to be removed, got %v", err)
+	}
+}
+
+func Test_FileStore_Delete(t *testing.T) {
+	record := newRecord("https://example.com", "Example", time.Hour)
+	store := newStore(t, dmhst.Retention{}, record)
+
+	tests := []struct {
+		name          string
+		id            string
+		expectedError error
+	}{
+		{name: "saved record", id: record.ID},
+		{name: "deleted record", id: record.ID, expectedError: dmhst.ErrNotFound},
+		{name: "malformed ID", id: "..", expectedError: dmhst.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := store.Delete(tt.id)
+
+			// Verify results
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if _, err = store.Get(tt.id); !errors.Is(err, dmhst.ErrNotFound) {
+				t.Errorf("expected the record to be gone, got %v", err)
+			}
+		})
+	}
+}
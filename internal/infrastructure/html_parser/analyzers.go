@@ -0,0 +1,103 @@
+package html_parser
+
+import (
+	"context"
+	"strings"
+
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+	dmext "web-pages-analyzer/internal/domain/extraction"
+	dmfp "web-pages-analyzer/internal/domain/fingerprint"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+	dmtrk "web-pages-analyzer/internal/domain/tracker"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+)
+
+// Analyzers of the HTML document, in the order of their sections in the analysis
+func Analyzers() []dmanl.Analyzer {
+	return []dmanl.Analyzer{
+		dmanl.NewVisitingAnalyzer(dmpg.HTMLVersionSection, visitors(doctypeVisitorSpec), func(_ context.Context, doc *dmanl.Document) (any, error) {
+			_, version := describeDoctype(visitorOf[*doctypeVisitor](doc, doctypeVisitorSpec).doctype)
+			return version, nil
+		}),
+		dmanl.NewVisitingAnalyzer(dmpg.DoctypeSection, visitors(doctypeVisitorSpec), func(_ context.Context, doc *dmanl.Document) (any, error) {
+			analysis, _ := describeDoctype(visitorOf[*doctypeVisitor](doc, doctypeVisitorSpec).doctype)
+			return *analysis, nil
+		}),
+		dmanl.NewVisitingAnalyzer(dmpg.TitleSection, visitors(titleVisitorSpec), func(_ context.Context, doc *dmanl.Document) (any, error) {
+			return strings.TrimSpace(visitorOf[*titleVisitor](doc, titleVisitorSpec).title), nil
+		}),
+		dmanl.NewVisitingAnalyzer(dmpg.HeadingsSection, visitors(headingsVisitorSpec), func(_ context.Context, doc *dmanl.Document) (any, error) {
+			return visitorOf[*headingsVisitor](doc, headingsVisitorSpec).counts, nil
+		}),
+		dmanl.NewVisitingAnalyzer(dmpg.HeadingOutlineSection, visitors(headingsVisitorSpec), func(_ context.Context, doc *dmanl.Document) (any, error) {
+			return visitorOf[*headingsVisitor](doc, headingsVisitorSpec).outline, nil
+		}),
+		dmanl.NewVisitingAnalyzer(dmpg.LinksSection, visitors(linksVisitorSpec), func(ctx context.Context, doc *dmanl.Document) (any, error) {
+			analysis, err := parserOf(doc).analyzeLinks(ctx, visitorOf[*linksVisitor](doc, linksVisitorSpec))
+			return *analysis, err
+		}),
+		dmanl.NewVisitingAnalyzer(dmpg.HasLoginFormSection, visitors(formsVisitorSpec), func(_ context.Context, doc *dmanl.Document) (any, error) {
+			return formAnalysis(doc).HasLoginForm, nil
+		}),
+		dmanl.NewVisitingAnalyzer(dmpg.FormsSection, visitors(formsVisitorSpec), func(_ context.Context, doc *dmanl.Document) (any, error) {
+			return *formAnalysis(doc), nil
+		}),
+		dmanl.NewVisitingAnalyzer(dmpg.MixedContentSection, visitors(resourcesVisitorSpec), func(_ context.Context, doc *dmanl.Document) (any, error) {
+			refs := visitorOf[*resourcesVisitor](doc, resourcesVisitorSpec).refs
+			return *detectMixedContent(refs, parserOf(doc).baseUrl), nil
+		}),
+		dmanl.NewVisitingAnalyzer(dmpg.ResourcesSection, visitors(resourcesVisitorSpec), func(ctx context.Context, doc *dmanl.Document) (any, error) {
+			inventory, err := parserOf(doc).inventoryResources(ctx, visitorOf[*resourcesVisitor](doc, resourcesVisitorSpec))
+			return *inventory, err
+		}),
+		dmanl.NewVisitingAnalyzer(dmpg.ContentSection, visitors(textVisitorSpec), func(_ context.Context, doc *dmanl.Document) (any, error) {
+			return *parserOf(doc).contentAnalysis(visitorOf[*textVisitor](doc, textVisitorSpec).blocks), nil
+		}),
+	}
+}
+
+// Match technology signatures against the document and the response headers and cookies
+func NewTechnologiesAnalyzer(fingerprinter dmfp.Fingerprinter) dmanl.Analyzer {
+	specs := visitors(resourcesVisitorSpec, fingerprintVisitorSpec)
+	return dmanl.NewVisitingAnalyzer(dmpg.TechnologiesSection, specs, func(_ context.Context, doc *dmanl.Document) (any, error) {
+		evidence := fingerprintEvidence(
+			visitorOf[*resourcesVisitor](doc, resourcesVisitorSpec),
+			visitorOf[*fingerprintVisitor](doc, fingerprintVisitorSpec),
+		)
+		if doc.Response != nil {
+			evidence.Header = doc.Response.Header
+		}
+
+		return fingerprinter.Fingerprint(evidence), nil
+	})
+}
+
+func NewTrackersAnalyzer(detector dmtrk.TrackerDetector) dmanl.Analyzer {
+	specs := visitors(resourcesVisitorSpec, trackersVisitorSpec)
+	return dmanl.NewVisitingAnalyzer(dmpg.TrackersSection, specs, func(_ context.Context, doc *dmanl.Document) (any, error) {
+		evidence := parserOf(doc).trackerEvidence(
+			visitorOf[*resourcesVisitor](doc, resourcesVisitorSpec),
+			visitorOf[*trackersVisitor](doc, trackersVisitorSpec),
+		)
+
+		return *detector.Detect(evidence), nil
+	})
+}
+
+// The form analysis shared by the "forms" and "has_login_form" sections
+func formAnalysis(doc *dmanl.Document) *dmhtml.FormAnalysis {
+	return parserOf(doc).formAnalysis(func() *formsVisitor {
+		return visitorOf[*formsVisitor](doc, formsVisitorSpec)
+	})
+}
+
+func visitors(specs ...dmanl.VisitorSpec) []dmanl.VisitorSpec {
+	return specs
+}
+
+// Extract the main content of the document as a *dmext.Article
+func NewArticleAnalyzer() dmanl.Analyzer {
+	return dmanl.NewAnalyzer(dmext.ArticleSection, func(_ context.Context, doc *dmanl.Document) (any, error) {
+		return parserOf(doc).extractArticle(), nil
+	})
+}
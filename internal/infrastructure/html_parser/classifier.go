@@ -0,0 +1,101 @@
+package html_parser
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+
+	dmhtml "web-pages-analyzer/internal/domain/html"
+)
+
+// Decides whether a URL belongs to the analyzed site
+type hostClassifier struct {
+	mode              string
+	baseScheme        string
+	baseHost          string
+	basePort          string
+	baseDomain        string
+	firstPartyDomains []string
+}
+
+func newHostClassifier(base *url.URL, opts dmhtml.ParserOptions) *hostClassifier {
+	mode := opts.LinkClassification
+	if mode == "" {
+		mode = dmhtml.SameDomainClassification
+	}
+
+	classifier := &hostClassifier{
+		mode:       mode,
+		baseScheme: strings.ToLower(base.Scheme),
+		baseHost:   normalizeHost(base.Hostname()),
+		basePort:   effectivePort(base),
+	}
+	classifier.baseDomain = registrableDomain(classifier.baseHost)
+
+	for _, domain := range opts.FirstPartyDomains {
+		if domain = normalizeHost(domain); domain != "" {
+			classifier.firstPartyDomains = append(classifier.firstPartyDomains, domain)
+		}
+	}
+
+	return classifier
+}
+
+func (c *hostClassifier) isFirstParty(target *url.URL) bool {
+	// Relative URLs stay on the same host
+	if target.Host == "" {
+		return true
+	}
+
+	host := normalizeHost(target.Hostname())
+
+	switch c.mode {
+	case dmhtml.ExactHostClassification:
+		return host == c.baseHost && effectivePort(target) == c.basePort
+	case dmhtml.SameSiteClassification:
+		return strings.EqualFold(target.Scheme, c.baseScheme) && registrableDomain(host) == c.baseDomain
+	case dmhtml.CustomClassification:
+		if registrableDomain(host) == c.baseDomain {
+			return true
+		}
+		for _, domain := range c.firstPartyDomains {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+		return false
+	default:
+		return registrableDomain(host) == c.baseDomain
+	}
+}
+
+// Registrable domain (eTLD+1) of the host, e.g. "example.co.uk" for "blog.example.co.uk"
+func registrableDomain(host string) string {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		// IP addresses, localhost and bare public suffixes have no registrable domain
+		return host
+	}
+
+	return domain
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+func effectivePort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return "443"
+	case "http":
+		return "80"
+	}
+
+	return ""
+}
@@ -0,0 +1,231 @@
+package html_parser
+
+import (
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+)
+
+// Number of keywords and phrases reported for each list
+const maxKeywords = 10
+
+// Elements whose content is never rendered as page text
+var nonTextElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true,
+	"iframe": true, "object": true, "svg": true, "math": true, "canvas": true,
+}
+
+// Elements which start a new block of text, text never runs across their boundaries
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true, "br": true,
+	"dd": true, "details": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true, "li": true,
+	"main": true, "nav": true, "ol": true, "p": true, "pre": true, "section": true,
+	"summary": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+	"button": true, "option": true, "caption": true,
+}
+
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`a about above after again against all am an and any are as at be because
+		been before being below between both but by can could did do does doing down during each few for from
+		further had has have having he her here hers herself him himself his how i if in into is it its itself
+		just me more most my myself no nor not now of off on once only or other our ours ourselves out over own
+		same she should so some such than that the their theirs them themselves then there these they this
+		those through to too under until up very was we were what when where which while who whom why will
+		with would you your yours yourself yourselves also may might must shall us get got can't don't it's
+		i'm you're we're they're isn't aren't wasn't weren't won't let's`) {
+		stopWords[word] = true
+	}
+}
+
+// Words are runs of letters and digits, joined by inner apostrophes or hyphens as in "don't" or "e-mail"
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+(?:['’-][\p{L}\p{N}]+)*`)
+
+// A sentence ends with terminal punctuation, optionally followed by closing quotes or brackets
+var sentenceEnd = regexp.MustCompile(`[.!?…]+["'”’)\]]*(?:\s+|$)`)
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// Measure the visible text of the page: size, readability and the most frequent keywords and phrases
+func (p *parser) contentAnalysis(blocks []string) *dmhtml.ContentAnalysis {
+	analysis := &dmhtml.ContentAnalysis{
+		HTMLBytes: int(p.htmlBytes),
+		Keywords:  []dmhtml.Keyword{},
+		Bigrams:   []dmhtml.Keyword{},
+		Trigrams:  []dmhtml.Keyword{},
+	}
+
+	analysis.TextBytes = len(strings.Join(blocks, "\n"))
+	if analysis.HTMLBytes > 0 {
+		analysis.TextToHTMLRatio = round(float64(analysis.TextBytes)/float64(analysis.HTMLBytes)*100, 2)
+	}
+
+	var sentences [][]string
+	syllables := 0
+	for _, block := range blocks {
+		for _, sentence := range sentenceEnd.Split(block, -1) {
+			words := wordPattern.FindAllString(sentence, -1)
+			if len(words) == 0 {
+				continue
+			}
+			for _, word := range words {
+				syllables += countSyllables(word)
+			}
+			analysis.WordCount += len(words)
+			sentences = append(sentences, words)
+		}
+	}
+	analysis.SentenceCount = len(sentences)
+
+	if analysis.WordCount == 0 {
+		return analysis
+	}
+
+	analysis.ReadingTimeSeconds = int(math.Ceil(float64(analysis.WordCount) * 60 / dmhtml.ReadingWordsPerMinute))
+	analysis.FleschReadingEase = round(206.835-
+		1.015*float64(analysis.WordCount)/float64(analysis.SentenceCount)-
+		84.6*float64(syllables)/float64(analysis.WordCount), 1)
+
+	analysis.Keywords = topTerms(countNGrams(sentences, 1), analysis.WordCount, 1)
+	analysis.Bigrams = topTerms(countNGrams(sentences, 2), analysis.WordCount, 2)
+	analysis.Trigrams = topTerms(countNGrams(sentences, 3), analysis.WordCount, 2)
+
+	return analysis
+}
+
+// Collect the rendered text of the document, one whitespace-normalized entry per block of text
+func extractTextBlocks(document *html.Node) []string {
+	visitor := &textVisitor{}
+	dmanl.Walk(document, visitor)
+
+	return visitor.blocks
+}
+
+// Elements hidden from readers by the hidden attribute, aria-hidden or an inline style
+func isHiddenElement(node *html.Node) bool {
+	if hasAttr(node, "hidden") || strings.EqualFold(getAttr(node, "aria-hidden"), "true") {
+		return true
+	}
+
+	if node.Data == "input" && strings.EqualFold(getAttr(node, "type"), "hidden") {
+		return true
+	}
+
+	return isHiddenByStyle(node)
+}
+
+func isHiddenByStyle(node *html.Node) bool {
+	style := strings.ReplaceAll(strings.ToLower(getAttr(node, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// Count the n-grams of every sentence which neither start nor end with a stop word
+func countNGrams(sentences [][]string, n int) map[string]int {
+	counts := make(map[string]int)
+
+	for _, words := range sentences {
+		for i := 0; i+n <= len(words); i++ {
+			first, last := strings.ToLower(words[i]), strings.ToLower(words[i+n-1])
+			if !isKeyword(first) || !isKeyword(last) {
+				continue
+			}
+
+			terms := make([]string, n)
+			for j := range terms {
+				terms[j] = strings.ToLower(words[i+j])
+			}
+			counts[strings.Join(terms, " ")]++
+		}
+	}
+
+	return counts
+}
+
+// Stop words, single characters and numbers carry no meaning on their own
+func isKeyword(word string) bool {
+	if stopWords[word] || len([]rune(word)) < 2 {
+		return false
+	}
+
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The most frequent terms seen at least minCount times, ties ordered alphabetically
+func topTerms(counts map[string]int, wordCount int, minCount int) []dmhtml.Keyword {
+	keywords := []dmhtml.Keyword{}
+	for term, count := range counts {
+		if count < minCount {
+			continue
+		}
+		keywords = append(keywords, dmhtml.Keyword{
+			Term:    term,
+			Count:   count,
+			Density: round(float64(count)/float64(wordCount)*100, 2),
+		})
+	}
+
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Count != keywords[j].Count {
+			return keywords[i].Count > keywords[j].Count
+		}
+		return keywords[i].Term < keywords[j].Term
+	})
+
+	if len(keywords) > maxKeywords {
+		keywords = keywords[:maxKeywords]
+	}
+
+	return keywords
+}
+
+// Estimate English syllables by counting vowel groups, a trailing silent "e" does not count
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+
+	count := 0
+	previousVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !previousVowel {
+			count++
+		}
+		previousVowel = vowel
+	}
+
+	if count > 1 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") {
+		count--
+	}
+
+	return max(count, 1)
+}
+
+func round(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
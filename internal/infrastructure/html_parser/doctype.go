@@ -0,0 +1,204 @@
+package html_parser
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+
+	dmhtml "web-pages-analyzer/internal/domain/html"
+	utlstr "web-pages-analyzer/internal/utils/string"
+)
+
+const (
+	html5Version   = "HTML5"
+	html401Version = "HTML 4.01"
+	html40Version  = "HTML 4.0"
+	html32Version  = "HTML 3.2"
+	html20Version  = "HTML 2.0"
+	xhtmlVersion   = "XHTML"
+	unknownVersion = "Unknown HTML Version"
+
+	unknownDTD = "Unknown"
+)
+
+type dtdVariant struct {
+	publicPrefix string
+	dtd          string
+	version      string
+}
+
+// Known public identifiers (lower case) and the DTD variant they declare
+var dtdVariants = []dtdVariant{
+	{"-//w3c//dtd html 4.01//", "HTML 4.01 Strict", html401Version},
+	{"-//w3c//dtd html 4.01 transitional//", "HTML 4.01 Transitional", html401Version},
+	{"-//w3c//dtd html 4.01 frameset//", "HTML 4.01 Frameset", html401Version},
+	{"-//w3c//dtd html 4.0//", "HTML 4.0 Strict", html40Version},
+	{"-//w3c//dtd html 4.0 transitional//", "HTML 4.0 Transitional", html40Version},
+	{"-//w3c//dtd html 4.0 frameset//", "HTML 4.0 Frameset", html40Version},
+	{"-//w3c//dtd xhtml 1.0 strict//", "XHTML 1.0 Strict", xhtmlVersion},
+	{"-//w3c//dtd xhtml 1.0 transitional//", "XHTML 1.0 Transitional", xhtmlVersion},
+	{"-//w3c//dtd xhtml 1.0 frameset//", "XHTML 1.0 Frameset", xhtmlVersion},
+	{"-//w3c//dtd xhtml 1.1//", "XHTML 1.1", xhtmlVersion},
+	{"-//w3c//dtd xhtml basic 1.0//", "XHTML Basic 1.0", xhtmlVersion},
+	{"-//w3c//dtd xhtml basic 1.1//", "XHTML Basic 1.1", xhtmlVersion},
+	{"-//w3c//dtd xhtml+rdfa 1.0//", "XHTML+RDFa 1.0", xhtmlVersion},
+	{"-//w3c//dtd xhtml+rdfa 1.1//", "XHTML+RDFa 1.1", xhtmlVersion},
+	{"-//wapforum//dtd xhtml mobile 1.0//", "XHTML Mobile 1.0", xhtmlVersion},
+	{"-//wapforum//dtd xhtml mobile 1.1//", "XHTML Mobile 1.1", xhtmlVersion},
+	{"-//wapforum//dtd xhtml mobile 1.2//", "XHTML Mobile 1.2", xhtmlVersion},
+	{"-//w3c//dtd html 3.2 final//", "HTML 3.2 Final", html32Version},
+	{"-//w3c//dtd html 3.2//", "HTML 3.2", html32Version},
+	{"-//ietf//dtd html 2.0//", "HTML 2.0", html20Version},
+	{"-//ietf//dtd html//", "HTML 2.0", html20Version},
+}
+
+// Public identifiers which trigger quirks mode, as listed in the WHATWG HTML spec
+var quirksPublicIDPrefixes = []string{
+	"+//silmaril//dtd html pro v0r11 19970101//",
+	"-//as//dtd html 3.0 aswedit + extensions//",
+	"-//advasoft ltd//dtd html 3.0 aswedit + extensions//",
+	"-//ietf//dtd html 2.0 level 1//",
+	"-//ietf//dtd html 2.0 level 2//",
+	"-//ietf//dtd html 2.0 strict level 1//",
+	"-//ietf//dtd html 2.0 strict level 2//",
+	"-//ietf//dtd html 2.0 strict//",
+	"-//ietf//dtd html 2.0//",
+	"-//ietf//dtd html 2.1e//",
+	"-//ietf//dtd html 3.0//",
+	"-//ietf//dtd html 3.2 final//",
+	"-//ietf//dtd html 3.2//",
+	"-//ietf//dtd html 3//",
+	"-//ietf//dtd html level 0//",
+	"-//ietf//dtd html level 1//",
+	"-//ietf//dtd html level 2//",
+	"-//ietf//dtd html level 3//",
+	"-//ietf//dtd html strict level 0//",
+	"-//ietf//dtd html strict level 1//",
+	"-//ietf//dtd html strict level 2//",
+	"-//ietf//dtd html strict level 3//",
+	"-//ietf//dtd html strict//",
+	"-//ietf//dtd html//",
+	"-//metrius//dtd metrius presentational//",
+	"-//microsoft//dtd internet explorer 2.0 html strict//",
+	"-//microsoft//dtd internet explorer 2.0 html//",
+	"-//microsoft//dtd internet explorer 2.0 tables//",
+	"-//microsoft//dtd internet explorer 3.0 html strict//",
+	"-//microsoft//dtd internet explorer 3.0 html//",
+	"-//microsoft//dtd internet explorer 3.0 tables//",
+	"-//netscape comm. corp.//dtd html//",
+	"-//netscape comm. corp.//dtd strict html//",
+	"-//o'reilly and associates//dtd html 2.0//",
+	"-//o'reilly and associates//dtd html extended 1.0//",
+	"-//o'reilly and associates//dtd html extended relaxed 1.0//",
+	"-//sq//dtd html 2.0 hotmetal + extensions//",
+	"-//softquad software//dtd hotmetal pro 6.0::19990601::extensions to html 4.0//",
+	"-//softquad//dtd hotmetal pro 4.0::19971010::extensions to html 4.0//",
+	"-//spyglass//dtd html 2.0 extended//",
+	"-//sun microsystems corp.//dtd hotjava html//",
+	"-//sun microsystems corp.//dtd hotjava strict html//",
+	"-//w3c//dtd html 3 1995-03-24//",
+	"-//w3c//dtd html 3.2 draft//",
+	"-//w3c//dtd html 3.2 final//",
+	"-//w3c//dtd html 3.2//",
+	"-//w3c//dtd html 3.2s draft//",
+	"-//w3c//dtd html 4.0 frameset//",
+	"-//w3c//dtd html 4.0 transitional//",
+	"-//w3c//dtd html experimental 19960712//",
+	"-//w3c//dtd html experimental 970421//",
+	"-//w3c//dtd w3 html//",
+	"-//w3o//dtd w3 html 3.0//",
+	"-//webtechs//dtd mozilla html 2.0//",
+	"-//webtechs//dtd mozilla html//",
+}
+
+// Describe a DOCTYPE node, a missing DOCTYPE triggers quirks mode
+func describeDoctype(doctype *html.Node) (*dmhtml.DoctypeAnalysis, string) {
+	if doctype == nil {
+		return &dmhtml.DoctypeAnalysis{
+			DTD:           unknownDTD,
+			RenderingMode: dmhtml.QuirksMode,
+		}, unknownVersion
+	}
+
+	analysis := &dmhtml.DoctypeAnalysis{Name: doctype.Data}
+	hasSystemID := false
+
+	for _, attr := range doctype.Attr {
+		switch attr.Key {
+		case "public":
+			analysis.PublicID = attr.Val
+		case "system":
+			analysis.SystemID = attr.Val
+			hasSystemID = true
+		}
+	}
+
+	version := unknownVersion
+	analysis.DTD = unknownDTD
+
+	publicID := strings.ToLower(analysis.PublicID)
+	if analysis.Name == "html" {
+		if publicID == "" {
+			if analysis.SystemID == "" || strings.EqualFold(analysis.SystemID, "about:legacy-compat") {
+				analysis.DTD = html5Version
+				version = html5Version
+			}
+		} else {
+			for _, variant := range dtdVariants {
+				if strings.HasPrefix(publicID, variant.publicPrefix) {
+					analysis.DTD = variant.dtd
+					version = variant.version
+					break
+				}
+			}
+		}
+	}
+
+	analysis.RenderingMode = renderingMode(analysis.Name, publicID, strings.ToLower(analysis.SystemID), hasSystemID)
+
+	return analysis, version
+}
+
+// Search for the DOCTYPE node, which is always a direct child of the document node
+func findDoctype(document *html.Node) *html.Node {
+	for child := document.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.DoctypeNode {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// Determine the rendering mode following the WHATWG "initial" insertion mode rules
+func renderingMode(name string, publicID string, systemID string, hasSystemID bool) string {
+	if name != "html" {
+		return dmhtml.QuirksMode
+	}
+
+	switch publicID {
+	case "-//w3o//dtd w3 html strict 3.0//en//", "-/w3d/dtd html 4.0 transitional/en", "html":
+		return dmhtml.QuirksMode
+	}
+
+	if systemID == "http://www.ibm.com/data/dtd/v11/ibmxhtml1-transitional.dtd" {
+		return dmhtml.QuirksMode
+	}
+
+	if utlstr.ContainsAnyPrefix(publicID, quirksPublicIDPrefixes...) {
+		return dmhtml.QuirksMode
+	}
+
+	if utlstr.ContainsAnyPrefix(publicID, "-//w3c//dtd html 4.01 frameset//", "-//w3c//dtd html 4.01 transitional//") {
+		if !hasSystemID {
+			return dmhtml.QuirksMode
+		}
+		return dmhtml.AlmostStandardsMode
+	}
+
+	if utlstr.ContainsAnyPrefix(publicID, "-//w3c//dtd xhtml 1.0 frameset//", "-//w3c//dtd xhtml 1.0 transitional//") {
+		return dmhtml.AlmostStandardsMode
+	}
+
+	return dmhtml.StandardsMode
+}
@@ -0,0 +1,78 @@
+package html_parser
+
+import (
+	"io"
+	"net/url"
+
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+	clihttp "web-pages-analyzer/internal/domain/clients/http"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+)
+
+// Memo key of the parser state shared by the HTML analyzers of a document
+const parserMemoKey = "html_parser"
+
+type documentParser struct{}
+
+func NewDocumentParser() dmanl.DocumentParser {
+	return &documentParser{}
+}
+
+func (dp *documentParser) Parse(body io.Reader, pageURL string, client clihttp.HttpClient, opts *dmhtml.ParserOptions) (*dmanl.Document, error) {
+	p, err := parse(body, pageURL, client, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &dmanl.Document{
+		URL:     pageURL,
+		Root:    p.node,
+		Size:    p.htmlBytes,
+		Options: p.opts,
+		Client:  client,
+	}
+	doc.Memo(parserMemoKey, func() any { return p })
+
+	return doc, nil
+}
+
+// Prepare a streamed document, which is tokenized as it is traversed and never held in memory as a whole
+func (dp *documentParser) Stream(body io.Reader, pageURL string, client clihttp.HttpClient, opts *dmhtml.ParserOptions) (*dmanl.Document, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts == nil {
+		opts = &dmhtml.ParserOptions{}
+	}
+
+	if err = opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := newParser(nil, base, client, *opts, 0)
+
+	doc := &dmanl.Document{
+		URL:     pageURL,
+		Options: p.opts,
+		Client:  client,
+		Stream: func(visitors ...dmanl.Visitor) error {
+			return p.stream(body, visitors...)
+		},
+	}
+	doc.Memo(parserMemoKey, func() any { return p })
+
+	return doc, nil
+}
+
+// Parser state of the document, built from its fields when it was not parsed by this package
+func parserOf(doc *dmanl.Document) *parser {
+	return doc.Memo(parserMemoKey, func() any {
+		base, err := url.Parse(doc.URL)
+		if err != nil {
+			base = &url.URL{}
+		}
+		return newParser(doc.Root, base, doc.Client, doc.Options, doc.Size)
+	}).(*parser)
+}
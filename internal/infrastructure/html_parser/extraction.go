@@ -0,0 +1,463 @@
+package html_parser
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	dmext "web-pages-analyzer/internal/domain/extraction"
+	utlstr "web-pages-analyzer/internal/utils/string"
+)
+
+// Paragraphs shorter than this are too short to tell content from boilerplate
+const minParagraphLength = 25
+
+// Class and id hints for blocks which usually hold, or never hold, the main content
+var (
+	positiveContentHint = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|blog|story`)
+	negativeContentHint = regexp.MustCompile(`(?i)comment|meta|footer|footnote|sidebar|sponsor|(^|[\s_-])ads?([\s_-]|$)|advert|share|social|nav|menu|related|promo|widget|banner|breadcrumb|masthead|cookie|popup|modal|newsletter|subscribe`)
+)
+
+// Landmark roles which never hold the main content
+var boilerplateRoles = []string{"navigation", "complementary", "banner", "contentinfo", "search", "dialog"}
+
+// Elements dropped from the extracted content on top of the ones never rendered as text
+var boilerplateElements = map[string]bool{
+	"nav": true, "aside": true, "footer": true, "form": true, "button": true, "input": true,
+	"select": true, "textarea": true, "dialog": true, "label": true,
+}
+
+// Attributes kept on the elements of the extracted content
+var contentAttributes = map[string][]string{
+	"a":    {"href", "title"},
+	"img":  {"src", "alt", "title"},
+	"td":   {"colspan", "rowspan"},
+	"th":   {"colspan", "rowspan"},
+	"time": {"datetime"},
+	"ol":   {"start"},
+}
+
+// Elements which may be kept without children
+var emptyContentElements = map[string]bool{"img": true, "br": true, "hr": true, "td": true, "th": true}
+
+// Extract the main content of the page with boilerplate-removal heuristics, as clean HTML, Markdown and text
+func (p *parser) extractArticle() *dmext.Article {
+	article := &dmext.Article{URL: p.baseUrl.String()}
+
+	bodies := findElements(p.node, "body")
+	if len(bodies) == 0 {
+		return article
+	}
+
+	lengths := newTextLengths(bodies[0])
+	content := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, node := range selectMainContent(bodies[0], lengths) {
+		if cleaned := p.cleanContent(node, lengths); cleaned != nil {
+			if cleaned.Data == "body" {
+				cleaned.Data, cleaned.DataAtom = "div", atom.Div
+			}
+			content.AppendChild(cleaned)
+		}
+	}
+
+	var rendered bytes.Buffer
+	if err := html.Render(&rendered, content); err == nil {
+		article.HTML = rendered.String()
+	}
+
+	article.Text = strings.Join(extractTextBlocks(content), "\n\n")
+	article.WordCount = len(wordPattern.FindAllString(article.Text, -1))
+	article.Markdown = renderMarkdown(content)
+
+	article.Title = findMeta(p.node, "og:title", "twitter:title")
+	if article.Title == "" {
+		visitor := &titleVisitor{}
+		p.walk(visitor)
+		article.Title = strings.TrimSpace(visitor.title)
+	}
+
+	article.Byline = findByline(p.node)
+	article.PublishedDate = findPublishedDate(p.node, content)
+
+	article.LeadImage = resolveURL(findMeta(p.node, "og:image", "og:image:url", "twitter:image"), p.docBaseUrl)
+	if article.LeadImage == "" {
+		if images := findElements(content, "img"); len(images) > 0 {
+			article.LeadImage = getAttr(images[0], "src")
+		}
+	}
+
+	return article
+}
+
+// Score the blocks holding paragraphs and select the best one, together with the siblings continuing it
+func selectMainContent(body *html.Node, lengths textLengths) []*html.Node {
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			if nonTextElements[node.Data] || boilerplateElements[node.Data] || isHiddenElement(node) || isUnlikelyCandidate(node) {
+				return
+			}
+
+			if isParagraph(node) {
+				if text := visibleText(node); len(text) >= minParagraphLength {
+					score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+
+					// The parent gets the full score, the grandparents a share of it
+					dividers := []float64{1, 2, 6}
+					ancestor := node.Parent
+					for level := 0; level < len(dividers) && ancestor != nil && ancestor.Type == html.ElementNode; level++ {
+						if _, ok := scores[ancestor]; !ok {
+							scores[ancestor] = initialContentScore(ancestor)
+							candidates = append(candidates, ancestor)
+						}
+						scores[ancestor] += score / dividers[level]
+						ancestor = ancestor.Parent
+					}
+				}
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(body)
+
+	var top *html.Node
+	var topScore float64
+	for _, candidate := range candidates {
+		score := scores[candidate] * (1 - lengths.linkDensity(candidate))
+		scores[candidate] = score
+		if top == nil || score > topScore {
+			top, topScore = candidate, score
+		}
+	}
+
+	if top == nil {
+		return []*html.Node{body}
+	}
+	if top.Parent == nil || top.Data == "body" {
+		return []*html.Node{top}
+	}
+
+	// Articles are often split over sibling blocks, e.g. a lead paragraph before the body
+	threshold := math.Max(10, topScore*0.2)
+	var selected []*html.Node
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling == top {
+			selected = append(selected, sibling)
+			continue
+		}
+		if sibling.Type != html.ElementNode || isUnlikelyCandidate(sibling) {
+			continue
+		}
+
+		if score, ok := scores[sibling]; ok && score >= threshold {
+			selected = append(selected, sibling)
+		} else if sibling.Data == "p" && lengths.of(sibling).text >= 80 && lengths.linkDensity(sibling) < 0.25 {
+			selected = append(selected, sibling)
+		}
+	}
+
+	return selected
+}
+
+// Paragraphs, and blocks used as paragraphs which hold no other block
+func isParagraph(node *html.Node) bool {
+	switch node.Data {
+	case "p", "pre", "blockquote":
+		return true
+	case "div", "section":
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && blockElements[child.Data] {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func initialContentScore(node *html.Node) float64 {
+	var score float64
+
+	switch node.Data {
+	case "article":
+		score = 25
+	case "main":
+		score = 15
+	case "div", "section":
+		score = 5
+	case "pre", "td", "blockquote":
+		score = 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li":
+		score = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score = -5
+	}
+
+	hints := getAttr(node, "class") + " " + getAttr(node, "id")
+	if positiveContentHint.MatchString(hints) {
+		score += 25
+	}
+	if negativeContentHint.MatchString(hints) {
+		score -= 25
+	}
+
+	return score
+}
+
+// Blocks whose class, id or role marks them as navigation, ads, comments and the like
+func isUnlikelyCandidate(node *html.Node) bool {
+	switch node.Data {
+	case "html", "body", "article", "main", "a":
+		return false
+	}
+
+	if slices.Contains(boilerplateRoles, strings.ToLower(getAttr(node, "role"))) {
+		return true
+	}
+
+	hints := getAttr(node, "class") + " " + getAttr(node, "id")
+	return negativeContentHint.MatchString(hints) && !positiveContentHint.MatchString(hints)
+}
+
+// Length of the visible text of every element and of its part inside links, measured once bottom-up
+// so that scoring the candidates stays linear in the size of the page
+type textLengths map[*html.Node]textLength
+
+type textLength struct {
+	text  int
+	links int
+}
+
+func newTextLengths(root *html.Node) textLengths {
+	lengths := make(textLengths)
+	lengths.measure(root, false)
+	return lengths
+}
+
+func (l textLengths) measure(node *html.Node, inLink bool) textLength {
+	var length textLength
+
+	switch node.Type {
+	case html.TextNode:
+		length.text = len(strings.Join(strings.Fields(node.Data), " "))
+		if inLink {
+			length.links = length.text
+		}
+		return length
+	case html.ElementNode:
+		if nonTextElements[node.Data] || isHiddenElement(node) {
+			l[node] = length
+			return length
+		}
+		inLink = inLink || node.Data == "a"
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		childLength := l.measure(child, inLink)
+		length.text += childLength.text
+		length.links += childLength.links
+	}
+
+	l[node] = length
+	return length
+}
+
+// Lengths of the node, measured on demand for a node outside the measured tree
+func (l textLengths) of(node *html.Node) textLength {
+	if length, ok := l[node]; ok {
+		return length
+	}
+
+	return l.measure(node, false)
+}
+
+// Share of the visible text of the node which belongs to links
+func (l textLengths) linkDensity(node *html.Node) float64 {
+	length := l.of(node)
+	if length.text == 0 {
+		return 0
+	}
+
+	return math.Min(float64(length.links)/float64(length.text), 1)
+}
+
+func visibleText(node *html.Node) string {
+	return strings.Join(extractTextBlocks(node), " ")
+}
+
+// Copy the node without boilerplate, presentational attributes and empty elements, resolving URLs
+func (p *parser) cleanContent(node *html.Node, lengths textLengths) *html.Node {
+	switch node.Type {
+	case html.TextNode:
+		return &html.Node{Type: html.TextNode, Data: node.Data}
+	case html.ElementNode:
+	default:
+		return nil
+	}
+
+	if nonTextElements[node.Data] || boilerplateElements[node.Data] || isHiddenElement(node) || isUnlikelyCandidate(node) {
+		return nil
+	}
+
+	// Link lists inside the content, e.g. tag clouds or "read more" blocks
+	switch node.Data {
+	case "ul", "ol", "div", "section", "table":
+		if lengths.linkDensity(node) > 0.5 {
+			return nil
+		}
+	}
+
+	clean := &html.Node{Type: html.ElementNode, Data: node.Data, DataAtom: node.DataAtom}
+	for _, key := range contentAttributes[node.Data] {
+		value := getAttr(node, key)
+		switch {
+		case node.Data == "img" && key == "src":
+			// Lazy-loaded images keep their real source in a data attribute
+			if lazy := getAttr(node, "data-src"); lazy != "" {
+				value = lazy
+			}
+			value = resolveURL(strings.TrimSpace(value), p.docBaseUrl)
+		case node.Data == "a" && key == "href":
+			value = p.contentHref(strings.TrimSpace(value))
+		}
+		if value != "" {
+			clean.Attr = append(clean.Attr, html.Attribute{Key: key, Val: value})
+		}
+	}
+
+	if node.Data == "img" && getAttr(clean, "src") == "" {
+		return nil
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if cleaned := p.cleanContent(child, lengths); cleaned != nil {
+			clean.AppendChild(cleaned)
+		}
+	}
+
+	if clean.FirstChild == nil && !emptyContentElements[node.Data] {
+		return nil
+	}
+
+	return clean
+}
+
+// Resolve HTTP links against the document base, keep other schemes as they are and drop scripts
+func (p *parser) contentHref(href string) string {
+	switch hrefScheme(href) {
+	case "":
+		if strings.HasPrefix(href, "#") {
+			return href
+		}
+		return resolveURL(href, p.docBaseUrl)
+	case "http", "https":
+		return resolveURL(href, p.docBaseUrl)
+	case "javascript", "data":
+		return ""
+	}
+
+	return href
+}
+
+// Content of the first meta tag with one of the given names or properties
+func findMeta(document *html.Node, names ...string) string {
+	metas := findElements(document, "meta")
+	for _, name := range names {
+		for _, meta := range metas {
+			for _, key := range []string{"name", "property", "itemprop"} {
+				if strings.EqualFold(getAttr(meta, key), name) {
+					if content := strings.TrimSpace(getAttr(meta, "content")); content != "" {
+						return content
+					}
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// Author from the meta tags, or from an element marked as author or byline
+func findByline(document *html.Node) string {
+	if author := findMeta(document, "author", "byl", "parsely-author", "sailthru.author"); author != "" {
+		return trimByline(author)
+	}
+
+	var byline string
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if byline != "" {
+			return
+		}
+
+		if node.Type == html.ElementNode && !nonTextElements[node.Data] && node.Data != "meta" {
+			hints := strings.ToLower(getAttr(node, "class") + " " + getAttr(node, "id"))
+			if strings.EqualFold(getAttr(node, "rel"), "author") || strings.EqualFold(getAttr(node, "itemprop"), "author") ||
+				utlstr.ContainsAnySubstring(hints, "byline", "author") {
+				if text := visibleText(node); text != "" && len(text) < 100 {
+					byline = trimByline(text)
+					return
+				}
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(document)
+
+	return byline
+}
+
+func trimByline(byline string) string {
+	byline = strings.TrimSpace(byline)
+	if len(byline) > 3 && strings.EqualFold(byline[:3], "by ") {
+		byline = strings.TrimSpace(byline[3:])
+	}
+
+	return byline
+}
+
+// Publication date from the meta tags, a datePublished item or the first <time> of the content
+func findPublishedDate(document *html.Node, content *html.Node) string {
+	if date := findMeta(document, "article:published_time", "datePublished", "date", "pubdate", "publishdate",
+		"dc.date", "dc.date.issued", "dcterms.created", "sailthru.date", "parsely-pub-date"); date != "" {
+		return date
+	}
+
+	for _, tag := range []string{"time", "span", "div", "p"} {
+		for _, node := range findElements(document, tag) {
+			if strings.EqualFold(getAttr(node, "itemprop"), "datePublished") {
+				for _, key := range []string{"datetime", "content"} {
+					if date := strings.TrimSpace(getAttr(node, key)); date != "" {
+						return date
+					}
+				}
+				return visibleText(node)
+			}
+		}
+	}
+
+	for _, root := range []*html.Node{content, document} {
+		for _, node := range findElements(root, "time") {
+			if date := strings.TrimSpace(getAttr(node, "datetime")); date != "" {
+				return date
+			}
+		}
+	}
+
+	return ""
+}
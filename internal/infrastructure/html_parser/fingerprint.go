@@ -0,0 +1,36 @@
+package html_parser
+
+import (
+	"slices"
+	"sort"
+
+	dmfp "web-pages-analyzer/internal/domain/fingerprint"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+)
+
+// Collect the parts of the document technology signatures match against
+func fingerprintEvidence(resources *resourcesVisitor, fingerprint *fingerprintVisitor) *dmfp.Evidence {
+	evidence := &dmfp.Evidence{
+		Meta:          make(map[string][]string, len(fingerprint.meta)),
+		InlineScripts: slices.Clone(fingerprint.inlineScripts),
+	}
+	for name, contents := range fingerprint.meta {
+		evidence.Meta[name] = slices.Clone(contents)
+	}
+
+	for _, ref := range resources.refs {
+		switch {
+		case ref.element == "script" && ref.attribute == "src":
+			evidence.ScriptSrcs = append(evidence.ScriptSrcs, ref.url)
+		case ref.element == "link" && linkResourceType(ref.node) == dmhtml.StylesheetResource:
+			evidence.Stylesheets = append(evidence.Stylesheets, ref.url)
+		}
+	}
+
+	for class := range fingerprint.classes {
+		evidence.Classes = append(evidence.Classes, class)
+	}
+	sort.Strings(evidence.Classes)
+
+	return evidence
+}
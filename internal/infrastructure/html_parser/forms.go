@@ -0,0 +1,258 @@
+package html_parser
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	dmhtml "web-pages-analyzer/internal/domain/html"
+	utlstr "web-pages-analyzer/internal/utils/string"
+)
+
+// Hidden field names used by common frameworks for anti-CSRF tokens
+var csrfFieldNames = []string{"csrf", "xsrf", "authenticity_token", "__requestverificationtoken", "_token", "anti-forgery"}
+
+// Markers of reCAPTCHA, hCaptcha, Turnstile and Friendly Captcha widgets
+var captchaMarkers = []string{"g-recaptcha", "recaptcha", "h-captcha", "hcaptcha", "cf-turnstile", "challenges.cloudflare.com", "frc-captcha", "captcha"}
+
+// Input types which submit or reset the form rather than carrying data
+var buttonInputTypes = []string{"submit", "button", "reset", "image"}
+
+type formSignals struct {
+	passwords    int
+	emails       int
+	textareas    int
+	search       bool
+	payment      bool
+	newPassword  bool
+	keywords     string
+	visibleCount int
+}
+
+func analyzeForms(visitor *formsVisitor, pageURL *url.URL, base *url.URL, classifier *hostClassifier, threshold int) *dmhtml.FormAnalysis {
+	analysis := &dmhtml.FormAnalysis{
+		Purposes: map[string]int{},
+		Forms:    []dmhtml.Form{},
+	}
+
+	var loginSignals [][]dmhtml.LoginSignal
+	for _, formNode := range visitor.forms {
+		form, signals := describeForm(formNode, visitor.associated[getAttr(formNode, "id")], pageURL, base, classifier, threshold)
+
+		analysis.Count++
+		analysis.Purposes[form.Purpose]++
+		analysis.Forms = append(analysis.Forms, form)
+
+		if form.Purpose != dmhtml.SignupForm && form.Purpose != dmhtml.PaymentForm {
+			loginSignals = append(loginSignals, signals)
+		}
+	}
+
+	analysis.Login = detectLogin(loginSignals, pageLoginSignals(visitor), threshold)
+	analysis.HasLoginForm = analysis.Login.Detected
+
+	return analysis
+}
+
+func describeForm(formNode *html.Node, associated []*html.Node, pageURL *url.URL, base *url.URL, classifier *hostClassifier, threshold int) (dmhtml.Form, []dmhtml.LoginSignal) {
+	form := dmhtml.Form{
+		Method:   strings.ToLower(strings.TrimSpace(getAttr(formNode, "method"))),
+		Action:   pageURL.String(),
+		Fields:   []dmhtml.FormField{},
+		Location: elementPath(formNode),
+	}
+
+	if form.Method != "post" && form.Method != "dialog" {
+		form.Method = "get"
+	}
+
+	// An empty action submits to the document itself
+	if action := strings.TrimSpace(getAttr(formNode, "action")); action != "" {
+		if parsed, err := url.Parse(action); err == nil {
+			form.Action = base.ResolveReference(parsed).String()
+		}
+	}
+
+	actionURL, err := url.Parse(form.Action)
+	if err == nil {
+		form.InsecureAction = pageURL.Scheme == "https" && actionURL.Scheme == "http"
+		form.ThirdParty = !classifier.isFirstParty(actionURL)
+	}
+
+	signals := formSignals{keywords: formKeywords(formNode)}
+
+	fieldNodes := append(formControls(formNode), associated...)
+	for _, node := range fieldNodes {
+		field := describeField(node)
+
+		if field.Type == "hidden" && utlstr.ContainsAnySubstring(strings.ToLower(field.Name), csrfFieldNames...) {
+			form.HasCSRFToken = true
+		}
+
+		if isButtonField(field) {
+			signals.keywords += " " + strings.ToLower(getAttr(node, "value"))
+			continue
+		}
+
+		collectFieldSignals(&signals, field, node)
+		form.Fields = append(form.Fields, field)
+	}
+
+	for _, button := range findElements(formNode, "button") {
+		signals.keywords += " " + strings.ToLower(getTextContent(button))
+	}
+
+	loginSignals := formLoginSignals(formNode, fieldNodes, actionURL)
+
+	form.HasCaptcha = hasCaptcha(formNode)
+	form.LoginScore = scoreLoginSignals(loginSignals)
+	form.Purpose = formPurpose(signals, form.LoginScore >= threshold, getAttr(formNode, "role"))
+
+	return form, loginSignals
+}
+
+func describeField(node *html.Node) dmhtml.FormField {
+	field := dmhtml.FormField{
+		Element:      node.Data,
+		Type:         strings.ToLower(getAttr(node, "type")),
+		Name:         getAttr(node, "name"),
+		ID:           getAttr(node, "id"),
+		Required:     hasAttr(node, "required"),
+		Autocomplete: strings.ToLower(strings.TrimSpace(getAttr(node, "autocomplete"))),
+	}
+
+	switch {
+	case node.Data == "input" && field.Type == "":
+		field.Type = "text"
+	case node.Data == "textarea":
+		field.Type = "textarea"
+	case node.Data == "select":
+		field.Type = "select"
+	}
+
+	return field
+}
+
+func collectFieldSignals(signals *formSignals, field dmhtml.FormField, node *html.Node) {
+	name := strings.ToLower(field.Name + " " + field.ID + " " + getAttr(node, "placeholder"))
+
+	if field.Type != "hidden" {
+		signals.visibleCount++
+		signals.keywords += " " + name
+	}
+
+	switch {
+	case field.Type == "password":
+		signals.passwords++
+	case field.Type == "email" || utlstr.ContainsAnySubstring(name, "email", "e-mail"):
+		signals.emails++
+	case field.Type == "search" || field.Name == "q" || field.Name == "s" || utlstr.ContainsAnySubstring(name, "search", "query"):
+		signals.search = true
+	case field.Type == "textarea":
+		signals.textareas++
+	}
+
+	if strings.Contains(field.Autocomplete, "cc-") || utlstr.ContainsAnySubstring(name, "card", "cvv", "cvc", "expiry") {
+		signals.payment = true
+	}
+	if strings.Contains(field.Autocomplete, "new-password") {
+		signals.newPassword = true
+	}
+}
+
+// Decide what the form is for, the most specific purpose wins
+func formPurpose(signals formSignals, isLogin bool, role string) string {
+	keywords := signals.keywords
+
+	switch {
+	case signals.payment:
+		return dmhtml.PaymentForm
+	case signals.passwords >= 2 || signals.newPassword ||
+		utlstr.ContainsAnySubstring(keywords, "register", "signup", "sign-up", "sign up", "create account", "create-account"):
+		return dmhtml.SignupForm
+	case isLogin:
+		return dmhtml.LoginForm
+	case signals.search || strings.EqualFold(role, "search"):
+		return dmhtml.SearchForm
+	case signals.textareas > 0 || utlstr.ContainsAnySubstring(keywords, "contact", "message"):
+		return dmhtml.ContactForm
+	case signals.emails > 0 && (signals.visibleCount <= 2 ||
+		utlstr.ContainsAnySubstring(keywords, "subscribe", "newsletter")):
+		return dmhtml.NewsletterForm
+	}
+
+	return dmhtml.OtherForm
+}
+
+// Lowercased action, id, class and name of the form
+func formKeywords(formNode *html.Node) string {
+	var keywords []string
+	for _, key := range []string{"action", "id", "class", "name"} {
+		keywords = append(keywords, strings.ToLower(getAttr(formNode, key)))
+	}
+
+	return strings.Join(keywords, " ")
+}
+
+func hasCaptcha(node *html.Node) bool {
+	if isCaptchaWidget(node) {
+		return true
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if hasCaptcha(child) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isCaptchaWidget(node *html.Node) bool {
+	if node.Type != html.ElementNode {
+		return false
+	}
+	if hasAttr(node, "data-sitekey") {
+		return true
+	}
+
+	markers := strings.ToLower(getAttr(node, "class") + " " + getAttr(node, "id") + " " + getAttr(node, "src"))
+	return utlstr.ContainsAnySubstring(markers, captchaMarkers...)
+}
+
+func isButtonField(field dmhtml.FormField) bool {
+	return field.Element == "input" && slices.Contains(buttonInputTypes, field.Type)
+}
+
+// Input, select and textarea elements within the node
+func formControls(node *html.Node) []*html.Node {
+	var controls []*html.Node
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode {
+			switch child.Data {
+			case "input", "select", "textarea":
+				controls = append(controls, child)
+				continue
+			}
+		}
+		controls = append(controls, formControls(child)...)
+	}
+
+	return controls
+}
+
+func findElements(node *html.Node, tag string) []*html.Node {
+	var elements []*html.Node
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == tag {
+			elements = append(elements, child)
+		}
+		elements = append(elements, findElements(child, tag)...)
+	}
+
+	return elements
+}
@@ -0,0 +1,195 @@
+package html_parser
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	dmhtml "web-pages-analyzer/internal/domain/html"
+)
+
+// Upper bound on the internal documents fetched to verify their anchors
+const maxFragmentDocuments = 20
+
+type fragmentRef struct {
+	node     *html.Node
+	href     string
+	url      *url.URL
+	samePage bool
+}
+
+// Verify that fragment links point at an existing id or name, fetching internal target documents if enabled
+func (p *parser) checkFragments(ctx context.Context, refs []fragmentRef, pageAnchors map[string]bool) (dmhtml.FragmentAnalysis, error) {
+	analysis := dmhtml.FragmentAnalysis{
+		TargetsFetched: p.opts.CheckFragments,
+		BrokenAnchors:  []dmhtml.BrokenAnchor{},
+	}
+
+	var targets []string
+	for _, ref := range refs {
+		if !ref.samePage && p.opts.CheckFragments && p.classifier.isFirstParty(ref.url) {
+			targets = append(targets, documentURL(ref.url))
+		}
+	}
+
+	anchors := map[string]map[string]bool{
+		documentURL(p.baseUrl): pageAnchors,
+	}
+	fetched, err := p.fetchAnchors(ctx, targets)
+	for document, documentAnchors := range fetched {
+		anchors[document] = documentAnchors
+	}
+
+	for _, ref := range refs {
+		if !ref.samePage && !p.classifier.isFirstParty(ref.url) {
+			continue
+		}
+
+		documentAnchors, ok := anchors[documentURL(ref.url)]
+		if !ok {
+			analysis.Unverified++
+			continue
+		}
+
+		analysis.Checked++
+		if hasAnchor(documentAnchors, ref.url) {
+			continue
+		}
+
+		analysis.Broken++
+		analysis.BrokenAnchors = append(analysis.BrokenAnchors, dmhtml.BrokenAnchor{
+			Href:     ref.href,
+			URL:      ref.url.String(),
+			Fragment: ref.url.Fragment,
+			SamePage: ref.samePage,
+			Location: elementPath(ref.node),
+		})
+	}
+
+	return analysis, err
+}
+
+// Fetch each target document once and collect its anchors, failed documents are left out. When the
+// context is done first, the documents fetched so far are returned with its error.
+func (p *parser) fetchAnchors(ctx context.Context, documents []string) (map[string]map[string]bool, error) {
+	anchors := make(map[string]map[string]bool)
+	if err := ctx.Err(); err != nil || len(documents) == 0 {
+		return anchors, err
+	}
+
+	type result struct {
+		url     string
+		anchors map[string]bool
+	}
+
+	seen := make(map[string]bool)
+	results := make(chan result, maxFragmentDocuments)
+
+	for _, document := range documents {
+		if seen[document] || len(seen) >= maxFragmentDocuments {
+			continue
+		}
+		seen[document] = true
+
+		go func(documentURL string) {
+			results <- result{url: documentURL, anchors: p.fetchDocumentAnchors(ctx, documentURL)}
+		}(document)
+	}
+
+	for range len(seen) {
+		select {
+		case r := <-results:
+			if r.anchors != nil {
+				anchors[r.url] = r.anchors
+			}
+		case <-ctx.Done():
+			return anchors, ctx.Err()
+		}
+	}
+
+	return anchors, nil
+}
+
+// Anchors of a fetched document, nil when it could not be fetched or parsed
+func (p *parser) fetchDocumentAnchors(ctx context.Context, documentURL string) map[string]bool {
+	resp, err := p.client.Get(ctx, documentURL)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	node, err := html.Parse(resp.Body)
+	if err != nil {
+		return nil
+	}
+
+	return collectAnchors(node, map[string]bool{})
+}
+
+// Describe an anchor with a fragment, links to the analyzed page itself are same-page anchors
+func newFragmentRef(node *html.Node, base *url.URL, pageURL *url.URL) (fragmentRef, bool) {
+	if !hasAttr(node, "href") {
+		return fragmentRef{}, false
+	}
+
+	href := strings.TrimSpace(getAttr(node, "href"))
+	parsed, err := url.Parse(href)
+	if err != nil || !strings.Contains(href, "#") {
+		return fragmentRef{}, false
+	}
+
+	resolved := base.ResolveReference(parsed)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return fragmentRef{}, false
+	}
+
+	return fragmentRef{
+		node:     node,
+		href:     href,
+		url:      resolved,
+		samePage: documentURL(resolved) == documentURL(pageURL),
+	}, true
+}
+
+// Every id attribute and <a name> in the document
+func collectAnchors(node *html.Node, anchors map[string]bool) map[string]bool {
+	if node.Type == html.ElementNode {
+		addAnchors(node, anchors)
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		collectAnchors(child, anchors)
+	}
+
+	return anchors
+}
+
+// The id of the element, or the name of an <a name>, can be the target of a fragment
+func addAnchors(node *html.Node, anchors map[string]bool) {
+	if id := getAttr(node, "id"); id != "" {
+		anchors[id] = true
+	}
+	if name := getAttr(node, "name"); name != "" && node.Data == "a" {
+		anchors[name] = true
+	}
+}
+
+// An empty fragment and "#top" scroll to the top of the document and are always valid
+func hasAnchor(anchors map[string]bool, target *url.URL) bool {
+	fragment := target.Fragment
+	if fragment == "" || strings.EqualFold(fragment, "top") {
+		return true
+	}
+
+	return anchors[fragment] || anchors[target.EscapedFragment()]
+}
+
+// URL of the document a link points at, without its fragment
+func documentURL(u *url.URL) string {
+	document := *u
+	document.Fragment = ""
+	document.RawFragment = ""
+	return document.String()
+}
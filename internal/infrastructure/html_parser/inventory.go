@@ -0,0 +1,246 @@
+package html_parser
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	dmhtml "web-pages-analyzer/internal/domain/html"
+)
+
+// Build the inventory of resources loaded by the document from its references and inline resources
+func inventoryResources(refs []resourceRef, inline []dmhtml.Resource, classifier *hostClassifier) *dmhtml.ResourceInventory {
+	var resources []dmhtml.Resource
+
+	for _, ref := range refs {
+		resourceType := resourceTypeOf(ref)
+		if resourceType == "" {
+			continue
+		}
+
+		resourceURL, err := url.Parse(ref.url)
+		if err == nil && resourceURL.Scheme == "data" {
+			// A data: URL embeds the resource in the document, like an inline script
+			resources = append(resources, dmhtml.Resource{
+				Type:       resourceType,
+				Element:    ref.element,
+				Inline:     true,
+				FirstParty: true,
+			})
+			continue
+		}
+
+		resources = append(resources, dmhtml.Resource{
+			URL:        ref.url,
+			Type:       resourceType,
+			Element:    ref.element,
+			Async:      hasAttr(ref.node, "async"),
+			Defer:      hasAttr(ref.node, "defer"),
+			Integrity:  getAttr(ref.node, "integrity") != "",
+			FirstParty: err == nil && classifier.isFirstParty(resourceURL),
+		})
+	}
+
+	resources = append(resources, inline...)
+
+	return groupResources(resources)
+}
+
+// Inline scripts and style blocks are part of the document itself
+func inlineResource(node *html.Node) (dmhtml.Resource, bool) {
+	if (node.Data != "script" && node.Data != "style") || hasAttr(node, "src") ||
+		strings.TrimSpace(getTextContent(node)) == "" {
+		return dmhtml.Resource{}, false
+	}
+
+	resourceType := dmhtml.ScriptResource
+	if node.Data == "style" {
+		resourceType = dmhtml.StylesheetResource
+	}
+
+	return dmhtml.Resource{
+		Type:       resourceType,
+		Element:    node.Data,
+		Inline:     true,
+		FirstParty: true,
+	}, true
+}
+
+// Classify a referenced resource, an empty type means it is not part of the inventory
+func resourceTypeOf(ref resourceRef) string {
+	switch ref.element {
+	case "script":
+		return dmhtml.ScriptResource
+	case "img", "input":
+		return dmhtml.ImageResource
+	case "iframe", "frame":
+		return dmhtml.IframeResource
+	case "video", "audio", "track", "embed", "object":
+		if ref.attribute == "poster" {
+			return dmhtml.ImageResource
+		}
+		return dmhtml.MediaResource
+	case "source":
+		if ref.node.Parent != nil && ref.node.Parent.Data == "picture" {
+			return dmhtml.ImageResource
+		}
+		return dmhtml.MediaResource
+	case "link":
+		return linkResourceType(ref.node)
+	}
+
+	return ""
+}
+
+func linkResourceType(node *html.Node) string {
+	rels := strings.Fields(strings.ToLower(getAttr(node, "rel")))
+
+	for _, rel := range rels {
+		switch rel {
+		case "stylesheet":
+			return dmhtml.StylesheetResource
+		case "icon", "apple-touch-icon":
+			return dmhtml.ImageResource
+		case "modulepreload":
+			return dmhtml.ScriptResource
+		case "preload", "prefetch":
+			switch strings.ToLower(getAttr(node, "as")) {
+			case "font":
+				return dmhtml.FontResource
+			case "script":
+				return dmhtml.ScriptResource
+			case "style":
+				return dmhtml.StylesheetResource
+			case "image":
+				return dmhtml.ImageResource
+			case "video", "audio", "track":
+				return dmhtml.MediaResource
+			}
+		}
+	}
+
+	return ""
+}
+
+func groupResources(resources []dmhtml.Resource) *dmhtml.ResourceInventory {
+	inventory := &dmhtml.ResourceInventory{
+		Scripts:     []dmhtml.Resource{},
+		Stylesheets: []dmhtml.Resource{},
+		Images:      []dmhtml.Resource{},
+		Iframes:     []dmhtml.Resource{},
+		Fonts:       []dmhtml.Resource{},
+		Media:       []dmhtml.Resource{},
+		Hosts:       []dmhtml.HostResources{},
+	}
+
+	hosts := make(map[string]*dmhtml.HostResources)
+
+	for _, resource := range resources {
+		switch resource.Type {
+		case dmhtml.ScriptResource:
+			inventory.Scripts = append(inventory.Scripts, resource)
+		case dmhtml.StylesheetResource:
+			inventory.Stylesheets = append(inventory.Stylesheets, resource)
+		case dmhtml.ImageResource:
+			inventory.Images = append(inventory.Images, resource)
+		case dmhtml.IframeResource:
+			inventory.Iframes = append(inventory.Iframes, resource)
+		case dmhtml.FontResource:
+			inventory.Fonts = append(inventory.Fonts, resource)
+		case dmhtml.MediaResource:
+			inventory.Media = append(inventory.Media, resource)
+		}
+
+		if resource.FirstParty {
+			inventory.FirstParty++
+		} else {
+			inventory.ThirdParty++
+		}
+
+		if resource.Inline {
+			continue
+		}
+
+		resourceURL, err := url.Parse(resource.URL)
+		if err != nil || !isHTTPURL(resourceURL) {
+			continue
+		}
+
+		host := strings.ToLower(resourceURL.Host)
+		if hosts[host] == nil {
+			hosts[host] = &dmhtml.HostResources{Host: host, FirstParty: resource.FirstParty, Types: map[string]int{}}
+		}
+		hosts[host].Count++
+		hosts[host].Types[resource.Type]++
+	}
+
+	for _, host := range hosts {
+		inventory.Hosts = append(inventory.Hosts, *host)
+	}
+
+	// First-party hosts first, then the hosts loading the most resources
+	sort.Slice(inventory.Hosts, func(i, j int) bool {
+		a, b := inventory.Hosts[i], inventory.Hosts[j]
+		if a.FirstParty != b.FirstParty {
+			return a.FirstParty
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Host < b.Host
+	})
+
+	return inventory
+}
+
+// Check every external resource fetched over HTTP through the HEAD pipeline used for links, resources
+// whose check was interrupted by the context are left without accessibility
+func (p *parser) checkResources(ctx context.Context, inventory *dmhtml.ResourceInventory) error {
+	groups := [][]dmhtml.Resource{
+		inventory.Scripts,
+		inventory.Stylesheets,
+		inventory.Images,
+		inventory.Iframes,
+		inventory.Fonts,
+		inventory.Media,
+	}
+
+	var urls []string
+	for _, group := range groups {
+		for _, resource := range group {
+			if resourceURL, err := url.Parse(resource.URL); err == nil && !resource.Inline && isHTTPURL(resourceURL) {
+				urls = append(urls, resource.URL)
+			}
+		}
+	}
+
+	checks, err := p.checkURLs(ctx, urls)
+
+	for _, group := range groups {
+		for i := range group {
+			check, checked := checks[group[i].URL]
+			if group[i].Inline || !checked {
+				continue
+			}
+
+			accessible := check.accessible
+			group[i].Accessible = &accessible
+			if !accessible {
+				inventory.Inaccessible++
+			}
+		}
+	}
+
+	inventory.Checked = true
+	inventory.Incomplete = err != nil
+
+	return err
+}
+
+// Only the resources fetched over HTTP are checked and grouped by host, not e.g. about:blank or blob: URLs
+func isHTTPURL(resourceURL *url.URL) bool {
+	return resourceURL.Scheme == "http" || resourceURL.Scheme == "https"
+}
@@ -0,0 +1,110 @@
+package html_parser
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	dmhtml "web-pages-analyzer/internal/domain/html"
+)
+
+// Effective base URL of the document, set by the first <base href> element
+func documentBase(document *html.Node, pageURL *url.URL) *url.URL {
+	if document == nil {
+		return pageURL
+	}
+
+	base := findBaseHref(document)
+	if base == "" {
+		return pageURL
+	}
+
+	return resolveBase(base, pageURL)
+}
+
+// Base URL set by the href of a <base> element
+func resolveBase(href string, pageURL *url.URL) *url.URL {
+	parsed, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return pageURL
+	}
+
+	return pageURL.ResolveReference(parsed)
+}
+
+func findBaseHref(node *html.Node) string {
+	if node.Type == html.ElementNode && node.Data == "base" && hasAttr(node, "href") {
+		return getAttr(node, "href")
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if href := findBaseHref(child); href != "" {
+			return href
+		}
+	}
+
+	return ""
+}
+
+// Describe a navigable anchor with its rel, target, download and hreflang attributes
+func newLink(node *html.Node, linkURL string) dmhtml.Link {
+	link := dmhtml.Link{
+		URL:      linkURL,
+		Rel:      strings.Fields(strings.ToLower(getAttr(node, "rel"))),
+		Download: hasAttr(node, "download"),
+		Hreflang: strings.TrimSpace(getAttr(node, "hreflang")),
+	}
+
+	for _, rel := range link.Rel {
+		switch rel {
+		case "nofollow":
+			link.NoFollow = true
+		case "sponsored":
+			link.Sponsored = true
+		case "ugc":
+			link.UGC = true
+		case "noopener":
+			link.NoOpener = true
+		case "noreferrer":
+			link.NoReferrer = true
+		}
+	}
+
+	// noreferrer implies noopener
+	link.UnsafeTargetBlank = strings.EqualFold(getAttr(node, "target"), "_blank") &&
+		!link.NoOpener && !link.NoReferrer
+
+	if link.Rel == nil {
+		link.Rel = []string{}
+	}
+
+	return link
+}
+
+func countLinkHygiene(hygiene *dmhtml.LinkHygiene, link dmhtml.Link) {
+	if link.NoFollow {
+		hygiene.NoFollow++
+	}
+	if link.Sponsored {
+		hygiene.Sponsored++
+	}
+	if link.UGC {
+		hygiene.UGC++
+	}
+	if link.NoOpener {
+		hygiene.NoOpener++
+	}
+	if link.NoReferrer {
+		hygiene.NoReferrer++
+	}
+	if link.UnsafeTargetBlank {
+		hygiene.UnsafeTargetBlank++
+	}
+	if link.Download {
+		hygiene.Download++
+	}
+	if link.Hreflang != "" {
+		hygiene.Hreflang++
+	}
+}
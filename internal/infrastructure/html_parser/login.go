@@ -0,0 +1,269 @@
+package html_parser
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	dmhtml "web-pages-analyzer/internal/domain/html"
+	utlstr "web-pages-analyzer/internal/utils/string"
+)
+
+// Weight of each login signal, the score is capped at 100
+const (
+	passwordFieldWeight        = 50
+	currentPasswordWeight      = 40
+	usernameAutocompleteWeight = 20
+	usernameFieldWeight        = 15
+	submitTextWeight           = 25
+	actionKeywordWeight        = 20
+	formKeywordWeight          = 10
+	usernameFirstStepWeight    = 30
+	ssoProviderWeight          = 50
+	loginIframeWeight          = 40
+	maxLoginScore              = 100
+)
+
+// Words in URLs, ids, names and classes which point at authentication
+var loginKeywords = []string{"login", "log-in", "signin", "sign-in", "logon", "auth", "session", "sso", "authenticate", "oauth"}
+
+// Matches submit captions such as "Log in" or "Sign in"
+var loginSubmitPattern = regexp.MustCompile(`(?i)\b(log\s?in|sign\s?in|log\s?on)\b`)
+
+// Matches the caption of a username-first step, e.g. "Next" or "Continue"
+var nextStepPattern = regexp.MustCompile(`(?i)^\s*(next|continue)\b`)
+
+// Matches federated login captions such as "Sign in with Google" or "Continue with Apple"
+var ssoTextPattern = regexp.MustCompile(`(?i)\b(sign\s?in|log\s?in|continue|sign\s?up)\s+(with|using|via)\s+(google|apple|microsoft|github|facebook|twitter|x|linkedin|gitlab|okta)\b`)
+
+// Authorization endpoints of common identity providers
+var ssoProviderURLs = []string{
+	"accounts.google.com/o/oauth2",
+	"appleid.apple.com/auth",
+	"login.microsoftonline.com",
+	"github.com/login/oauth",
+	"facebook.com/dialog/oauth",
+	"api.twitter.com/oauth",
+	"linkedin.com/oauth",
+	"gitlab.com/oauth",
+}
+
+// Matches any non alphanumeric separator, used to split identifiers into words
+var wordSeparator = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Signals raised by the fields, captions and action of a single form
+func formLoginSignals(formNode *html.Node, fields []*html.Node, actionURL *url.URL) []dmhtml.LoginSignal {
+	var signals []dmhtml.LoginSignal
+	add := func(name string, weight int, detail string) {
+		signals = append(signals, dmhtml.LoginSignal{Name: name, Weight: weight, Detail: detail})
+	}
+
+	var passwords, identifiers int
+	var currentPassword, usernameAutocomplete, usernameField bool
+
+	for _, node := range fields {
+		field := describeField(node)
+		if isButtonField(field) || field.Type == "hidden" {
+			continue
+		}
+
+		for _, token := range strings.Fields(field.Autocomplete) {
+			switch token {
+			case "current-password":
+				currentPassword = true
+			case "username", "email", "webauthn":
+				usernameAutocomplete = true
+			}
+		}
+
+		switch field.Type {
+		case "password":
+			passwords++
+		case "text", "email", "tel":
+			identifiers++
+			words := identifierWords(field.Name + " " + field.ID)
+			if field.Type == "email" || utlstr.ContainsAnySubstring(words, "user", "email", "login", "account", "identifier") {
+				usernameField = true
+			}
+		}
+	}
+
+	// A second password field belongs to a signup or password change form
+	if passwords == 1 {
+		add("password_field", passwordFieldWeight, "form has a password field")
+	}
+	if currentPassword {
+		add("autocomplete_current_password", currentPasswordWeight, `autocomplete="current-password"`)
+	}
+	if usernameAutocomplete {
+		add("autocomplete_username", usernameAutocompleteWeight, `autocomplete="username"`)
+	}
+	if usernameField {
+		add("username_field", usernameFieldWeight, "form has a username or email field")
+	}
+
+	captions := submitCaptions(formNode, fields)
+	for _, caption := range captions {
+		if loginSubmitPattern.MatchString(caption) {
+			add("submit_text", submitTextWeight, strings.TrimSpace(caption))
+			break
+		}
+	}
+
+	if actionURL != nil && hasWord(identifierWords(actionURL.Path), loginKeywords...) {
+		add("action_keyword", actionKeywordWeight, actionURL.Path)
+	}
+
+	formWords := identifierWords(getAttr(formNode, "id") + " " + getAttr(formNode, "name") + " " + getAttr(formNode, "class"))
+	if hasWord(formWords, loginKeywords...) {
+		add("form_keyword", formKeywordWeight, strings.TrimSpace(formWords))
+	}
+
+	// Username-first flows ask for the identifier alone and the password on the next step
+	if passwords == 0 && identifiers == 1 && usernameField && (usernameAutocomplete || len(signals) > 1 || hasNextStep(captions)) {
+		add("username_first_step", usernameFirstStepWeight, "single identifier field without a password")
+	}
+
+	return signals
+}
+
+// Signals raised by the page outside of its forms
+func pageLoginSignals(visitor *formsVisitor) []dmhtml.LoginSignal {
+	var signals []dmhtml.LoginSignal
+
+	if input := visitor.barePassword; input != nil {
+		signals = append(signals, dmhtml.LoginSignal{
+			Name:   "password_field",
+			Weight: passwordFieldWeight,
+			Detail: "password field outside of a form at " + elementPath(input),
+		})
+	}
+
+	if provider := visitor.ssoProvider; provider != "" {
+		signals = append(signals, dmhtml.LoginSignal{Name: "sso_provider", Weight: ssoProviderWeight, Detail: provider})
+	}
+
+	for _, iframe := range visitor.iframes {
+		src, err := url.Parse(strings.TrimSpace(getAttr(iframe, "src")))
+		if err == nil && hasWord(identifierWords(src.Host+" "+src.Path), loginKeywords...) {
+			signals = append(signals, dmhtml.LoginSignal{Name: "login_iframe", Weight: loginIframeWeight, Detail: src.String()})
+			break
+		}
+	}
+
+	return signals
+}
+
+// Combine the best form with the page-level signals into a confidence score
+func detectLogin(formSignals [][]dmhtml.LoginSignal, pageSignals []dmhtml.LoginSignal, threshold int) dmhtml.LoginDetection {
+	var best []dmhtml.LoginSignal
+	for _, signals := range formSignals {
+		if scoreLoginSignals(signals) > scoreLoginSignals(best) {
+			best = signals
+		}
+	}
+
+	signals := append([]dmhtml.LoginSignal{}, best...)
+	for _, signal := range pageSignals {
+		// A bare password field only counts when no form already has one
+		if signal.Name == "password_field" && hasSignal(signals, "password_field") {
+			continue
+		}
+		signals = append(signals, signal)
+	}
+
+	score := scoreLoginSignals(signals)
+	return dmhtml.LoginDetection{
+		Score:     score,
+		Threshold: threshold,
+		Detected:  score >= threshold,
+		Signals:   signals,
+	}
+}
+
+func scoreLoginSignals(signals []dmhtml.LoginSignal) int {
+	score := 0
+	for _, signal := range signals {
+		score += signal.Weight
+	}
+
+	return min(score, maxLoginScore)
+}
+
+func hasSignal(signals []dmhtml.LoginSignal, name string) bool {
+	for _, signal := range signals {
+		if signal.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Captions of the buttons and submit inputs of the form
+func submitCaptions(formNode *html.Node, fields []*html.Node) []string {
+	var captions []string
+
+	for _, button := range findElements(formNode, "button") {
+		captions = append(captions, getTextContent(button))
+	}
+
+	for _, node := range fields {
+		if field := describeField(node); isButtonField(field) {
+			captions = append(captions, getAttr(node, "value"))
+		}
+	}
+
+	return captions
+}
+
+func hasNextStep(captions []string) bool {
+	for _, caption := range captions {
+		if nextStepPattern.MatchString(caption) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The identity provider of a "Sign in with ..." control or a link to it
+func ssoProviderOf(node *html.Node) string {
+	href := strings.ToLower(getAttr(node, "href"))
+	for _, provider := range ssoProviderURLs {
+		if strings.Contains(href, provider) {
+			return provider
+		}
+	}
+
+	caption := strings.Join(strings.Fields(getTextContent(node)+" "+getAttr(node, "aria-label")), " ")
+	return ssoTextPattern.FindString(caption)
+}
+
+// Lowercase words of an identifier, e.g. "signin-form loginBox" becomes " signin form loginbox "
+func identifierWords(identifier string) string {
+	return " " + strings.TrimSpace(wordSeparator.ReplaceAllString(strings.ToLower(identifier), " ")) + " "
+}
+
+// Whether any keyword appears as a word, e.g. "sign-in" in " sign in form ", or prefixes a compound such as "loginform".
+// Short keywords must match whole words so "auth" does not match "author".
+func hasWord(words string, keywords ...string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(keyword, "-") {
+			if strings.Contains(words, " "+strings.ReplaceAll(keyword, "-", " ")+" ") {
+				return true
+			}
+			keyword = strings.ReplaceAll(keyword, "-", "")
+		}
+
+		for _, word := range strings.Fields(words) {
+			if word == keyword || (len(keyword) > 4 && strings.HasPrefix(word, keyword)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,220 @@
+package html_parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var extraBlankLines = regexp.MustCompile(`\n{3,}`)
+
+// Writes Markdown while collapsing the whitespace of the HTML text it is given
+type markdownWriter struct {
+	out          strings.Builder
+	pendingSpace bool
+	listDepth    int
+}
+
+// Convert cleaned content to Markdown
+func renderMarkdown(node *html.Node) string {
+	w := &markdownWriter{}
+	w.children(node)
+
+	return strings.TrimSpace(extraBlankLines.ReplaceAllString(w.out.String(), "\n\n"))
+}
+
+func (w *markdownWriter) atLineStart() bool {
+	out := w.out.String()
+	return out == "" || strings.HasSuffix(out, "\n") || strings.HasSuffix(out, " ")
+}
+
+// Write HTML text, runs of whitespace become a single space
+func (w *markdownWriter) text(s string) {
+	if s == "" {
+		return
+	}
+
+	if strings.TrimSpace(s) == "" {
+		w.pendingSpace = true
+		return
+	}
+
+	if isSpace(s[0]) {
+		w.pendingSpace = true
+	}
+	w.raw(strings.Join(strings.Fields(s), " "))
+	w.pendingSpace = isSpace(s[len(s)-1])
+}
+
+// Write Markdown syntax as is
+func (w *markdownWriter) raw(s string) {
+	if w.pendingSpace && !w.atLineStart() {
+		w.out.WriteString(" ")
+	}
+	w.pendingSpace = false
+	w.out.WriteString(s)
+}
+
+// Separate blocks by a blank line, list items stay on consecutive lines
+func (w *markdownWriter) block() {
+	w.pendingSpace = false
+	if w.out.Len() == 0 {
+		return
+	}
+	if w.listDepth > 0 {
+		w.newline()
+		return
+	}
+	w.out.WriteString("\n\n")
+}
+
+func (w *markdownWriter) newline() {
+	w.pendingSpace = false
+	if w.out.Len() > 0 && !strings.HasSuffix(w.out.String(), "\n") {
+		w.out.WriteString("\n")
+	}
+}
+
+func (w *markdownWriter) children(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		w.node(child)
+	}
+}
+
+func (w *markdownWriter) node(node *html.Node) {
+	if node.Type == html.TextNode {
+		w.text(node.Data)
+		return
+	}
+	if node.Type != html.ElementNode {
+		return
+	}
+
+	switch node.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(node.Data[1:])
+		w.block()
+		w.raw(strings.Repeat("#", level) + " ")
+		w.children(node)
+		w.block()
+	case "p", "div", "section", "article", "main", "header", "figure", "figcaption", "details", "summary":
+		if w.listDepth > 0 {
+			w.children(node)
+			return
+		}
+		w.block()
+		w.children(node)
+		w.block()
+	case "br":
+		w.raw("  \n")
+	case "hr":
+		w.block()
+		w.raw("---")
+		w.block()
+	case "a":
+		href := getAttr(node, "href")
+		if href == "" {
+			w.children(node)
+			return
+		}
+		w.raw("[")
+		w.children(node)
+		w.raw("](" + href + ")")
+	case "img":
+		w.raw("![" + getAttr(node, "alt") + "](" + getAttr(node, "src") + ")")
+	case "strong", "b":
+		w.raw("**")
+		w.children(node)
+		w.raw("**")
+	case "em", "i":
+		w.raw("_")
+		w.children(node)
+		w.raw("_")
+	case "code":
+		w.raw("`" + getTextContent(node) + "`")
+	case "pre":
+		w.block()
+		w.raw("```\n" + strings.TrimRight(getTextContent(node), "\n") + "\n```")
+		w.block()
+	case "blockquote":
+		quote := &markdownWriter{}
+		quote.children(node)
+		lines := strings.Split(strings.TrimSpace(extraBlankLines.ReplaceAllString(quote.out.String(), "\n\n")), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		w.block()
+		w.raw(strings.Join(lines, "\n"))
+		w.block()
+	case "ul", "ol":
+		w.list(node)
+	case "table":
+		w.table(node)
+	default:
+		w.children(node)
+	}
+}
+
+func (w *markdownWriter) list(node *html.Node) {
+	if w.listDepth == 0 {
+		w.block()
+	} else {
+		w.newline()
+	}
+
+	number := 1
+	if start, err := strconv.Atoi(getAttr(node, "start")); err == nil {
+		number = start
+	}
+
+	w.listDepth++
+	for item := node.FirstChild; item != nil; item = item.NextSibling {
+		if item.Type != html.ElementNode || item.Data != "li" {
+			continue
+		}
+
+		marker := "- "
+		if node.Data == "ol" {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		w.newline()
+		w.raw(strings.Repeat("  ", w.listDepth-1) + marker)
+		w.children(item)
+	}
+	w.listDepth--
+
+	if w.listDepth == 0 {
+		w.block()
+	} else {
+		w.newline()
+	}
+}
+
+// Render every row with its cells as plain text, the first row as the header
+func (w *markdownWriter) table(node *html.Node) {
+	w.block()
+
+	for i, row := range findElements(node, "tr") {
+		var cells []string
+		for cell := row.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+				cells = append(cells, strings.ReplaceAll(visibleText(cell), "|", "\\|"))
+			}
+		}
+
+		w.out.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			w.out.WriteString("|" + strings.Repeat(" --- |", len(cells)) + "\n")
+		}
+	}
+
+	w.block()
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
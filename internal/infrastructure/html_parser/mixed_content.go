@@ -0,0 +1,60 @@
+package html_parser
+
+import (
+	"net/url"
+
+	dmhtml "web-pages-analyzer/internal/domain/html"
+)
+
+// Detect subresources loaded over plain HTTP by an HTTPS page
+func detectMixedContent(refs []resourceRef, pageURL *url.URL) *dmhtml.MixedContentAnalysis {
+	analysis := &dmhtml.MixedContentAnalysis{
+		Applicable: pageURL.Scheme == "https",
+		Items:      []dmhtml.MixedContentItem{},
+	}
+
+	if !analysis.Applicable {
+		return analysis
+	}
+
+	for _, ref := range refs {
+		resourceURL, err := url.Parse(ref.url)
+		if err != nil || resourceURL.Scheme != "http" {
+			continue
+		}
+
+		item := dmhtml.MixedContentItem{
+			URL:       ref.url,
+			Element:   ref.element,
+			Attribute: ref.attribute,
+			Type:      mixedContentType(ref),
+			Location:  elementPath(ref.node),
+		}
+
+		if item.Type == dmhtml.ActiveMixedContent {
+			analysis.Active++
+		} else {
+			analysis.Passive++
+		}
+
+		analysis.Items = append(analysis.Items, item)
+	}
+
+	return analysis
+}
+
+// Only media loaded by media elements is passive, everything else can alter the page
+func mixedContentType(ref resourceRef) string {
+	switch ref.element {
+	case "img", "video", "audio", "source", "track":
+		if ref.attribute != "style" {
+			return dmhtml.PassiveMixedContent
+		}
+	case "input":
+		if ref.attribute == "src" {
+			return dmhtml.PassiveMixedContent
+		}
+	}
+
+	return dmhtml.ActiveMixedContent
+}
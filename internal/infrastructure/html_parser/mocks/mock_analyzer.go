@@ -0,0 +1,195 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/analyzer/analyzer.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/domain/analyzer/analyzer.go -destination=internal/infrastructure/html_parser/mocks/mock_analyzer.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+	analyzer "web-pages-analyzer/internal/domain/analyzer"
+	http "web-pages-analyzer/internal/domain/clients/http"
+	html "web-pages-analyzer/internal/domain/html"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAnalyzer is a mock of Analyzer interface.
+type MockAnalyzer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAnalyzerMockRecorder
+	isgomock struct{}
+}
+
+// MockAnalyzerMockRecorder is the mock recorder for MockAnalyzer.
+type MockAnalyzerMockRecorder struct {
+	mock *MockAnalyzer
+}
+
+// NewMockAnalyzer creates a new mock instance.
+func NewMockAnalyzer(ctrl *gomock.Controller) *MockAnalyzer {
+	mock := &MockAnalyzer{ctrl: ctrl}
+	mock.recorder = &MockAnalyzerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAnalyzer) EXPECT() *MockAnalyzerMockRecorder {
+	return m.recorder
+}
+
+// Analyze mocks base method.
+func (m *MockAnalyzer) Analyze(ctx context.Context, doc *analyzer.Document) (any, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Analyze", ctx, doc)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Analyze indicates an expected call of Analyze.
+func (mr *MockAnalyzerMockRecorder) Analyze(ctx, doc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Analyze", reflect.TypeOf((*MockAnalyzer)(nil).Analyze), ctx, doc)
+}
+
+// Name mocks base method.
+func (m *MockAnalyzer) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockAnalyzerMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockAnalyzer)(nil).Name))
+}
+
+// MockDocumentParser is a mock of DocumentParser interface.
+type MockDocumentParser struct {
+	ctrl     *gomock.Controller
+	recorder *MockDocumentParserMockRecorder
+	isgomock struct{}
+}
+
+// MockDocumentParserMockRecorder is the mock recorder for MockDocumentParser.
+type MockDocumentParserMockRecorder struct {
+	mock *MockDocumentParser
+}
+
+// NewMockDocumentParser creates a new mock instance.
+func NewMockDocumentParser(ctrl *gomock.Controller) *MockDocumentParser {
+	mock := &MockDocumentParser{ctrl: ctrl}
+	mock.recorder = &MockDocumentParserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDocumentParser) EXPECT() *MockDocumentParserMockRecorder {
+	return m.recorder
+}
+
+// Parse mocks base method.
+func (m *MockDocumentParser) Parse(body io.Reader, pageURL string, client http.HttpClient, opts *html.ParserOptions) (*analyzer.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Parse", body, pageURL, client, opts)
+	ret0, _ := ret[0].(*analyzer.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Parse indicates an expected call of Parse.
+func (mr *MockDocumentParserMockRecorder) Parse(body, pageURL, client, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Parse", reflect.TypeOf((*MockDocumentParser)(nil).Parse), body, pageURL, client, opts)
+}
+
+// Stream mocks base method.
+func (m *MockDocumentParser) Stream(body io.Reader, pageURL string, client http.HttpClient, opts *html.ParserOptions) (*analyzer.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stream", body, pageURL, client, opts)
+	ret0, _ := ret[0].(*analyzer.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stream indicates an expected call of Stream.
+func (mr *MockDocumentParserMockRecorder) Stream(body, pageURL, client, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stream", reflect.TypeOf((*MockDocumentParser)(nil).Stream), body, pageURL, client, opts)
+}
+
+// MockStreamingAnalyzer is a mock of StreamingAnalyzer interface.
+type MockStreamingAnalyzer struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamingAnalyzerMockRecorder
+	isgomock struct{}
+}
+
+// MockStreamingAnalyzerMockRecorder is the mock recorder for MockStreamingAnalyzer.
+type MockStreamingAnalyzerMockRecorder struct {
+	mock *MockStreamingAnalyzer
+}
+
+// NewMockStreamingAnalyzer creates a new mock instance.
+func NewMockStreamingAnalyzer(ctrl *gomock.Controller) *MockStreamingAnalyzer {
+	mock := &MockStreamingAnalyzer{ctrl: ctrl}
+	mock.recorder = &MockStreamingAnalyzerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStreamingAnalyzer) EXPECT() *MockStreamingAnalyzerMockRecorder {
+	return m.recorder
+}
+
+// Analyze mocks base method.
+func (m *MockStreamingAnalyzer) Analyze(ctx context.Context, doc *analyzer.Document) (any, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Analyze", ctx, doc)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Analyze indicates an expected call of Analyze.
+func (mr *MockStreamingAnalyzerMockRecorder) Analyze(ctx, doc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Analyze", reflect.TypeOf((*MockStreamingAnalyzer)(nil).Analyze), ctx, doc)
+}
+
+// Name mocks base method.
+func (m *MockStreamingAnalyzer) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockStreamingAnalyzerMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockStreamingAnalyzer)(nil).Name))
+}
+
+// Streaming mocks base method.
+func (m *MockStreamingAnalyzer) Streaming() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Streaming")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Streaming indicates an expected call of Streaming.
+func (mr *MockStreamingAnalyzerMockRecorder) Streaming() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Streaming", reflect.TypeOf((*MockStreamingAnalyzer)(nil).Streaming))
+}
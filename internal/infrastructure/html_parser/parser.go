@@ -1,10 +1,10 @@
 package html_parser
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"io"
 	"net/url"
+	"slices"
 	"strings"
 	"sync"
 
@@ -15,25 +15,25 @@ import (
 	utlstr "web-pages-analyzer/internal/utils/string"
 )
 
-const (
-	html5Version   = "HTML5"
-	html4Version   = "HTML 4.01"
-	xhtmlVersion   = "XHTML"
-	unknownVersion = "Unknown HTML Version"
-)
-
 type parser struct {
-	node    *html.Node
-	baseUrl *url.URL
-	body    io.Reader
-	client  clihttp.HttpClient
+	node       *html.Node
+	baseUrl    *url.URL
+	docBaseUrl *url.URL
+	client     clihttp.HttpClient
+	opts       dmhtml.ParserOptions
+	classifier *hostClassifier
+	htmlBytes  int64
+
+	formsOnce sync.Once
+	forms     *dmhtml.FormAnalysis
 }
 
-func New(body io.Reader, baseUrl string, client clihttp.HttpClient) (dmhtml.HtmlParser, error) {
-	var buf bytes.Buffer
-	teeBody := io.TeeReader(body, &buf)
+// Parse the document read from the body, as served from the base URL
+func parse(body io.Reader, baseUrl string, client clihttp.HttpClient, opts *dmhtml.ParserOptions) (*parser, error) {
+	// Count the bytes of the document for the text-to-HTML ratio
+	counter := &countingReader{reader: body}
 
-	node, err := html.Parse(teeBody)
+	node, err := html.Parse(counter)
 	if err != nil {
 		return nil, err
 	}
@@ -43,283 +43,197 @@ func New(body io.Reader, baseUrl string, client clihttp.HttpClient) (dmhtml.Html
 		return nil, err
 	}
 
-	return &parser{
-		node:    node,
-		baseUrl: base,
-		body:    io.NopCloser(bytes.NewReader(buf.Bytes())),
-		client:  client,
-	}, nil
-}
-
-func (p *parser) GetHtmlVersion() string {
-	scanner := bufio.NewScanner(p.body)
-
-	for scanner.Scan() {
-		line := strings.ToLower(scanner.Text())
-
-		if strings.Contains(line, "<!doctype html>") {
-			return html5Version
-		} else if strings.Contains(line, "<!doctype html public") {
-			if strings.Contains(line, "4.01") {
-				return html4Version
-			} else if strings.Contains(line, "xhtml") {
-				return xhtmlVersion
-			}
-		}
+	if opts == nil {
+		opts = &dmhtml.ParserOptions{}
 	}
 
-	return unknownVersion
-}
-
-// Extract the title from the HTML document
-func (p *parser) GetTitle() string {
-	return strings.TrimSpace(findTitle(p.node))
-}
-
-// Count the number of heading levels in the HTML document
-func (p *parser) CountHeadingLevels() map[string]int {
-	headings := map[string]int{
-		"h1": 0,
-		"h2": 0,
-		"h3": 0,
-		"h4": 0,
-		"h5": 0,
-		"h6": 0,
+	if err = opts.Validate(); err != nil {
+		return nil, err
 	}
 
-	countHeadingLevels(p.node, headings)
-	return headings
-}
-
-func (p *parser) HasLoginForm() bool {
-	return existLoginForm(p.node)
+	return newParser(node, base, client, *opts, counter.count), nil
 }
 
-func (p *parser) AnalyzeLinks() *dmhtml.LinkAnalysis {
-	var internal, external, inaccessible int
-
-	links := extractLinks(p.node, p.baseUrl)
-
-	type linkResult struct {
-		isInternal   bool
-		isAccessible bool
-	}
-
-	results := make(chan linkResult, len(links))
-	var wg sync.WaitGroup
-
-	for _, link := range links {
-		wg.Add(1)
-		go func(linkURL string, baseHost string) {
-			defer wg.Done()
+func newParser(node *html.Node, base *url.URL, client clihttp.HttpClient, opts dmhtml.ParserOptions, htmlBytes int64) *parser {
+	return &parser{
+		node:       node,
+		baseUrl:    base,
+		docBaseUrl: documentBase(node, base),
+		client:     client,
+		opts:       opts,
+		classifier: newHostClassifier(base, opts),
+		htmlBytes:  htmlBytes,
+	}
+}
+
+// Computed once from the forms the visitor collected, the form analysis also backs the login flag
+func (p *parser) formAnalysis(visit func() *formsVisitor) *dmhtml.FormAnalysis {
+	p.formsOnce.Do(func() {
+		threshold := dmhtml.DefaultLoginThreshold
+		if p.opts.LoginThreshold != nil {
+			threshold = *p.opts.LoginThreshold
+		}
 
-			parsedLink, err := url.Parse(linkURL)
-			if err != nil {
-				results <- linkResult{isInternal: false, isAccessible: false}
-				return
-			}
+		p.forms = analyzeForms(visit(), p.baseUrl, p.docBaseUrl, p.classifier, threshold)
+	})
 
-			isInternal := isInternalLink(parsedLink, baseHost)
+	return p.forms
+}
 
-			isAccessible := p.checkLinkAccessibility(linkURL)
+// Classify and check the links the visitor collected. Checks interrupted by the context leave the
+// analysis incomplete, it is returned along with the context error.
+func (p *parser) analyzeLinks(ctx context.Context, visitor *linksVisitor) (*dmhtml.LinkAnalysis, error) {
+	var internal, external, inaccessible, unchecked int
+	var hygiene dmhtml.LinkHygiene
+	redirects := []clihttp.RedirectChain{}
 
-			results <- linkResult{isInternal: isInternal, isAccessible: isAccessible}
-		}(link, p.baseUrl.Host)
+	links := slices.Clone(visitor.links)
+	urls := make([]string, len(links))
+	for i, link := range links {
+		urls[i] = link.URL
 	}
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	checks, err := p.checkURLs(ctx, urls)
+	reported := make(map[string]bool)
 
-	// Collect data from results channel
-	for result := range results {
-		if result.isInternal {
-			internal++
-		} else {
+	for i, link := range links {
+		parsedLink, err := url.Parse(link.URL)
+		if err != nil || !p.classifier.isFirstParty(parsedLink) {
 			external++
+		} else {
+			internal++
+			links[i].Internal = true
 		}
 
-		if !result.isAccessible {
+		countLinkHygiene(&hygiene, link)
+
+		check, checked := checks[link.URL]
+		switch {
+		case !checked:
+			unchecked++
+		case !check.accessible:
 			inaccessible++
+			links[i].Inaccessible = true
 		}
-	}
-
-	return &dmhtml.LinkAnalysis{
-		Internal:     internal,
-		External:     external,
-		Inaccessible: inaccessible,
-	}
-}
 
-// check if a link is accessible
-func (p *parser) checkLinkAccessibility(linkURL string) bool {
-	resp, err := p.client.Head(linkURL)
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-
-	return resp.StatusCode >= 200 && resp.StatusCode < 400
-}
-
-func extractLinks(node *html.Node, base *url.URL) []string {
-	var links []string
-
-	if node.Type == html.ElementNode && node.Data == "a" {
-		for _, attr := range node.Attr {
-			if attr.Key == "href" && attr.Val != "" {
-				if resolvedURL := resolveURL(attr.Val, base); resolvedURL != "" {
-					links = append(links, resolvedURL)
-				}
-			}
+		if check.redirects != nil && len(check.redirects.Hops) > 0 && !reported[link.URL] {
+			redirects = append(redirects, *check.redirects)
+			reported[link.URL] = true
 		}
 	}
 
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		links = append(links, extractLinks(child, base)...)
+	fragments, fragmentsErr := p.checkFragments(ctx, visitor.fragments, visitor.anchors)
+	if err == nil {
+		err = fragmentsErr
 	}
 
-	return links
+	return &dmhtml.LinkAnalysis{
+		Classification: p.classifier.mode,
+		BaseURL:        p.docBaseUrl.String(),
+		Internal:       internal,
+		External:       external,
+		Inaccessible:   inaccessible,
+		Unchecked:      unchecked,
+		Redirected:     len(redirects),
+		Redirects:      redirects,
+		Hygiene:        hygiene,
+		Fragments:      fragments,
+		NonHTTP:        analyzeSchemeLinks(visitor.schemeLinks),
+		Details:        links,
+		Incomplete:     err != nil,
+	}, err
 }
 
-func resolveURL(href string, base *url.URL) string {
-	if href == "" || utlstr.ContainsAnyPrefix(href, "#", "javascript:", "mailto:", "tel:") {
-		return ""
-	}
+// Build the inventory from the resources the visitor collected, checking them if enabled
+func (p *parser) inventoryResources(ctx context.Context, visitor *resourcesVisitor) (*dmhtml.ResourceInventory, error) {
+	inventory := inventoryResources(visitor.refs, visitor.inline, p.classifier)
 
-	parsed, err := url.Parse(href)
-	if err != nil {
-		return ""
+	if p.opts.CheckResources {
+		return inventory, p.checkResources(ctx, inventory)
 	}
 
-	resolved := base.ResolveReference(parsed)
-	return resolved.String()
+	return inventory, nil
 }
 
-func isInternalLink(linkURL *url.URL, baseHost string) bool {
-	if linkURL.Host == "" {
-		return true
-	}
-
-	return strings.EqualFold(linkURL.Host, baseHost)
+type urlCheck struct {
+	accessible bool
+	redirects  *clihttp.RedirectChain
 }
 
-func existLoginForm(node *html.Node) bool {
-	if node.Type == html.ElementNode {
-		if node.Data == "form" && existLoginFormTag(node) {
-			return true
-		}
-
-		if node.Data == "input" && existInputTag(node) {
-			return true
-		}
+// Check each distinct URL concurrently with a HEAD request. When the context is done first, the checks
+// completed so far are returned with its error and the pending ones are abandoned.
+func (p *parser) checkURLs(ctx context.Context, urls []string) (map[string]urlCheck, error) {
+	checks := make(map[string]urlCheck, len(urls))
+	if err := ctx.Err(); err != nil {
+		return checks, err
 	}
 
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		if existLoginForm(child) {
-			return true
-		}
+	type result struct {
+		url   string
+		check urlCheck
 	}
 
-	return false
-}
-
-func existLoginFormTag(formNode *html.Node) bool {
-	hasPasswordField := false
-	hasUsernameField := false
-
-	for _, attr := range formNode.Attr {
-		value := strings.ToLower(attr.Val)
-
-		if utlstr.ContainsAnySubstring(attr.Key, "action", "id", "class", "name") &&
-			utlstr.ContainsAnySubstring(value, "login", "signin", "auth", "session") {
-			return true
-		}
-	}
-
-	checkFormInputs(formNode, &hasPasswordField, &hasUsernameField)
-
-	return hasPasswordField && hasUsernameField
-}
-
-func checkFormInputs(node *html.Node, hasPassword *bool, hasUsername *bool) {
-	if node.Type == html.ElementNode && node.Data == "input" {
-		inputType := ""
-		inputName := ""
-		inputId := ""
-
-		for _, attr := range node.Attr {
-			switch attr.Key {
-			case "type":
-				inputType = strings.ToLower(attr.Val)
-			case "name":
-				inputName = strings.ToLower(attr.Val)
-			case "id":
-				inputId = strings.ToLower(attr.Val)
-			}
-		}
-
-		if inputType == "password" {
-			*hasPassword = true
-		}
+	seen := make(map[string]bool, len(urls))
+	results := make(chan result, len(urls))
 
-		if utlstr.ContainsAnySubstring(inputType, "email", "text") {
-			if utlstr.ContainsAnySubstring(inputName, "user", "email", "login") ||
-				utlstr.ContainsAnySubstring(inputId, "user", "email", "login") {
-				*hasUsername = true
-			}
+	for _, u := range urls {
+		if seen[u] {
+			continue
 		}
-	}
+		seen[u] = true
 
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		checkFormInputs(child, hasPassword, hasUsername)
+		go func(linkURL string) {
+			isAccessible, chain := p.checkLinkAccessibility(ctx, linkURL)
+			results <- result{url: linkURL, check: urlCheck{accessible: isAccessible, redirects: chain}}
+		}(u)
 	}
-}
 
-func existInputTag(inputNode *html.Node) bool {
-	for _, attr := range inputNode.Attr {
-		value := strings.ToLower(attr.Val)
-
-		if attr.Key == "type" && value == "password" {
-			return true
-		}
-
-		if utlstr.ContainsAnySubstring(attr.Key, "name", "id", "class") &&
-			utlstr.ContainsAnySubstring(value, "password", "login", "signin", "auth") {
-			return true
+	for range len(seen) {
+		select {
+		case r := <-results:
+			checks[r.url] = r.check
+		case <-ctx.Done():
+			return checks, ctx.Err()
 		}
 	}
 
-	return false
+	return checks, nil
 }
 
-func countHeadingLevels(node *html.Node, headings map[string]int) {
-	if node.Type == html.ElementNode {
-		switch node.Data {
-		case "h1", "h2", "h3", "h4", "h5", "h6":
-			headings[node.Data]++
+// check if a link is accessible and capture the redirects followed to reach it, the request is abandoned
+// once the context is done
+func (p *parser) checkLinkAccessibility(ctx context.Context, linkURL string) (bool, *clihttp.RedirectChain) {
+	resp, err := p.client.Head(ctx, linkURL)
+	if err != nil {
+		// The redirects which led to a failing response are still reported
+		if httpErr, ok := clihttp.NewHttpErrorFromErr(err); ok {
+			return false, httpErr.Redirects
 		}
+		return false, nil
 	}
+	defer resp.Body.Close()
 
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		countHeadingLevels(child, headings)
+	// A chain the client gave up on ends on a redirect, the link never reached a page
+	chain := clihttp.NewRedirectChain(linkURL, resp)
+	if chain.Loop || chain.Excessive {
+		return false, chain
 	}
+
+	return resp.StatusCode >= 200 && resp.StatusCode < 400, chain
 }
 
-// Search for the title element in the HTML document
-func findTitle(node *html.Node) string {
-	if node.Type == html.ElementNode && node.Data == "title" {
-		return getTextContent(node)
+func resolveURL(href string, base *url.URL) string {
+	if href == "" || utlstr.ContainsAnyPrefix(href, "#", "javascript:", "mailto:", "tel:") {
+		return ""
 	}
 
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		if title := findTitle(child); title != "" {
-			return title
-		}
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return ""
 	}
 
-	return ""
+	resolved := base.ResolveReference(parsed)
+	return resolved.String()
 }
 
 func getTextContent(node *html.Node) string {
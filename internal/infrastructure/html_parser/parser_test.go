@@ -1,18 +1,33 @@
 package html_parser
 
 import (
+	"context"
+	"errors"
 	"io"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"go.uber.org/mock/gomock"
+	"golang.org/x/net/html"
 
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
 	clihttp "web-pages-analyzer/internal/domain/clients/http"
+	dmext "web-pages-analyzer/internal/domain/extraction"
+	dmfp "web-pages-analyzer/internal/domain/fingerprint"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+	dmtrk "web-pages-analyzer/internal/domain/tracker"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
 	httpmocks "web-pages-analyzer/internal/infrastructure/clients/http/mocks"
+	fgp "web-pages-analyzer/internal/infrastructure/fingerprinter"
+	trkdet "web-pages-analyzer/internal/infrastructure/tracker_detector"
 )
 
-func Test_GetHtmlVersion(t *testing.T) {
+func Test_HTMLVersion(t *testing.T) {
 	tests := []struct {
 		name        string
 		htmlContent string
@@ -38,6 +53,31 @@ func Test_GetHtmlVersion(t *testing.T) {
 			htmlContent: `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd">`,
 			expected:    "XHTML",
 		},
+		{
+			name:        "HTML 4.01 Transitional doctype spanning lines",
+			htmlContent: "<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01 Transitional//EN\"\n\t\"http://www.w3.org/TR/html4/loose.dtd\">\n<html></html>",
+			expected:    "HTML 4.01",
+		},
+		{
+			name:        "XHTML 1.1 HTML version",
+			htmlContent: `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.1//EN" "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd">`,
+			expected:    "XHTML",
+		},
+		{
+			name:        "HTML 3.2 HTML version",
+			htmlContent: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">`,
+			expected:    "HTML 3.2",
+		},
+		{
+			name:        "HTML5 legacy-compat doctype",
+			htmlContent: `<!DOCTYPE html SYSTEM "about:legacy-compat"><html></html>`,
+			expected:    "HTML5",
+		},
+		{
+			name:        "doctype text inside comment and script",
+			htmlContent: "<!-- <!DOCTYPE html> --><html><head><script>var d = '<!doctype html>';</script></head></html>",
+			expected:    "Unknown HTML Version",
+		},
 		{
 			name:        "Unknown HTML version",
 			htmlContent: "<html><head><title>Test</title></head></html>",
@@ -51,22 +91,100 @@ func Test_GetHtmlVersion(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockClient := httpmocks.NewMockHttpClient(ctrl)
-			body := strings.NewReader(tt.htmlContent)
-
-			parser, err := New(body, "https://example.com", mockClient)
-			if err != nil {
-				t.Fatalf("failed to create new parser: %v", err)
+			result := analyzeSection[string](t, tt.htmlContent, "https://example.com", mockClient, nil, dmpg.HTMLVersionSection)
+			if result != tt.expected {
+				t.Errorf("expected HTML version %s, got %s", tt.expected, result)
 			}
+		})
+	}
+}
+
+func Test_Doctype(t *testing.T) {
+	tests := []struct {
+		name        string
+		htmlContent string
+		expected    dmhtml.DoctypeAnalysis
+	}{
+		{
+			name:        "HTML5 doctype",
+			htmlContent: "<!DOCTYPE html><html></html>",
+			expected:    dmhtml.DoctypeAnalysis{Name: "html", DTD: "HTML5", RenderingMode: dmhtml.StandardsMode},
+		},
+		{
+			name:        "HTML 4.01 Strict doctype",
+			htmlContent: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd">`,
+			expected: dmhtml.DoctypeAnalysis{
+				Name:          "html",
+				PublicID:      "-//W3C//DTD HTML 4.01//EN",
+				SystemID:      "http://www.w3.org/TR/html4/strict.dtd",
+				DTD:           "HTML 4.01 Strict",
+				RenderingMode: dmhtml.StandardsMode,
+			},
+		},
+		{
+			name:        "HTML 4.01 Transitional doctype with system identifier",
+			htmlContent: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">`,
+			expected: dmhtml.DoctypeAnalysis{
+				Name:          "html",
+				PublicID:      "-//W3C//DTD HTML 4.01 Transitional//EN",
+				SystemID:      "http://www.w3.org/TR/html4/loose.dtd",
+				DTD:           "HTML 4.01 Transitional",
+				RenderingMode: dmhtml.AlmostStandardsMode,
+			},
+		},
+		{
+			name:        "HTML 4.01 Frameset doctype without system identifier",
+			htmlContent: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Frameset//EN">`,
+			expected: dmhtml.DoctypeAnalysis{
+				Name:          "html",
+				PublicID:      "-//W3C//DTD HTML 4.01 Frameset//EN",
+				DTD:           "HTML 4.01 Frameset",
+				RenderingMode: dmhtml.QuirksMode,
+			},
+		},
+		{
+			name:        "XHTML 1.0 Transitional doctype",
+			htmlContent: `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">`,
+			expected: dmhtml.DoctypeAnalysis{
+				Name:          "html",
+				PublicID:      "-//W3C//DTD XHTML 1.0 Transitional//EN",
+				SystemID:      "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd",
+				DTD:           "XHTML 1.0 Transitional",
+				RenderingMode: dmhtml.AlmostStandardsMode,
+			},
+		},
+		{
+			name:        "HTML 3.2 doctype",
+			htmlContent: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">`,
+			expected: dmhtml.DoctypeAnalysis{
+				Name:          "html",
+				PublicID:      "-//W3C//DTD HTML 3.2 Final//EN",
+				DTD:           "HTML 3.2 Final",
+				RenderingMode: dmhtml.QuirksMode,
+			},
+		},
+		{
+			name:        "missing doctype",
+			htmlContent: "<html><body></body></html>",
+			expected:    dmhtml.DoctypeAnalysis{DTD: "Unknown", RenderingMode: dmhtml.QuirksMode},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
-			result := parser.GetHtmlVersion()
+			mockClient := httpmocks.NewMockHttpClient(ctrl)
+			result := analyzeSection[dmhtml.DoctypeAnalysis](t, tt.htmlContent, "https://example.com", mockClient, nil, dmpg.DoctypeSection)
 			if result != tt.expected {
-				t.Errorf("expected HTML version %s, got %s", tt.expected, result)
+				t.Errorf("expected doctype %+v, got %+v", tt.expected, result)
 			}
 		})
 	}
 }
 
-func Test_GetTitle(t *testing.T) {
+func Test_Title(t *testing.T) {
 	tests := []struct {
 		name        string
 		htmlContent string
@@ -105,14 +223,7 @@ func Test_GetTitle(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockClient := httpmocks.NewMockHttpClient(ctrl)
-			body := strings.NewReader(tt.htmlContent)
-
-			parser, err := New(body, "https://example.com", mockClient)
-			if err != nil {
-				t.Fatalf("failed to create new parser: %v", err)
-			}
-
-			result := parser.GetTitle()
+			result := analyzeSection[string](t, tt.htmlContent, "https://example.com", mockClient, nil, dmpg.TitleSection)
 			if result != tt.expected {
 				t.Errorf("expected title %s, got %q", tt.expected, result)
 			}
@@ -120,7 +231,7 @@ func Test_GetTitle(t *testing.T) {
 	}
 }
 
-func Test_CountHeadingLevels(t *testing.T) {
+func Test_Headings(t *testing.T) {
 	tests := []struct {
 		name        string
 		htmlContent string
@@ -163,14 +274,7 @@ func Test_CountHeadingLevels(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockClient := httpmocks.NewMockHttpClient(ctrl)
-			body := strings.NewReader(tt.htmlContent)
-
-			parser, err := New(body, "https://example.com", mockClient)
-			if err != nil {
-				t.Fatalf("failed to create new parser: %v", err)
-			}
-
-			result := parser.CountHeadingLevels()
+			result := analyzeSection[map[string]int](t, tt.htmlContent, "https://example.com", mockClient, nil, dmpg.HeadingsSection)
 			for level, expectedCount := range tt.expected {
 				if result[level] != expectedCount {
 					t.Errorf("expected %s count %d, got %d", level, expectedCount, result[level])
@@ -180,6 +284,49 @@ func Test_CountHeadingLevels(t *testing.T) {
 	}
 }
 
+func Test_HeadingOutline(t *testing.T) {
+	tests := []struct {
+		name        string
+		htmlContent string
+		expected    []dmhtml.Heading
+	}{
+		{
+			name: "headings in document order",
+			htmlContent: `<html><body>
+				<h1>Main</h1><section><h2>First</h2><h3>Detail</h3></section><h2>Second</h2>
+			</body></html>`,
+			expected: []dmhtml.Heading{
+				{Level: "h1", Text: "Main"}, {Level: "h2", Text: "First"}, {Level: "h3", Text: "Detail"}, {Level: "h2", Text: "Second"},
+			},
+		},
+		{
+			name:        "text of the nested elements with collapsed whitespace",
+			htmlContent: "<html><body><h1>\n  Green <em>tea</em>\n  <a href=\"/tea\">guide</a> </h1></body></html>",
+			expected:    []dmhtml.Heading{{Level: "h1", Text: "Green tea guide"}},
+		},
+		{
+			name:        "no headings",
+			htmlContent: "<html><body><p>No headings here</p></body></html>",
+			expected:    []dmhtml.Heading{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := httpmocks.NewMockHttpClient(ctrl)
+			result := analyzeSection[[]dmhtml.Heading](t, tt.htmlContent, "https://example.com", mockClient, nil, dmpg.HeadingOutlineSection)
+
+			// Verify results
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected outline %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
+
 func Test_HasLoginForm(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -228,6 +375,17 @@ func Test_HasLoginForm(t *testing.T) {
 			htmlContent: `<html><body><p>No forms here</p></body></html>`,
 			expected:    false,
 		},
+		{
+			name: "signup form is not a login form",
+			htmlContent: `<html><body>
+				<form action="/register" method="post">
+					<input type="email" name="email">
+					<input type="password" name="password">
+					<input type="password" name="password_confirmation">
+				</form>
+			</body></html>`,
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -236,14 +394,7 @@ func Test_HasLoginForm(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockClient := httpmocks.NewMockHttpClient(ctrl)
-			body := strings.NewReader(tt.htmlContent)
-
-			parser, err := New(body, "https://example.com", mockClient)
-			if err != nil {
-				t.Fatalf("unexpected error creating parser: %v", err)
-			}
-
-			result := parser.HasLoginForm()
+			result := analyzeSection[bool](t, tt.htmlContent, "https://example.com", mockClient, nil, dmpg.HasLoginFormSection)
 			if result != tt.expected {
 				t.Errorf("expected %v, got %v", tt.expected, result)
 			}
@@ -251,7 +402,7 @@ func Test_HasLoginForm(t *testing.T) {
 	}
 }
 
-func Test_AnalyzeLinks(t *testing.T) {
+func Test_Links(t *testing.T) {
 	tests := []struct {
 		name                 string
 		htmlContent          string
@@ -260,6 +411,7 @@ func Test_AnalyzeLinks(t *testing.T) {
 		expectedInternal     int
 		expectedExternal     int
 		expectedInaccessible int
+		expectedRedirected   int
 	}{
 		{
 			name: "mixed internal and external links",
@@ -273,13 +425,13 @@ func Test_AnalyzeLinks(t *testing.T) {
 			baseURL: "https://example.com",
 			mockSetup: func(mock *httpmocks.MockHttpClient) {
 				// Internal links
-				mock.EXPECT().Head("https://example.com/internal").Return(
+				mock.EXPECT().Head(gomock.Any(), "https://example.com/internal").Return(
 					&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
-				mock.EXPECT().Head("https://example.com/page").Return(
+				mock.EXPECT().Head(gomock.Any(), "https://example.com/page").Return(
 					&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
 
 				// External link
-				mock.EXPECT().Head("https://external.com").Return(
+				mock.EXPECT().Head(gomock.Any(), "https://external.com").Return(
 					&http.Response{StatusCode: 404, Body: io.NopCloser(strings.NewReader(""))}, nil)
 			},
 			expectedInternal:     2,
@@ -294,9 +446,9 @@ func Test_AnalyzeLinks(t *testing.T) {
 			</body></html>`,
 			baseURL: "https://example.com",
 			mockSetup: func(mock *httpmocks.MockHttpClient) {
-				mock.EXPECT().Head("https://example.com/page1").Return(
+				mock.EXPECT().Head(gomock.Any(), "https://example.com/page1").Return(
 					&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
-				mock.EXPECT().Head("https://external.com").Return(
+				mock.EXPECT().Head(gomock.Any(), "https://external.com").Return(
 					&http.Response{StatusCode: 301, Body: io.NopCloser(strings.NewReader(""))}, nil)
 			},
 			expectedInternal:     1,
@@ -311,15 +463,65 @@ func Test_AnalyzeLinks(t *testing.T) {
 			</body></html>`,
 			baseURL: "https://example.com",
 			mockSetup: func(mock *httpmocks.MockHttpClient) {
-				mock.EXPECT().Head("https://example.com/page1").Return(
+				mock.EXPECT().Head(gomock.Any(), "https://example.com/page1").Return(
 					&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
-				mock.EXPECT().Head("https://unreachable.com").Return(
+				mock.EXPECT().Head(gomock.Any(), "https://unreachable.com").Return(
 					nil, clihttp.NewHttpError(502, "Bad Gateway"))
 			},
 			expectedInternal:     1,
 			expectedExternal:     1,
 			expectedInaccessible: 1,
 		},
+		{
+			name: "redirected links are reported",
+			htmlContent: `<html><body>
+				<a href="/old">Old Page</a>
+				<a href="https://external.com">External</a>
+			</body></html>`,
+			baseURL: "https://example.com",
+			mockSetup: func(mock *httpmocks.MockHttpClient) {
+				mock.EXPECT().Head(gomock.Any(), "https://example.com/old").Return(
+					redirectedResponse("https://example.com/old", "http://example.com/new"), nil)
+				mock.EXPECT().Head(gomock.Any(), "https://external.com").Return(
+					&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
+			},
+			expectedInternal:     1,
+			expectedExternal:     1,
+			expectedInaccessible: 0,
+			expectedRedirected:   1,
+		},
+		{
+			name: "excessive redirect chains are inaccessible",
+			htmlContent: `<html><body>
+				<a href="/start">Start</a>
+			</body></html>`,
+			baseURL: "https://example.com",
+			mockSetup: func(mock *httpmocks.MockHttpClient) {
+				resp := redirectedResponse("https://example.com/start", "https://example.com/r1")
+				resp.StatusCode = http.StatusFound
+				resp.Header = http.Header{"Location": []string{"https://example.com/r2"}}
+				mock.EXPECT().Head(gomock.Any(), "https://example.com/start").Return(resp, nil)
+			},
+			expectedInternal:     1,
+			expectedInaccessible: 1,
+			expectedRedirected:   1,
+		},
+		{
+			name: "failing redirected links keep their chain",
+			htmlContent: `<html><body>
+				<a href="/old">Old Page</a>
+			</body></html>`,
+			baseURL: "https://example.com",
+			mockSetup: func(mock *httpmocks.MockHttpClient) {
+				httpErr := clihttp.NewHttpError(404, "Not Found")
+				httpErr.Redirects = clihttp.NewRedirectChain("https://example.com/old",
+					redirectedResponse("https://example.com/old", "https://example.com/gone"))
+				mock.EXPECT().Head(gomock.Any(), "https://example.com/old").Return(nil, httpErr)
+			},
+			expectedInternal:     1,
+			expectedInaccessible: 1,
+			expectedRedirected:   1,
+		},
 		{
 			name:        "no links",
 			htmlContent: `<html><body><p>No links here</p></body></html>`,
@@ -341,13 +543,7 @@ func Test_AnalyzeLinks(t *testing.T) {
 			mockClient := httpmocks.NewMockHttpClient(ctrl)
 			tt.mockSetup(mockClient)
 
-			body := strings.NewReader(tt.htmlContent)
-			parser, err := New(body, tt.baseURL, mockClient)
-			if err != nil {
-				t.Fatalf("unexpected error creating parser: %v", err)
-			}
-
-			result := parser.AnalyzeLinks()
+			result := analyzeSection[dmhtml.LinkAnalysis](t, tt.htmlContent, tt.baseURL, mockClient, nil, dmpg.LinksSection)
 
 			if result.Internal != tt.expectedInternal {
 				t.Errorf("expected %d internal links, got %d", tt.expectedInternal, result.Internal)
@@ -358,6 +554,1621 @@ func Test_AnalyzeLinks(t *testing.T) {
 			if result.Inaccessible != tt.expectedInaccessible {
 				t.Errorf("expected %d inaccessible links, got %d", tt.expectedInaccessible, result.Inaccessible)
 			}
+			flagged := 0
+			for _, link := range result.Details {
+				if link.Inaccessible {
+					flagged++
+				}
+			}
+			if flagged != tt.expectedInaccessible {
+				t.Errorf("expected %d links flagged inaccessible, got %d", tt.expectedInaccessible, flagged)
+			}
+			if result.Redirected != tt.expectedRedirected {
+				t.Errorf("expected %d redirected links, got %d", tt.expectedRedirected, result.Redirected)
+			}
+		})
+	}
+}
+
+func Test_HostClassifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		opts     dmhtml.ParserOptions
+		target   string
+		expected bool
+	}{
+		{
+			name:     "subdomain is first-party by default",
+			baseURL:  "https://www.example.com",
+			target:   "https://blog.example.com/post",
+			expected: true,
+		},
+		{
+			name:     "apex domain is first-party by default",
+			baseURL:  "https://www.example.com",
+			target:   "https://EXAMPLE.com./about",
+			expected: true,
+		},
+		{
+			name:     "public suffix is respected",
+			baseURL:  "https://shop.example.co.uk",
+			target:   "https://other.co.uk",
+			expected: false,
+		},
+		{
+			name:     "relative link is first-party",
+			baseURL:  "https://example.com",
+			target:   "/about",
+			expected: true,
+		},
+		{
+			name:     "exact host treats default port as equal",
+			baseURL:  "https://example.com",
+			opts:     dmhtml.ParserOptions{LinkClassification: dmhtml.ExactHostClassification},
+			target:   "https://example.com:443/page",
+			expected: true,
+		},
+		{
+			name:     "exact host rejects subdomains",
+			baseURL:  "https://example.com",
+			opts:     dmhtml.ParserOptions{LinkClassification: dmhtml.ExactHostClassification},
+			target:   "https://www.example.com",
+			expected: false,
+		},
+		{
+			name:     "same site requires the same scheme",
+			baseURL:  "https://example.com",
+			opts:     dmhtml.ParserOptions{LinkClassification: dmhtml.SameSiteClassification},
+			target:   "http://www.example.com",
+			expected: false,
+		},
+		{
+			name:    "custom domains are first-party",
+			baseURL: "https://example.com",
+			opts: dmhtml.ParserOptions{
+				LinkClassification: dmhtml.CustomClassification,
+				FirstPartyDomains:  []string{"example-cdn.net"},
+			},
+			target:   "https://static.example-cdn.net/app.js",
+			expected: true,
+		},
+		{
+			name:    "custom domains do not match lookalike hosts",
+			baseURL: "https://example.com",
+			opts: dmhtml.ParserOptions{
+				LinkClassification: dmhtml.CustomClassification,
+				FirstPartyDomains:  []string{"example-cdn.net"},
+			},
+			target:   "https://notexample-cdn.net/app.js",
+			expected: false,
+		},
+		{
+			name:     "IP addresses compare by host",
+			baseURL:  "http://127.0.0.1:8080",
+			target:   "http://127.0.0.1:9090",
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, _ := url.Parse(tt.baseURL)
+			target, _ := url.Parse(tt.target)
+
+			result := newHostClassifier(base, tt.opts).isFirstParty(target)
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func Test_Links_BaseHref(t *testing.T) {
+	htmlContent := `<html><head><base href="https://static.example.com/docs/"></head><body>
+		<a href="guide.html">Guide</a>
+		<img src="logo.png">
+	</body></html>`
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := httpmocks.NewMockHttpClient(ctrl)
+	mockClient.EXPECT().Head(gomock.Any(), "https://static.example.com/docs/guide.html").Return(
+		&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil)
+
+	result := analyzeSection[dmhtml.LinkAnalysis](t, htmlContent, "https://example.com/page", mockClient, nil, dmpg.LinksSection)
+	if result.BaseURL != "https://static.example.com/docs/" {
+		t.Errorf("expected base URL from <base href>, got %q", result.BaseURL)
+	}
+	if len(result.Details) != 1 || result.Details[0].URL != "https://static.example.com/docs/guide.html" {
+		t.Fatalf("expected link resolved against <base href>, got %+v", result.Details)
+	}
+	if !result.Details[0].Internal {
+		t.Errorf("expected link on a subdomain to be internal")
+	}
+
+	inventory := analyzeSection[dmhtml.ResourceInventory](t, htmlContent, "https://example.com/page", mockClient, nil, dmpg.ResourcesSection)
+	if len(inventory.Images) != 1 || inventory.Images[0].URL != "https://static.example.com/docs/logo.png" {
+		t.Errorf("expected resource resolved against <base href>, got %+v", inventory.Images)
+	}
+}
+
+func Test_Links_Hygiene(t *testing.T) {
+	htmlContent := `<html><body>
+		<a href="https://ads.com" rel="sponsored nofollow" target="_blank">Ad</a>
+		<a href="https://forum.com" rel="UGC" target="_blank">Post</a>
+		<a href="https://safe.com" target="_blank" rel="noopener noreferrer">Safe</a>
+		<a href="https://referrer.com" target="_blank" rel="noreferrer">No referrer</a>
+		<a href="/report.pdf" download>Report</a>
+		<a href="/de/" hreflang="de">Deutsch</a>
+	</body></html>`
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := httpmocks.NewMockHttpClient(ctrl)
+	mockClient.EXPECT().Head(gomock.Any(), gomock.Any()).Return(
+		&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil).Times(6)
+
+	result := analyzeSection[dmhtml.LinkAnalysis](t, htmlContent, "https://example.com", mockClient, nil, dmpg.LinksSection)
+
+	expected := dmhtml.LinkHygiene{
+		NoFollow:          1,
+		Sponsored:         1,
+		UGC:               1,
+		NoOpener:          1,
+		NoReferrer:        2,
+		UnsafeTargetBlank: 2,
+		Download:          1,
+		Hreflang:          1,
+	}
+	if result.Hygiene != expected {
+		t.Errorf("expected hygiene %+v, got %+v", expected, result.Hygiene)
+	}
+
+	if len(result.Details) != 6 {
+		t.Fatalf("expected 6 links, got %d", len(result.Details))
+	}
+	if !result.Details[0].UnsafeTargetBlank || result.Details[2].UnsafeTargetBlank {
+		t.Errorf("unexpected target=_blank flags: %+v", result.Details)
+	}
+	if result.Details[5].Hreflang != "de" || !result.Details[4].Download {
+		t.Errorf("unexpected download/hreflang attributes: %+v", result.Details)
+	}
+}
+
+func Test_Links_Fragments(t *testing.T) {
+	htmlContent := `<html><body>
+		<a href="#">Top</a>
+		<a href="#TOP">Top</a>
+		<a href="#features">Features</a>
+		<a href="#pricing">Pricing</a>
+		<a name="legacy"></a>
+		<a href="/page#legacy">Legacy</a>
+		<a href="/docs#install">Install</a>
+		<a href="/docs#missing">Missing</a>
+		<a href="https://other.com/#anything">Other</a>
+		<section id="features"></section>
+	</body></html>`
+
+	tests := []struct {
+		name               string
+		opts               *dmhtml.ParserOptions
+		mockSetup          func(*httpmocks.MockHttpClient)
+		expectedChecked    int
+		expectedUnverified int
+		expectedBroken     []string
+	}{
+		{
+			name:               "same-page anchors only",
+			opts:               nil,
+			mockSetup:          func(mock *httpmocks.MockHttpClient) {},
+			expectedChecked:    5,
+			expectedUnverified: 2,
+			expectedBroken:     []string{"#pricing"},
+		},
+		{
+			name: "internal target documents are fetched",
+			opts: &dmhtml.ParserOptions{CheckFragments: true},
+			mockSetup: func(mock *httpmocks.MockHttpClient) {
+				mock.EXPECT().Get(gomock.Any(), "https://example.com/docs").Return(
+					&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(
+						`<html><body><h2 id="install">Install</h2></body></html>`))}, nil).Times(1)
+			},
+			expectedChecked:    7,
+			expectedUnverified: 0,
+			expectedBroken:     []string{"#pricing", "/docs#missing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := httpmocks.NewMockHttpClient(ctrl)
+			mockClient.EXPECT().Head(gomock.Any(), gomock.Any()).Return(
+				&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil).AnyTimes()
+			tt.mockSetup(mockClient)
+
+			result := analyzeSection[dmhtml.LinkAnalysis](t, htmlContent, "https://example.com/page", mockClient, tt.opts, dmpg.LinksSection).Fragments
+
+			if result.Checked != tt.expectedChecked {
+				t.Errorf("expected %d checked fragments, got %d", tt.expectedChecked, result.Checked)
+			}
+			if result.Unverified != tt.expectedUnverified {
+				t.Errorf("expected %d unverified fragments, got %d", tt.expectedUnverified, result.Unverified)
+			}
+			if result.Broken != len(tt.expectedBroken) || len(result.BrokenAnchors) != len(tt.expectedBroken) {
+				t.Fatalf("expected broken anchors %v, got %+v", tt.expectedBroken, result.BrokenAnchors)
+			}
+			for i, href := range tt.expectedBroken {
+				if result.BrokenAnchors[i].Href != href {
+					t.Errorf("expected broken anchor %q, got %q", href, result.BrokenAnchors[i].Href)
+				}
+			}
+			if result.BrokenAnchors[0].Location != "html > body > a:nth-of-type(4)" {
+				t.Errorf("unexpected location %q", result.BrokenAnchors[0].Location)
+			}
 		})
 	}
 }
+
+func Test_Links_NonHTTP(t *testing.T) {
+	htmlContent := `<html><body>
+		<a href="mailto:sales@example.com?subject=Hello">Email</a>
+		<a href="mailto:not-an-email">Broken email</a>
+		<a href="tel:+1 (555) 010-0000">Call</a>
+		<a href="tel:call-us">Broken phone</a>
+		<a href="JavaScript:void(0)">Menu</a>
+		<a href="javascript:openChat()">Chat</a>
+		<a href="data:text/plain,hello">Data</a>
+		<a href="ftp://files.example.com/file.zip">FTP</a>
+		<a href="/about">About</a>
+	</body></html>`
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := httpmocks.NewMockHttpClient(ctrl)
+	mockClient.EXPECT().Head(gomock.Any(), "https://example.com/about").Return(
+		&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil).Times(1)
+
+	result := analyzeSection[dmhtml.LinkAnalysis](t, htmlContent, "https://example.com", mockClient, nil, dmpg.LinksSection)
+	if result.Internal+result.External != 1 {
+		t.Errorf("expected non-HTTP links to be excluded from link counts, got %d", result.Internal+result.External)
+	}
+
+	nonHTTP := result.NonHTTP
+	expectedCounts := map[string]int{
+		dmhtml.MailtoScheme:     2,
+		dmhtml.TelScheme:        2,
+		dmhtml.JavascriptScheme: 2,
+		dmhtml.DataScheme:       1,
+		dmhtml.OtherScheme:      1,
+	}
+	for category, count := range expectedCounts {
+		if nonHTTP.Counts[category] != count {
+			t.Errorf("expected %d %s links, got %d", count, category, nonHTTP.Counts[category])
+		}
+	}
+
+	if nonHTTP.Invalid != 2 {
+		t.Errorf("expected 2 invalid links, got %d", nonHTTP.Invalid)
+	}
+	// invalid mailto and tel, the javascript:void(0) placeholder and the data URL
+	if nonHTTP.Issues != 4 {
+		t.Errorf("expected 4 links with issues, got %d", nonHTTP.Issues)
+	}
+
+	expectedValid := []bool{true, false, true, false, true, true, true, true}
+	for i, valid := range expectedValid {
+		if nonHTTP.Links[i].Valid != valid {
+			t.Errorf("expected %q valid=%v, got %v", nonHTTP.Links[i].Href, valid, nonHTTP.Links[i].Valid)
+		}
+	}
+	if nonHTTP.Links[4].Scheme != dmhtml.JavascriptScheme || nonHTTP.Links[4].Issue == "" {
+		t.Errorf("expected javascript:void(0) to be flagged as a placeholder, got %+v", nonHTTP.Links[4])
+	}
+	if nonHTTP.Links[5].Issue != "" {
+		t.Errorf("expected javascript handler without an issue, got %q", nonHTTP.Links[5].Issue)
+	}
+	if nonHTTP.Links[7].Scheme != "ftp" {
+		t.Errorf("expected ftp scheme, got %q", nonHTTP.Links[7].Scheme)
+	}
+}
+
+func Test_Forms(t *testing.T) {
+	htmlContent := `<html><body>
+		<form action="/session" method="POST">
+			<input type="hidden" name="authenticity_token" value="abc">
+			<input type="email" name="email" autocomplete="username" required>
+			<input type="password" name="password" autocomplete="current-password" required>
+			<button type="submit">Log in</button>
+		</form>
+		<form action="/register" method="post">
+			<input name="email" type="email">
+			<input name="password" type="password" autocomplete="new-password">
+			<div class="g-recaptcha" data-sitekey="key"></div>
+		</form>
+		<form role="search" action="https://search.other.com/find">
+			<input type="search" name="q">
+		</form>
+		<form action="http://lists.example.com/subscribe" method="post">
+			<input type="email" name="subscriber" placeholder="Your email">
+			<input type="submit" value="Subscribe">
+		</form>
+		<form id="checkout" action="/pay" method="post">
+			<input name="cardnumber" autocomplete="cc-number">
+		</form>
+		<input form="checkout" name="cvc" autocomplete="cc-csc">
+		<form action="/contact" method="post">
+			<input name="name">
+			<input type="email" name="email">
+			<textarea name="message"></textarea>
+		</form>
+	</body></html>`
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := httpmocks.NewMockHttpClient(ctrl)
+
+	result := analyzeSection[dmhtml.FormAnalysis](t, htmlContent, "https://example.com", mockClient, nil, dmpg.FormsSection)
+
+	if result.Count != 6 || !result.HasLoginForm {
+		t.Fatalf("expected 6 forms including a login form, got %d (login %v)", result.Count, result.HasLoginForm)
+	}
+
+	expectedPurposes := []string{
+		dmhtml.LoginForm,
+		dmhtml.SignupForm,
+		dmhtml.SearchForm,
+		dmhtml.NewsletterForm,
+		dmhtml.PaymentForm,
+		dmhtml.ContactForm,
+	}
+	for i, purpose := range expectedPurposes {
+		if result.Forms[i].Purpose != purpose {
+			t.Errorf("form %d: expected purpose %q, got %q", i, purpose, result.Forms[i].Purpose)
+		}
+	}
+
+	login := result.Forms[0]
+	if login.Method != "post" || login.Action != "https://example.com/session" {
+		t.Errorf("unexpected login method/action: %s %s", login.Method, login.Action)
+	}
+	if !login.HasCSRFToken || login.HasCaptcha {
+		t.Errorf("expected CSRF token without CAPTCHA on the login form")
+	}
+	if len(login.Fields) != 3 || !login.Fields[1].Required || login.Fields[2].Autocomplete != "current-password" {
+		t.Errorf("unexpected login fields: %+v", login.Fields)
+	}
+
+	if !result.Forms[1].HasCaptcha {
+		t.Errorf("expected CAPTCHA on the signup form")
+	}
+
+	search := result.Forms[2]
+	if search.Method != "get" || !search.ThirdParty || search.InsecureAction {
+		t.Errorf("unexpected search form flags: %+v", search)
+	}
+
+	if newsletter := result.Forms[3]; !newsletter.InsecureAction || newsletter.ThirdParty {
+		t.Errorf("expected insecure first-party newsletter action, got %+v", newsletter)
+	}
+
+	if payment := result.Forms[4]; len(payment.Fields) != 2 || payment.Fields[1].Name != "cvc" {
+		t.Errorf("expected associated field in the payment form, got %+v", payment.Fields)
+	}
+}
+
+func Test_Forms_LoginDetection(t *testing.T) {
+	tests := []struct {
+		name             string
+		htmlContent      string
+		threshold        int
+		expectedScore    int
+		expectedDetected bool
+		expectedSignals  []string
+	}{
+		{
+			name: "username-first step",
+			htmlContent: `<html><body>
+				<form action="/signin/identifier" method="post">
+					<input type="email" name="identifier" autocomplete="username">
+					<button>Next</button>
+				</form>
+			</body></html>`,
+			expectedScore:    85,
+			expectedDetected: true,
+			expectedSignals:  []string{"autocomplete_username", "username_field", "action_keyword", "username_first_step"},
+		},
+		{
+			name: "current-password autocomplete",
+			htmlContent: `<html><body>
+				<form method="post">
+					<input type="password" name="pw" autocomplete="current-password">
+				</form>
+			</body></html>`,
+			expectedScore:    90,
+			expectedDetected: true,
+			expectedSignals:  []string{"password_field", "autocomplete_current_password"},
+		},
+		{
+			name: "sign in with provider buttons only",
+			htmlContent: `<html><body>
+				<a href="https://accounts.google.com/o/oauth2/v2/auth?client_id=1">Google</a>
+			</body></html>`,
+			expectedScore:    50,
+			expectedDetected: true,
+			expectedSignals:  []string{"sso_provider"},
+		},
+		{
+			name: "login form inside an iframe",
+			htmlContent: `<html><body>
+				<iframe src="https://auth.example.com/login"></iframe>
+			</body></html>`,
+			expectedScore:    40,
+			expectedDetected: false,
+			expectedSignals:  []string{"login_iframe"},
+		},
+		{
+			name: "author class is not an authentication signal",
+			htmlContent: `<html><body>
+				<form class="author-bio"><input type="text" name="author"></form>
+			</body></html>`,
+			expectedScore:    0,
+			expectedDetected: false,
+			expectedSignals:  []string{},
+		},
+		{
+			name: "threshold is configurable",
+			htmlContent: `<html><body>
+				<input type="password" name="pin">
+			</body></html>`,
+			threshold:        60,
+			expectedScore:    50,
+			expectedDetected: false,
+			expectedSignals:  []string{"password_field"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := httpmocks.NewMockHttpClient(ctrl)
+			opts := &dmhtml.ParserOptions{}
+			if tt.threshold != 0 {
+				opts.LoginThreshold = &tt.threshold
+			}
+
+			result := analyzeSection[dmhtml.FormAnalysis](t, tt.htmlContent, "https://example.com", mockClient, opts, dmpg.FormsSection)
+			login := result.Login
+
+			if login.Score != tt.expectedScore {
+				t.Errorf("expected score %d, got %d (%+v)", tt.expectedScore, login.Score, login.Signals)
+			}
+			if login.Detected != tt.expectedDetected || result.HasLoginForm != tt.expectedDetected {
+				t.Errorf("expected detected %v, got %v", tt.expectedDetected, login.Detected)
+			}
+			if len(login.Signals) != len(tt.expectedSignals) {
+				t.Fatalf("expected signals %v, got %+v", tt.expectedSignals, login.Signals)
+			}
+			for i, name := range tt.expectedSignals {
+				if login.Signals[i].Name != name {
+					t.Errorf("expected signal %q, got %q", name, login.Signals[i].Name)
+				}
+			}
+		})
+	}
+}
+
+func Test_Links_RedirectChain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := httpmocks.NewMockHttpClient(ctrl)
+	mockClient.EXPECT().Head(gomock.Any(), "https://example.com/old").Return(
+		redirectedResponse("https://example.com/old", "http://example.com/new"), nil)
+
+	result := analyzeSection[dmhtml.LinkAnalysis](t, `<html><body><a href="/old">Old Page</a></body></html>`, "https://example.com", mockClient, nil, dmpg.LinksSection)
+	if len(result.Redirects) != 1 {
+		t.Fatalf("expected 1 redirect chain, got %d", len(result.Redirects))
+	}
+
+	chain := result.Redirects[0]
+	if chain.FinalURL != "http://example.com/new" {
+		t.Errorf("expected final URL %s, got %s", "http://example.com/new", chain.FinalURL)
+	}
+	if len(chain.Hops) != 1 || chain.Hops[0].StatusCode != http.StatusMovedPermanently {
+		t.Errorf("expected a single 301 hop, got %+v", chain.Hops)
+	}
+	if !chain.Downgrade {
+		t.Error("expected HTTPS to HTTP downgrade to be flagged")
+	}
+}
+
+// Build a response as returned by the http client after following a single redirect
+func redirectedResponse(from string, to string) *http.Response {
+	fromURL, _ := url.Parse(from)
+	toURL, _ := url.Parse(to)
+
+	redirect := &http.Response{
+		StatusCode: http.StatusMovedPermanently,
+		Header:     http.Header{"Location": []string{to}},
+		Request:    &http.Request{URL: fromURL},
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    &http.Request{URL: toURL, Response: redirect},
+	}
+}
+
+func Test_MixedContent(t *testing.T) {
+	tests := []struct {
+		name               string
+		htmlContent        string
+		baseURL            string
+		expectedApplicable bool
+		expectedActive     int
+		expectedPassive    int
+		expectedLocations  []string
+	}{
+		{
+			name: "active and passive mixed content",
+			htmlContent: `<html><head>
+				<script src="http://cdn.example.com/app.js"></script>
+				<link rel="stylesheet" href="http://cdn.example.com/style.css">
+				<link rel="canonical" href="http://example.com/">
+			</head><body>
+				<div id="hero"><img src="http://cdn.example.com/hero.png"></div>
+				<img src="/logo.png" srcset="http://cdn.example.com/logo-2x.png 2x">
+				<iframe src="https://video.example.com/embed"></iframe>
+				<form action="http://example.com/subscribe"></form>
+				<div style="background: url('http://cdn.example.com/bg.png')"></div>
+			</body></html>`,
+			baseURL:            "https://example.com",
+			expectedApplicable: true,
+			expectedActive:     4,
+			expectedPassive:    2,
+			expectedLocations: []string{
+				"html > head > script",
+				"html > head > link:nth-of-type(1)",
+				"div#hero > img",
+				"html > body > img",
+				"html > body > form",
+				"html > body > div:nth-of-type(2)",
+			},
+		},
+		{
+			name: "secure resources only",
+			htmlContent: `<html><body>
+				<img src="/logo.png">
+				<script src="https://cdn.example.com/app.js"></script>
+			</body></html>`,
+			baseURL:            "https://example.com",
+			expectedApplicable: true,
+		},
+		{
+			name:               "HTTP page is not applicable",
+			htmlContent:        `<html><body><img src="http://cdn.example.com/logo.png"></body></html>`,
+			baseURL:            "http://example.com",
+			expectedApplicable: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := httpmocks.NewMockHttpClient(ctrl)
+			result := analyzeSection[dmhtml.MixedContentAnalysis](t, tt.htmlContent, tt.baseURL, mockClient, nil, dmpg.MixedContentSection)
+
+			if result.Applicable != tt.expectedApplicable {
+				t.Errorf("expected applicable %v, got %v", tt.expectedApplicable, result.Applicable)
+			}
+			if result.Active != tt.expectedActive {
+				t.Errorf("expected %d active items, got %d", tt.expectedActive, result.Active)
+			}
+			if result.Passive != tt.expectedPassive {
+				t.Errorf("expected %d passive items, got %d", tt.expectedPassive, result.Passive)
+			}
+
+			for i, location := range tt.expectedLocations {
+				if i >= len(result.Items) || result.Items[i].Location != location {
+					t.Errorf("expected item %d at %q, got %+v", i, location, result.Items)
+				}
+			}
+		})
+	}
+}
+
+func Test_Resources(t *testing.T) {
+	htmlContent := `<html><head>
+		<script src="/app.js" defer></script>
+		<script src="https://cdn.other.com/lib.js" async integrity="sha384-abc"></script>
+		<script>window.dataLayer = [];</script>
+		<link rel="stylesheet" href="/style.css">
+		<link rel="preload" href="https://fonts.other.com/font.woff2" as="font">
+		<style>body { color: red; }</style>
+	</head><body>
+		<picture>
+			<source srcset="/hero.webp 1x, /hero-2x.webp 2x">
+			<img src="/hero.png">
+		</picture>
+		<iframe src="https://video.other.com/embed/1"></iframe>
+		<iframe src="about:blank"></iframe>
+		<video src="/intro.mp4" poster="/intro.png"></video>
+		<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+	</body></html>`
+
+	tests := []struct {
+		name                 string
+		opts                 *dmhtml.ParserOptions
+		mockSetup            func(*httpmocks.MockHttpClient)
+		expectedInaccessible int
+	}{
+		{
+			name:      "inventory without accessibility checks",
+			opts:      nil,
+			mockSetup: func(mock *httpmocks.MockHttpClient) {},
+		},
+		{
+			name: "inventory with accessibility checks",
+			opts: &dmhtml.ParserOptions{CheckResources: true},
+			mockSetup: func(mock *httpmocks.MockHttpClient) {
+				mock.EXPECT().Head(gomock.Any(), "https://cdn.other.com/lib.js").Return(
+					nil, clihttp.NewHttpError(404, "Not Found"))
+				mock.EXPECT().Head(gomock.Any(), gomock.Any()).Return(
+					&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil).Times(9)
+			},
+			expectedInaccessible: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := httpmocks.NewMockHttpClient(ctrl)
+			tt.mockSetup(mockClient)
+
+			result := analyzeSection[dmhtml.ResourceInventory](t, htmlContent, "https://example.com", mockClient, tt.opts, dmpg.ResourcesSection)
+
+			counts := map[string]int{
+				"scripts":     len(result.Scripts),
+				"stylesheets": len(result.Stylesheets),
+				"images":      len(result.Images),
+				"iframes":     len(result.Iframes),
+				"fonts":       len(result.Fonts),
+				"media":       len(result.Media),
+			}
+			expectedCounts := map[string]int{
+				"scripts": 3, "stylesheets": 2, "images": 5, "iframes": 2, "fonts": 1, "media": 1,
+			}
+			for group, expected := range expectedCounts {
+				if counts[group] != expected {
+					t.Errorf("expected %d %s, got %d", expected, group, counts[group])
+				}
+			}
+
+			if result.FirstParty != 11 || result.ThirdParty != 3 {
+				t.Errorf("expected 11 first-party and 3 third-party resources, got %d and %d", result.FirstParty, result.ThirdParty)
+			}
+
+			thirdPartyScript := result.Scripts[1]
+			if !thirdPartyScript.Async || !thirdPartyScript.Integrity || thirdPartyScript.FirstParty {
+				t.Errorf("expected async third-party script with integrity, got %+v", thirdPartyScript)
+			}
+
+			if !result.Scripts[2].Inline {
+				t.Errorf("expected inline script, got %+v", result.Scripts[2])
+			}
+
+			// A data: URL is part of the document, it is neither checked nor grouped by host
+			if dataImage := result.Images[4]; !dataImage.Inline || dataImage.Accessible != nil {
+				t.Errorf("expected inline data: image, got %+v", dataImage)
+			}
+			if blank := result.Iframes[1]; blank.Accessible != nil {
+				t.Errorf("expected about:blank iframe to be left unchecked, got %+v", blank)
+			}
+
+			if len(result.Hosts) != 4 || result.Hosts[0].Host != "example.com" || result.Hosts[0].Count != 7 {
+				t.Errorf("expected example.com to be the first of 4 hosts, got %+v", result.Hosts)
+			}
+
+			if result.Checked != (tt.opts != nil) {
+				t.Errorf("expected checked %v, got %v", tt.opts != nil, result.Checked)
+			}
+
+			if result.Inaccessible != tt.expectedInaccessible {
+				t.Errorf("expected %d inaccessible resources, got %d", tt.expectedInaccessible, result.Inaccessible)
+			}
+		})
+	}
+}
+
+func Test_ParseSrcset(t *testing.T) {
+	tests := []struct {
+		name     string
+		srcset   string
+		expected []string
+	}{
+		{
+			name:     "density descriptors",
+			srcset:   "/hero.webp 1x, /hero-2x.webp 2x",
+			expected: []string{"/hero.webp", "/hero-2x.webp"},
+		},
+		{
+			name:     "data URL with commas",
+			srcset:   "data:image/png;base64,iVBORw0KGgo= 1x, /hero-2x.png 2x",
+			expected: []string{"data:image/png;base64,iVBORw0KGgo=", "/hero-2x.png"},
+		},
+		{
+			name:     "candidates without descriptors",
+			srcset:   "/a.png, /b.png 2x,/c.png",
+			expected: []string{"/a.png", "/b.png", "/c.png"},
+		},
+		{
+			name:     "URL with commas and width descriptor",
+			srcset:   "/img?size=1,2 480w,\n\t/img?size=3,4 960w",
+			expected: []string{"/img?size=1,2", "/img?size=3,4"},
+		},
+		{
+			name:     "empty attribute",
+			srcset:   " , ",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseSrcset(tt.srcset)
+
+			// Verify results
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func Test_TechnologiesEvidence(t *testing.T) {
+	htmlContent := `<html><head>
+		<meta name="Generator" content="WordPress 6.4.2">
+		<script src="/wp-includes/js/jquery/jquery.min.js?ver=3.7.1"></script>
+		<script>window.dataLayer = [];</script>
+		<link rel="stylesheet" href="https://cdn.example.com/bootstrap.min.css">
+	</head><body class="home page">
+		<div class="page container"></div>
+	</body></html>`
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := httpmocks.NewMockHttpClient(ctrl)
+
+	fingerprinter := &evidenceFingerprinter{}
+	runAnalyzer(t, NewTechnologiesAnalyzer(fingerprinter), htmlContent, "https://example.com", mockClient, nil)
+	evidence := fingerprinter.evidence
+
+	if generators := evidence.Meta["generator"]; len(generators) != 1 || generators[0] != "WordPress 6.4.2" {
+		t.Errorf("unexpected meta generator: %v", evidence.Meta)
+	}
+	if len(evidence.ScriptSrcs) != 1 || evidence.ScriptSrcs[0] != "https://example.com/wp-includes/js/jquery/jquery.min.js?ver=3.7.1" {
+		t.Errorf("unexpected script sources: %v", evidence.ScriptSrcs)
+	}
+	if len(evidence.InlineScripts) != 1 {
+		t.Errorf("expected 1 inline script, got %v", evidence.InlineScripts)
+	}
+	if len(evidence.Stylesheets) != 1 {
+		t.Errorf("expected 1 stylesheet, got %v", evidence.Stylesheets)
+	}
+	if strings.Join(evidence.Classes, ",") != "container,home,page" {
+		t.Errorf("expected unique sorted classes, got %v", evidence.Classes)
+	}
+}
+
+func Test_TrackersEvidence(t *testing.T) {
+	htmlContent := `<html><head>
+		<script async src="https://www.googletagmanager.com/gtag/js?id=G-1"></script>
+		<script src="/app.js"></script>
+		<link rel="preconnect" href="https://fonts.gstatic.com">
+	</head><body>
+		<img src="https://ads.example.net/banner.png" width="300" height="250">
+		<img src="https://px.tracker.net/p.gif" width="1" height="1">
+		<iframe src="https://www.youtube.com/embed/1"></iframe>
+		<noscript><img height="1" width="1" style="display:none" src="https://www.facebook.com/tr?id=1&ev=PageView&noscript=1"/></noscript>
+		<div id="cookie-banner" class="fixed"><button class="cookie-banner__accept">Accept</button></div>
+	</body></html>`
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := httpmocks.NewMockHttpClient(ctrl)
+
+	detector := &evidenceDetector{}
+	runAnalyzer(t, NewTrackersAnalyzer(detector), htmlContent, "https://example.com", mockClient, nil)
+	evidence := detector.evidence
+
+	expected := []struct {
+		host string
+		kind string
+	}{
+		{"www.googletagmanager.com", "script"},
+		{"ads.example.net", "image"},
+		{"px.tracker.net", "pixel"},
+		{"www.youtube.com", "iframe"},
+		{"www.facebook.com", "pixel"},
+		{"fonts.gstatic.com", "link"},
+	}
+
+	if len(evidence.Requests) != len(expected) {
+		t.Fatalf("expected %d third-party requests, got %+v", len(expected), evidence.Requests)
+	}
+	for i, e := range expected {
+		if evidence.Requests[i].Host != e.host || evidence.Requests[i].Kind != e.kind {
+			t.Errorf("expected %s via %s, got %+v", e.host, e.kind, evidence.Requests[i])
+		}
+	}
+
+	if len(evidence.ConsentElements) != 1 || evidence.ConsentElements[0] != "div#cookie-banner" {
+		t.Errorf("expected the cookie banner to be found once, got %v", evidence.ConsentElements)
+	}
+}
+
+func Test_IsConsentMarked(t *testing.T) {
+	tests := []struct {
+		name     string
+		markup   string
+		expected bool
+	}{
+		{name: "marker as id", markup: `<div id="cookie-consent"></div>`, expected: true},
+		{name: "marker as leading words", markup: `<div id="onetrust-banner-sdk"></div>`, expected: true},
+		{name: "marker among classes", markup: `<div class="modal gdpr_notice"></div>`, expected: true},
+		{name: "marker inside a longer word", markup: `<div class="trusted-badge"></div>`, expected: false},
+		{name: "marker as a word prefix", markup: `<div id="gdprs"></div>`, expected: false},
+		{name: "no markers", markup: `<div class="header"></div>`, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.markup))
+			if err != nil {
+				t.Fatalf("unexpected error parsing markup: %v", err)
+			}
+
+			// Verify results
+			if result := isConsentMarked(findElements(doc, "div")[0]); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func Test_Content(t *testing.T) {
+	htmlContent := `<html><head><title>Ignored title</title><style>.a{}</style></head><body>
+		<h1>Green tea</h1>
+		<p>Green tea is good. Green tea is cheap!</p>
+		<div hidden>Secret text here</div>
+		<p style="display: none">Invisible</p>
+		<script>var x = "code";</script>
+		<noscript>Enable JavaScript</noscript>
+		<p>Drink green tea daily</p>
+	</body></html>`
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := httpmocks.NewMockHttpClient(ctrl)
+
+	result := analyzeSection[dmhtml.ContentAnalysis](t, htmlContent, "https://example.com", mockClient, nil, dmpg.ContentSection)
+
+	if result.WordCount != 14 {
+		t.Errorf("expected 14 words, got %d", result.WordCount)
+	}
+	if result.SentenceCount != 4 {
+		t.Errorf("expected 4 sentences, got %d", result.SentenceCount)
+	}
+	if result.ReadingTimeSeconds != 5 {
+		t.Errorf("expected a reading time of 5 seconds, got %d", result.ReadingTimeSeconds)
+	}
+	if result.FleschReadingEase != 112.6 {
+		t.Errorf("expected a Flesch reading ease of 112.6, got %v", result.FleschReadingEase)
+	}
+
+	text := "Green tea\nGreen tea is good. Green tea is cheap!\nDrink green tea daily"
+	if result.TextBytes != len(text) || result.HTMLBytes != len(htmlContent) {
+		t.Errorf("expected %d text and %d HTML bytes, got %d and %d", len(text), len(htmlContent), result.TextBytes, result.HTMLBytes)
+	}
+
+	expectedKeywords := []dmhtml.Keyword{
+		{Term: "green", Count: 4, Density: 28.57},
+		{Term: "tea", Count: 4, Density: 28.57},
+		{Term: "cheap", Count: 1, Density: 7.14},
+		{Term: "daily", Count: 1, Density: 7.14},
+		{Term: "drink", Count: 1, Density: 7.14},
+		{Term: "good", Count: 1, Density: 7.14},
+	}
+	if !reflect.DeepEqual(result.Keywords, expectedKeywords) {
+		t.Errorf("expected keywords %+v, got %+v", expectedKeywords, result.Keywords)
+	}
+
+	expectedBigrams := []dmhtml.Keyword{{Term: "green tea", Count: 4, Density: 28.57}}
+	if !reflect.DeepEqual(result.Bigrams, expectedBigrams) {
+		t.Errorf("expected bigrams %+v, got %+v", expectedBigrams, result.Bigrams)
+	}
+
+	if len(result.Trigrams) != 0 {
+		t.Errorf("expected no repeated trigrams, got %+v", result.Trigrams)
+	}
+}
+
+func Test_Content_Empty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := httpmocks.NewMockHttpClient(ctrl)
+
+	result := analyzeSection[dmhtml.ContentAnalysis](t, "<html><body><script>run()</script></body></html>", "https://example.com", mockClient, nil, dmpg.ContentSection)
+
+	if result.WordCount != 0 || result.SentenceCount != 0 || result.FleschReadingEase != 0 || result.TextToHTMLRatio != 0 {
+		t.Errorf("expected empty content metrics, got %+v", result)
+	}
+}
+
+func Test_TextLengths(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body><div id="outer">
+		<p id="text">Plain   text <a href="/a">link <b>bold</b></a></p>
+		<ul id="links"><li><a href="/b">Only a link</a></li></ul>
+		<div id="hidden" hidden>Hidden <a href="/c">link</a></div>
+		<script>var notText = 1;</script>
+	</div></body></html>`))
+	if err != nil {
+		t.Fatalf("unexpected error parsing markup: %v", err)
+	}
+
+	lengths := newTextLengths(findElements(doc, "body")[0])
+
+	byID := func(id string) *html.Node {
+		for _, tag := range []string{"p", "ul", "div"} {
+			for _, node := range findElements(doc, tag) {
+				if getAttr(node, "id") == id {
+					return node
+				}
+			}
+		}
+		t.Fatalf("no element with id %s", id)
+		return nil
+	}
+
+	tests := []struct {
+		id              string
+		expected        textLength
+		expectedDensity float64
+	}{
+		{id: "text", expected: textLength{text: 18, links: 8}, expectedDensity: 8.0 / 18},
+		{id: "links", expected: textLength{text: 11, links: 11}, expectedDensity: 1},
+		{id: "hidden", expected: textLength{}, expectedDensity: 0},
+		{id: "outer", expected: textLength{text: 29, links: 19}, expectedDensity: 19.0 / 29},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			node := byID(tt.id)
+
+			// Verify results
+			if lengths[node] != tt.expected {
+				t.Errorf("expected lengths %+v, got %+v", tt.expected, lengths[node])
+			}
+			if density := lengths.linkDensity(node); density != tt.expectedDensity {
+				t.Errorf("expected link density %v, got %v", tt.expectedDensity, density)
+			}
+		})
+	}
+}
+
+func Test_Article(t *testing.T) {
+	tests := []struct {
+		name              string
+		htmlContent       string
+		expectedTitle     string
+		expectedByline    string
+		expectedPublished string
+		expectedLeadImage string
+		expectedMarkdown  string
+		notExpected       []string
+	}{
+		{
+			name: "semantic article with boilerplate around it",
+			htmlContent: `<!DOCTYPE html><html><head><title>Brewing Green Tea | Tea Blog</title>
+				<meta property="og:image" content="/images/lead.jpg">
+				<meta name="author" content="By Jane Doe">
+				<meta property="article:published_time" content="2024-03-01T10:00:00Z">
+			</head><body>
+				<header class="masthead"><nav><a href="/">Home</a> <a href="/blog">Blog</a></nav></header>
+				<aside class="sidebar"><ul><li><a href="/a">Popular post number one here</a></li></ul></aside>
+				<article class="post">
+					<h1>Brewing green tea</h1>
+					<p>Green tea is delicate, so water that is too hot makes it <strong>bitter</strong>, dull and astringent.</p>
+					<p>Use water at about 80 degrees and <a href="/guides/steeping">read our steeping guide</a> for details.</p>
+					<img src="/images/cup.jpg" alt="A cup" class="wide">
+					<ul><li>Sencha, grassy and fresh</li><li>Gyokuro, sweet and rich</li></ul>
+					<div class="share-buttons"><a href="https://twitter.com/share">Tweet</a></div>
+					<script>track()</script>
+				</article>
+				<footer><p>Copyright 2024 Tea Blog, all rights reserved, do not copy.</p></footer>
+			</body></html>`,
+			expectedTitle:     "Brewing Green Tea | Tea Blog",
+			expectedByline:    "Jane Doe",
+			expectedPublished: "2024-03-01T10:00:00Z",
+			expectedLeadImage: "https://example.com/images/lead.jpg",
+			expectedMarkdown: "# Brewing green tea\n\n" +
+				"Green tea is delicate, so water that is too hot makes it **bitter**, dull and astringent.\n\n" +
+				"Use water at about 80 degrees and [read our steeping guide](https://example.com/guides/steeping) for details.\n\n" +
+				"![A cup](https://example.com/images/cup.jpg)\n\n" +
+				"- Sencha, grassy and fresh\n- Gyokuro, sweet and rich",
+			notExpected: []string{"Home", "Popular post", "Tweet", "track()", "Copyright", "class="},
+		},
+		{
+			name: "div layout with comments and byline in the page",
+			htmlContent: `<html><head><title>Release notes</title></head><body>
+				<div id="menu"><a href="/docs">Docs</a> <a href="/download">Download</a> <a href="/community">Community</a></div>
+				<div class="main-column">
+					<p class="byline">By <a href="/authors/sam" rel="author">Sam Lee</a></p>
+					<time datetime="2024-05-02">May 2</time>
+					<div class="entry-content">
+						<p>This release brings faster builds, smaller binaries, and a new plugin API for extensions.</p>
+						<p><img src="/img/chart.png" alt="Build times"></p>
+						<p>Upgrading is safe for most projects, but read the migration notes, especially for plugins.</p>
+					</div>
+				</div>
+				<div id="comments"><p>Great release, thanks to everyone who worked on it, really appreciated!</p></div>
+			</body></html>`,
+			expectedTitle:     "Release notes",
+			expectedByline:    "Sam Lee",
+			expectedPublished: "2024-05-02",
+			expectedLeadImage: "https://example.com/img/chart.png",
+			expectedMarkdown: "This release brings faster builds, smaller binaries, and a new plugin API for extensions.\n\n" +
+				"![Build times](https://example.com/img/chart.png)\n\n" +
+				"Upgrading is safe for most projects, but read the migration notes, especially for plugins.",
+			notExpected: []string{"Docs", "Great release"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := httpmocks.NewMockHttpClient(ctrl)
+
+			article := analyzeSection[*dmext.Article](t, tt.htmlContent, "https://example.com/blog/post", mockClient, nil, dmext.ArticleSection)
+
+			if article.Title != tt.expectedTitle {
+				t.Errorf("expected title %q, got %q", tt.expectedTitle, article.Title)
+			}
+			if article.Byline != tt.expectedByline {
+				t.Errorf("expected byline %q, got %q", tt.expectedByline, article.Byline)
+			}
+			if article.PublishedDate != tt.expectedPublished {
+				t.Errorf("expected published date %q, got %q", tt.expectedPublished, article.PublishedDate)
+			}
+			if article.LeadImage != tt.expectedLeadImage {
+				t.Errorf("expected lead image %q, got %q", tt.expectedLeadImage, article.LeadImage)
+			}
+			if article.Markdown != tt.expectedMarkdown {
+				t.Errorf("expected markdown:\n%s\ngot:\n%s", tt.expectedMarkdown, article.Markdown)
+			}
+			if article.WordCount == 0 || !strings.HasPrefix(article.HTML, "<div>") {
+				t.Errorf("expected extracted HTML and text, got %+v", article)
+			}
+
+			for _, unexpected := range tt.notExpected {
+				if strings.Contains(article.HTML, unexpected) || strings.Contains(article.Text, unexpected) {
+					t.Errorf("did not expect %q in the extracted content", unexpected)
+				}
+			}
+		})
+	}
+}
+
+func Test_Analyzers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := httpmocks.NewMockHttpClient(ctrl)
+
+	page := `<!DOCTYPE html><html><head><title>Sign in</title></head><body>
+		<h1>Welcome</h1>
+		<form action="/login" method="post"><input name="username"/><input type="password" name="password"/><button>Log in</button></form>
+		</body></html>`
+
+	doc, err := NewDocumentParser().Parse(strings.NewReader(page), "https://example.com", mockClient, nil)
+	if err != nil {
+		t.Fatalf("failed to parse document: %v", err)
+	}
+
+	sections := make(map[string]any)
+	var names []string
+	for _, analyzer := range Analyzers() {
+		section, err := analyzer.Analyze(context.Background(), doc)
+		if err != nil {
+			t.Fatalf("%s analyzer failed: %v", analyzer.Name(), err)
+		}
+		names = append(names, analyzer.Name())
+		sections[analyzer.Name()] = section
+	}
+
+	expectedNames := []string{
+		dmpg.HTMLVersionSection, dmpg.DoctypeSection, dmpg.TitleSection, dmpg.HeadingsSection, dmpg.HeadingOutlineSection,
+		dmpg.LinksSection, dmpg.HasLoginFormSection, dmpg.FormsSection, dmpg.MixedContentSection, dmpg.ResourcesSection,
+		dmpg.ContentSection,
+	}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("expected analyzers %v, got %v", expectedNames, names)
+	}
+
+	if sections[dmpg.HTMLVersionSection] != "HTML5" {
+		t.Errorf("expected HTML version %q, got %v", "HTML5", sections[dmpg.HTMLVersionSection])
+	}
+	if sections[dmpg.TitleSection] != "Sign in" {
+		t.Errorf("expected title %q, got %v", "Sign in", sections[dmpg.TitleSection])
+	}
+	if sections[dmpg.HasLoginFormSection] != true {
+		t.Errorf("expected a login form, got %v", sections[dmpg.HasLoginFormSection])
+	}
+
+	// The login flag and the forms section come from the same form analysis
+	forms, ok := sections[dmpg.FormsSection].(dmhtml.FormAnalysis)
+	if !ok || len(forms.Forms) != 1 {
+		t.Errorf("expected one analyzed form, got %+v", sections[dmpg.FormsSection])
+	}
+}
+
+// Parse the page and run the analyzer over it, as an analysis does
+func runAnalyzer(t *testing.T, analyzer dmanl.Analyzer, page string, pageURL string, client clihttp.HttpClient, opts *dmhtml.ParserOptions) any {
+	t.Helper()
+
+	doc, err := NewDocumentParser().Parse(strings.NewReader(page), pageURL, client, opts)
+	if err != nil {
+		t.Fatalf("failed to parse document: %v", err)
+	}
+
+	section, err := analyzer.Analyze(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("%s analyzer failed: %v", analyzer.Name(), err)
+	}
+
+	return section
+}
+
+// Run the registered analyzer of the section over the page
+func analyzeSection[T any](t *testing.T, page string, pageURL string, client clihttp.HttpClient, opts *dmhtml.ParserOptions, name string) T {
+	t.Helper()
+
+	for _, analyzer := range append(Analyzers(), NewArticleAnalyzer()) {
+		if analyzer.Name() == name {
+			return runAnalyzer(t, analyzer, page, pageURL, client, opts).(T)
+		}
+	}
+
+	t.Fatalf("no analyzer for the %s section", name)
+	var zero T
+	return zero
+}
+
+// Keeps the evidence it is handed instead of matching signatures
+type evidenceFingerprinter struct {
+	evidence *dmfp.Evidence
+}
+
+func (f *evidenceFingerprinter) Fingerprint(evidence *dmfp.Evidence) []dmfp.Technology {
+	f.evidence = evidence
+	return nil
+}
+
+// Keeps the evidence it is handed instead of identifying trackers
+type evidenceDetector struct {
+	evidence *dmtrk.Evidence
+}
+
+func (d *evidenceDetector) Detect(evidence *dmtrk.Evidence) *dmtrk.TrackerAnalysis {
+	d.evidence = evidence
+	return &dmtrk.TrackerAnalysis{}
+}
+
+// Answers every request with an empty 200 response, so that link and resource checks cost nothing
+type okClient struct{}
+
+func (okClient) Get(context.Context, string) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func (c okClient) GetConditional(ctx context.Context, url string, _ clihttp.Validators) (*http.Response, error) {
+	return c.Get(ctx, url)
+}
+
+func (okClient) Head(context.Context, string) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+// A page with every kind of element the analyzers look at, repeated to the given number of sections
+func generatePage(sections int) string {
+	var page strings.Builder
+	page.WriteString(`<!DOCTYPE html><html><head><title>Generated</title>
+		<meta name="generator" content="WordPress 6.4"><link rel="stylesheet" href="/style.css">
+		<link rel="preconnect" href="https://fonts.example.net"></head><body class="home">`)
+
+	for i := 0; i < sections; i++ {
+		id := strconv.Itoa(i)
+		page.WriteString(`<section id="s` + id + `" class="post wp-block"><h2>Section ` + id + `</h2>
+			<p>Some readable text about section ` + id + `, with a <a href="/page/` + id + `">link</a>,
+			an <a href="https://example.org/` + id + `">external link</a> and a <a href="#s` + id + `">fragment</a>.</p>
+			<img src="/images/` + id + `.png" srcset="/images/` + id + `@2x.png 2x" alt="">
+			<script>var section` + id + ` = true;</script>
+			<form action="/search" method="get"><input type="search" name="q"><button>Search</button></form>
+			</section>`)
+	}
+
+	page.WriteString(`<div id="cookie-consent"><a href="mailto:privacy@example.com">Privacy</a></div>
+		<script src="https://www.googletagmanager.com/gtag/js"></script></body></html>`)
+
+	return page.String()
+}
+
+// The HTML analyzers along with the technologies and trackers analyzers
+func allAnalyzers(tb testing.TB) []dmanl.Analyzer {
+	fingerprinter, err := fgp.New()
+	if err != nil {
+		tb.Fatalf("failed to create the fingerprinter: %v", err)
+	}
+	trackerDetector, err := trkdet.New()
+	if err != nil {
+		tb.Fatalf("failed to create the tracker detector: %v", err)
+	}
+
+	return append(Analyzers(), NewTechnologiesAnalyzer(fingerprinter), NewTrackersAnalyzer(trackerDetector))
+}
+
+func Test_Analyzers_SinglePass(t *testing.T) {
+	page := generatePage(20)
+
+	analyze := func(traverse bool) map[string]any {
+		doc, err := NewDocumentParser().Parse(strings.NewReader(page), "https://example.com", okClient{}, nil)
+		if err != nil {
+			t.Fatalf("failed to parse document: %v", err)
+		}
+
+		analyzers := allAnalyzers(t)
+		if traverse {
+			if err := doc.Traverse(analyzers...); err != nil {
+				t.Fatalf("failed to traverse document: %v", err)
+			}
+		}
+
+		sections := make(map[string]any)
+		for _, analyzer := range analyzers {
+			section, err := analyzer.Analyze(context.Background(), doc)
+			if err != nil {
+				t.Fatalf("%s analyzer failed: %v", analyzer.Name(), err)
+			}
+			sections[analyzer.Name()] = section
+		}
+		return sections
+	}
+
+	// Analyzers reading a shared traversal find what they find when each walks the document alone
+	separate, shared := analyze(false), analyze(true)
+	for name, section := range separate {
+		if !reflect.DeepEqual(section, shared[name]) {
+			t.Errorf("expected the same %s section from a single pass, got %+v instead of %+v", name, shared[name], section)
+		}
+	}
+}
+
+func benchmarkAnalyzers(b *testing.B, traverse func(doc *dmanl.Document, analyzers []dmanl.Analyzer)) {
+	page := generatePage(500)
+	analyzers := allAnalyzers(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		doc, err := NewDocumentParser().Parse(strings.NewReader(page), "https://example.com", okClient{}, nil)
+		if err != nil {
+			b.Fatalf("failed to parse document: %v", err)
+		}
+		b.StartTimer()
+
+		traverse(doc, analyzers)
+		for _, analyzer := range analyzers {
+			if _, err := analyzer.Analyze(context.Background(), doc); err != nil {
+				b.Fatalf("%s analyzer failed: %v", analyzer.Name(), err)
+			}
+		}
+	}
+}
+
+// Every analyzer walks the document for its own visitors
+func BenchmarkAnalyzers_SeparateWalks(b *testing.B) {
+	benchmarkAnalyzers(b, func(doc *dmanl.Document, analyzers []dmanl.Analyzer) {
+		for _, analyzer := range analyzers {
+			doc.Traverse(analyzer)
+		}
+	})
+}
+
+// One walk feeds the visitors of every analyzer
+func BenchmarkAnalyzers_SinglePass(b *testing.B) {
+	benchmarkAnalyzers(b, func(doc *dmanl.Document, analyzers []dmanl.Analyzer) {
+		doc.Traverse(analyzers...)
+	})
+}
+
+func Test_Stream(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+	}{
+		{
+			name: "generated page",
+			page: generatePage(20),
+		},
+		{
+			name: "login page",
+			page: `<!DOCTYPE html><html><head><title>Sign in</title></head><body>
+				<h1>Welcome</h1><h2>Members</h2>
+				<form action="/login" method="post"><label>User <input name="username"></label>
+				<input type="password" name="password"><button>Log in</button></form>
+				<a href="https://accounts.google.com/o/oauth2/auth">Sign in with Google</a>
+				</body></html>`,
+		},
+		{
+			name: "legacy doctype and base element",
+			page: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">
+				<html><head><base href="https://cdn.example.com/docs/"><title> Legacy </title></head>
+				<body><p>First <a href="intro.html">intro</a></p><p>Second <a href="#missing">missing</a>
+				<ul><li><a name="top-anchor">anchor</a></li></ul><p id="contact"><a href="mailto:docs@example.com">mail</a>
+				<input type="password" name="pin" id="pin"></body></html>`,
+		},
+		{
+			name: "no doctype",
+			page: `<title>Untitled</title><h3>Heading</h3>`,
+		},
+		{
+			name: "headings with markup",
+			page: `<!DOCTYPE html><html><body><h1>Green <em>tea</em></h1><h2>Brewing</h3>
+				<p>Steep it <a href="/steep">briefly</a></p><h2><a href="/faq">FAQ</a><h3>Nested</h3></body></html>`,
+		},
+		{
+			name: "repeated siblings",
+			page: `<!DOCTYPE html><html><head><title>Contact</title></head><body>
+				<div><form action="/search"><input type="search" name="q"></form>
+				<form action="/login" method="post"><div><input name="user"></div><div><input type="password" name="pass">
+				<div class="g-recaptcha" data-sitekey="key"></div></div><button>Sign <b>in</b></button></form></div>
+				<div><p><a href="mailto:one@example.com">one</a><a href="tel:+15550100">call</a></p>
+				<p><a href="mailto:two@example.com">two</a></p><p><a href="sms:+15550100">text</a></p></div>
+				<input type="password" name="pin"><input type="password" name="code"></body></html>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := NewDocumentParser().Parse(strings.NewReader(tt.page), "https://example.com/page", okClient{}, nil)
+			if err != nil {
+				t.Fatalf("failed to parse document: %v", err)
+			}
+			streamed, err := NewDocumentParser().Stream(strings.NewReader(tt.page), "https://example.com/page", okClient{}, nil)
+			if err != nil {
+				t.Fatalf("failed to prepare the streamed document: %v", err)
+			}
+
+			var analyzers []dmanl.Analyzer
+			for _, analyzer := range allAnalyzers(t) {
+				if dmanl.Streams(analyzer) {
+					analyzers = append(analyzers, analyzer)
+				}
+			}
+
+			expectedNames := []string{
+				dmpg.HTMLVersionSection, dmpg.DoctypeSection, dmpg.TitleSection, dmpg.HeadingsSection,
+				dmpg.HeadingOutlineSection, dmpg.LinksSection, dmpg.HasLoginFormSection, dmpg.FormsSection,
+			}
+			var names []string
+			for _, analyzer := range analyzers {
+				names = append(names, analyzer.Name())
+			}
+			if !reflect.DeepEqual(names, expectedNames) {
+				t.Fatalf("expected streaming analyzers %v, got %v", expectedNames, names)
+			}
+
+			if err = streamed.Traverse(analyzers...); err != nil {
+				t.Fatalf("failed to stream document: %v", err)
+			}
+
+			// The streamed document yields the sections the parsed one does
+			for _, analyzer := range analyzers {
+				expected, err := analyzer.Analyze(context.Background(), parsed)
+				if err != nil {
+					t.Fatalf("%s analyzer failed on the parsed document: %v", analyzer.Name(), err)
+				}
+				got, err := analyzer.Analyze(context.Background(), streamed)
+				if err != nil {
+					t.Fatalf("%s analyzer failed on the streamed document: %v", analyzer.Name(), err)
+				}
+				if !reflect.DeepEqual(got, expected) {
+					t.Errorf("expected %s section %+v, got %+v", analyzer.Name(), expected, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_Stream_MalformedMarkup(t *testing.T) {
+	page := `<html><body><div><a href="/one">one<a href="/two">two</div>
+		<form id="outer"><form id="inner"><input type="search" name="q"></form>
+		<input form="outer" type="email" name="email"></body></html>`
+
+	doc, err := NewDocumentParser().Stream(strings.NewReader(page), "https://example.com", okClient{}, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare the streamed document: %v", err)
+	}
+
+	analyzers := Analyzers()
+	if err = doc.Traverse(analyzers...); err != nil {
+		t.Fatalf("failed to stream document: %v", err)
+	}
+
+	sections := make(map[string]any)
+	for _, analyzer := range analyzers {
+		if !dmanl.Streams(analyzer) {
+			continue
+		}
+		if sections[analyzer.Name()], err = analyzer.Analyze(context.Background(), doc); err != nil {
+			t.Fatalf("%s analyzer failed: %v", analyzer.Name(), err)
+		}
+	}
+
+	// A link closes the link it is nested in
+	links := sections[dmpg.LinksSection].(dmhtml.LinkAnalysis)
+	if links.Internal != 2 {
+		t.Errorf("expected 2 internal links, got %d", links.Internal)
+	}
+
+	// The nested form is dropped, its field and the associated one belong to the outer form
+	forms := sections[dmpg.FormsSection].(dmhtml.FormAnalysis)
+	if forms.Count != 1 || len(forms.Forms[0].Fields) != 2 {
+		t.Fatalf("expected one form with 2 fields, got %+v", forms)
+	}
+	if forms.Forms[0].Purpose != dmhtml.SearchForm {
+		t.Errorf("expected a search form, got %q", forms.Forms[0].Purpose)
+	}
+}
+
+func Test_Stream_UnclosedSubtree(t *testing.T) {
+	// The heading is never closed, the rest of the page would be buffered as its content
+	paragraph := "<p>" + strings.Repeat("tea ", 250) + "</p>"
+	page := `<html><body><h1>Green tea` + strings.Repeat(paragraph, 2*maxSubtreeBytes/len(paragraph)) +
+		`<a href="/after">after</a><form action="/search"><input type="search" name="q"></form></body></html>`
+
+	doc, err := NewDocumentParser().Stream(strings.NewReader(page), "https://example.com", okClient{}, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare the streamed document: %v", err)
+	}
+
+	analyzers := Analyzers()
+	if err = doc.Traverse(analyzers...); err != nil {
+		t.Fatalf("failed to stream document: %v", err)
+	}
+
+	sections := make(map[string]any)
+	for _, analyzer := range analyzers {
+		if !dmanl.Streams(analyzer) {
+			continue
+		}
+		if sections[analyzer.Name()], err = analyzer.Analyze(context.Background(), doc); err != nil {
+			t.Fatalf("%s analyzer failed: %v", analyzer.Name(), err)
+		}
+	}
+
+	// The heading is walked once it holds too much, with the text read so far
+	outline := sections[dmpg.HeadingOutlineSection].([]dmhtml.Heading)
+	if len(outline) != 1 {
+		t.Fatalf("expected one heading, got %d", len(outline))
+	}
+	if text := outline[0].Text; !strings.HasPrefix(text, "Green tea") || len(text) > maxSubtreeBytes+len(paragraph) {
+		t.Errorf("expected the heading to be cut at %d bytes, got %d bytes", maxSubtreeBytes, len(text))
+	}
+
+	// The rest of the page is streamed as usual
+	links := sections[dmpg.LinksSection].(dmhtml.LinkAnalysis)
+	if links.Internal != 1 {
+		t.Errorf("expected the link after the heading, got %d internal links", links.Internal)
+	}
+	forms := sections[dmpg.FormsSection].(dmhtml.FormAnalysis)
+	if forms.Count != 1 || len(forms.Forms[0].Fields) != 1 {
+		t.Errorf("expected the form after the heading, got %+v", forms)
+	}
+}
+
+// Answers HEAD requests at once, except for the slow URL whose request hangs until released or abandoned
+// with its context
+type slowClient struct {
+	okClient
+	slow      string
+	release   chan struct{}
+	abandoned chan struct{}
+}
+
+func (c slowClient) Head(ctx context.Context, url string) (*http.Response, error) {
+	if url == c.slow {
+		select {
+		case <-c.release:
+		case <-ctx.Done():
+			close(c.abandoned)
+			return nil, ctx.Err()
+		}
+	}
+	return c.okClient.Head(ctx, url)
+}
+
+func Test_Links_Interrupted(t *testing.T) {
+	page := `<html><body>
+		<a href="/fast">fast</a><a href="https://example.org/fast">external</a><a href="/slow">slow</a>
+		</body></html>`
+
+	client := slowClient{slow: "https://example.com/slow", release: make(chan struct{}), abandoned: make(chan struct{})}
+	defer close(client.release)
+
+	doc, err := NewDocumentParser().Parse(strings.NewReader(page), "https://example.com", client, nil)
+	if err != nil {
+		t.Fatalf("failed to parse document: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var links dmanl.Analyzer
+	for _, analyzer := range Analyzers() {
+		if analyzer.Name() == dmpg.LinksSection {
+			links = analyzer
+		}
+	}
+
+	section, err := links.Analyze(ctx, doc)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+
+	// The links are all classified, only the check of the slow one is missing
+	analysis, ok := section.(dmhtml.LinkAnalysis)
+	if !ok {
+		t.Fatalf("expected the partial link analysis, got %v", section)
+	}
+	if !analysis.Incomplete {
+		t.Error("expected the link analysis to be incomplete")
+	}
+	if analysis.Internal != 2 || analysis.External != 1 {
+		t.Errorf("expected 2 internal and 1 external links, got %d and %d", analysis.Internal, analysis.External)
+	}
+	if analysis.Unchecked != 1 || analysis.Inaccessible != 0 {
+		t.Errorf("expected 1 unchecked and no inaccessible link, got %d and %d", analysis.Unchecked, analysis.Inaccessible)
+	}
+
+	// The pending request does not outlive the analysis
+	select {
+	case <-client.abandoned:
+	case <-time.After(time.Second):
+		t.Error("expected the slow HEAD request to be abandoned with the context")
+	}
+}
@@ -0,0 +1,212 @@
+package html_parser
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+)
+
+// Matches url(...) references and @import "..." rules in CSS
+var cssURLPattern = regexp.MustCompile(`(?i)url\(\s*['"]?([^'")]+?)['"]?\s*\)|@import\s+['"]([^'"]+)['"]`)
+
+// Link relations which make the browser fetch the referenced resource
+var fetchedLinkRels = []string{"stylesheet", "icon", "apple-touch-icon", "preload", "modulepreload", "prefetch", "manifest"}
+
+type resourceRef struct {
+	node      *html.Node
+	element   string
+	attribute string
+	url       string
+}
+
+// Collect every subresource referenced by the tree, resolved against the base URL
+func collectResources(node *html.Node, base *url.URL) []resourceRef {
+	visitor := newResourcesVisitor(base)
+	dmanl.Walk(node, visitor)
+
+	return visitor.refs
+}
+
+// Append the subresources referenced by the attributes and the style content of an element
+func appendResources(refs []resourceRef, node *html.Node, base *url.URL) []resourceRef {
+	add := func(attribute string, rawURL string) {
+		if resolved := resolveURL(strings.TrimSpace(rawURL), base); resolved != "" {
+			refs = append(refs, resourceRef{node: node, element: node.Data, attribute: attribute, url: resolved})
+		}
+	}
+
+	for _, attr := range node.Attr {
+		switch {
+		case attr.Key == "src" && isSourceElement(node):
+			add(attr.Key, attr.Val)
+		case attr.Key == "srcset" && (node.Data == "img" || node.Data == "source"):
+			for _, candidate := range parseSrcset(attr.Val) {
+				add(attr.Key, candidate)
+			}
+		case attr.Key == "poster" && node.Data == "video":
+			add(attr.Key, attr.Val)
+		case attr.Key == "data" && node.Data == "object":
+			add(attr.Key, attr.Val)
+		case attr.Key == "href" && node.Data == "link" && isFetchedLink(node):
+			add(attr.Key, attr.Val)
+		case attr.Key == "action" && node.Data == "form":
+			add(attr.Key, attr.Val)
+		case attr.Key == "style":
+			for _, cssURL := range extractCSSURLs(attr.Val) {
+				add(attr.Key, cssURL)
+			}
+		}
+	}
+
+	if node.Data == "style" {
+		for _, cssURL := range extractCSSURLs(getTextContent(node)) {
+			add("style", cssURL)
+		}
+	}
+
+	return refs
+}
+
+func isSourceElement(node *html.Node) bool {
+	switch node.Data {
+	case "img", "script", "iframe", "frame", "embed", "video", "audio", "source", "track":
+		return true
+	case "input":
+		return strings.EqualFold(getAttr(node, "type"), "image")
+	}
+
+	return false
+}
+
+func isFetchedLink(node *html.Node) bool {
+	return slices.ContainsFunc(strings.Fields(strings.ToLower(getAttr(node, "rel"))), func(rel string) bool {
+		return slices.Contains(fetchedLinkRels, rel)
+	})
+}
+
+// Extract the URLs from a srcset attribute such as "a.png 1x, b.png 2x". Candidates are parsed as the
+// HTML standard does: the URL runs up to whitespace, so it may contain commas like a data: URL, and
+// its descriptors run up to the next comma outside parentheses.
+func parseSrcset(srcset string) []string {
+	var urls []string
+
+	isSpace := func(c byte) bool { return strings.IndexByte(" \t\n\f\r", c) >= 0 }
+
+	for pos := 0; pos < len(srcset); {
+		for pos < len(srcset) && (isSpace(srcset[pos]) || srcset[pos] == ',') {
+			pos++
+		}
+
+		start := pos
+		for pos < len(srcset) && !isSpace(srcset[pos]) {
+			pos++
+		}
+		candidate := srcset[start:pos]
+
+		// Trailing commas end a candidate without descriptors
+		if trimmed := strings.TrimRight(candidate, ","); trimmed != candidate {
+			if trimmed != "" {
+				urls = append(urls, trimmed)
+			}
+			continue
+		}
+		if candidate != "" {
+			urls = append(urls, candidate)
+		}
+
+		for depth := 0; pos < len(srcset) && (srcset[pos] != ',' || depth > 0); pos++ {
+			switch srcset[pos] {
+			case '(':
+				depth++
+			case ')':
+				depth = max(depth-1, 0)
+			}
+		}
+	}
+
+	return urls
+}
+
+func extractCSSURLs(css string) []string {
+	var urls []string
+
+	for _, match := range cssURLPattern.FindAllStringSubmatch(css, -1) {
+		if match[1] != "" {
+			urls = append(urls, match[1])
+		} else if match[2] != "" {
+			urls = append(urls, match[2])
+		}
+	}
+
+	return urls
+}
+
+func getAttr(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+func hasAttr(node *html.Node, key string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Describe where an element sits in the document, e.g. "html > body > div:nth-of-type(2) > img"
+func elementPath(node *html.Node) string {
+	var parts []string
+
+	for n := node; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		if id := getAttr(n, "id"); id != "" {
+			parts = append(parts, fmt.Sprintf("%s#%s", n.Data, id))
+			break
+		}
+
+		part := n.Data
+		if index, count := siblingPosition(n); count > 1 {
+			part = fmt.Sprintf("%s:nth-of-type(%d)", n.Data, index)
+		}
+		parts = append(parts, part)
+	}
+
+	// Parts were collected from the element up to the root
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+
+	return strings.Join(parts, " > ")
+}
+
+// Position of the element among its siblings of the same tag, and the number of such siblings
+func siblingPosition(node *html.Node) (int, int) {
+	index := 1
+	for sibling := node.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+		if sibling.Type == html.ElementNode && sibling.Data == node.Data {
+			index++
+		}
+	}
+
+	count := index
+	for sibling := node.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type == html.ElementNode && sibling.Data == node.Data {
+			count++
+		}
+	}
+
+	return index, count
+}
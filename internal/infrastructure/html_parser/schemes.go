@@ -0,0 +1,163 @@
+package html_parser
+
+import (
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	dmhtml "web-pages-analyzer/internal/domain/html"
+)
+
+// Matches the scheme of an absolute URL, e.g. "mailto:" or "ftp:"
+var schemePattern = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.\-]*):`)
+
+// Matches javascript: hrefs which do nothing, e.g. "javascript:void(0)" or "javascript:;"
+var placeholderScriptPattern = regexp.MustCompile(`^\s*(void\s*\(?\s*0\s*\)?|undefined)?\s*;?\s*$`)
+
+// Visual separators allowed in tel: numbers
+var telSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "", "%20", "")
+
+const (
+	telMinDigits = 3
+	telMaxDigits = 15 // E.164
+)
+
+// An anchor whose href uses a non-HTTP scheme, located once the document was read
+type schemeLinkRef struct {
+	link dmhtml.SchemeLink
+	node *html.Node
+}
+
+// Inventory the anchors whose href uses a non-HTTP scheme
+func analyzeSchemeLinks(refs []schemeLinkRef) dmhtml.SchemeLinkAnalysis {
+	analysis := dmhtml.SchemeLinkAnalysis{
+		Counts: map[string]int{
+			dmhtml.MailtoScheme:     0,
+			dmhtml.TelScheme:        0,
+			dmhtml.JavascriptScheme: 0,
+			dmhtml.DataScheme:       0,
+			dmhtml.OtherScheme:      0,
+		},
+		Links: []dmhtml.SchemeLink{},
+	}
+
+	for _, ref := range refs {
+		link := ref.link
+		link.Location = elementPath(ref.node)
+
+		analysis.Counts[link.Category]++
+		if !link.Valid {
+			analysis.Invalid++
+		}
+		if link.Issue != "" {
+			analysis.Issues++
+		}
+		analysis.Links = append(analysis.Links, link)
+	}
+
+	return analysis
+}
+
+// Describe an anchor whose href uses a non-HTTP scheme
+func newSchemeLinkRef(node *html.Node) (schemeLinkRef, bool) {
+	href := strings.TrimSpace(getAttr(node, "href"))
+	scheme := hrefScheme(href)
+	if scheme == "" || isHTTPHref(href) {
+		return schemeLinkRef{}, false
+	}
+
+	return schemeLinkRef{link: classifySchemeLink(href, scheme), node: node}, true
+}
+
+func classifySchemeLink(href string, scheme string) dmhtml.SchemeLink {
+	link := dmhtml.SchemeLink{Href: href, Scheme: scheme, Valid: true}
+	value := href[len(scheme)+1:]
+
+	switch scheme {
+	case dmhtml.MailtoScheme:
+		link.Category = dmhtml.MailtoScheme
+		link.Issue = validateMailto(value)
+		link.Valid = link.Issue == ""
+	case dmhtml.TelScheme:
+		link.Category = dmhtml.TelScheme
+		link.Issue = validateTel(value)
+		link.Valid = link.Issue == ""
+	case dmhtml.JavascriptScheme:
+		link.Category = dmhtml.JavascriptScheme
+		if placeholderScriptPattern.MatchString(value) {
+			link.Issue = "placeholder link, use a <button> for actions"
+		}
+	case dmhtml.DataScheme:
+		link.Category = dmhtml.DataScheme
+		if !strings.Contains(value, ",") {
+			link.Valid, link.Issue = false, "malformed data URL"
+		} else {
+			link.Issue = "browsers block top-level navigation to data URLs"
+		}
+	default:
+		link.Category = dmhtml.OtherScheme
+	}
+
+	return link
+}
+
+// Validate the recipients of a mailto: link, e.g. "a@example.com,b@example.com?subject=Hi"
+func validateMailto(value string) string {
+	recipients, _, _ := strings.Cut(value, "?")
+	if strings.TrimSpace(recipients) == "" {
+		return "missing recipient"
+	}
+
+	for _, recipient := range strings.Split(recipients, ",") {
+		address, err := url.PathUnescape(strings.TrimSpace(recipient))
+		if err != nil {
+			return "malformed recipient"
+		}
+		if _, err = mail.ParseAddress(address); err != nil {
+			return "invalid email address " + address
+		}
+	}
+
+	return ""
+}
+
+// Validate a tel: number such as "+1 (555) 010-0000", parameters like ";ext=1" are ignored
+func validateTel(value string) string {
+	number, _, _ := strings.Cut(value, ";")
+	number = strings.TrimPrefix(telSeparators.Replace(number), "+")
+
+	if number == "" {
+		return "missing phone number"
+	}
+
+	for _, c := range number {
+		if c < '0' || c > '9' {
+			return "phone number contains invalid characters"
+		}
+	}
+
+	if len(number) < telMinDigits || len(number) > telMaxDigits {
+		return "phone number has an invalid length"
+	}
+
+	return ""
+}
+
+// Lowercase scheme of an absolute href, empty for relative hrefs
+func hrefScheme(href string) string {
+	match := schemePattern.FindStringSubmatch(strings.TrimSpace(href))
+	if match == nil {
+		return ""
+	}
+
+	return strings.ToLower(match[1])
+}
+
+// Relative hrefs resolve against the page and navigate over HTTP(S)
+func isHTTPHref(href string) bool {
+	scheme := hrefScheme(href)
+	return scheme == "" || scheme == "http" || scheme == "https"
+}
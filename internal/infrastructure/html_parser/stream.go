@@ -0,0 +1,333 @@
+package html_parser
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+)
+
+// Elements built whole while streaming, their visitors read the text inside them
+var streamedSubtrees = map[string]bool{
+	"title": true, "a": true, "button": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// Text and markup kept for one subtree, in bytes. An element left open, such as an unclosed link, would
+// otherwise keep the rest of the page in memory: past the limit it is walked as if it was closed.
+const maxSubtreeBytes = 64 << 10
+
+// Elements which have no content and no end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"keygen": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// Elements whose end tag is commonly left out, a sibling of the same tag closes them
+var impliedEndElements = map[string]bool{
+	"p": true, "li": true, "dt": true, "dd": true, "option": true, "tr": true, "td": true, "th": true,
+}
+
+type streamer struct {
+	parser   *parser
+	visitors []dmanl.Visitor
+	open     []*html.Node                          // Open elements outside of the subtree being built, the document node first
+	subtree  *html.Node                            // Root of the subtree being built
+	current  *html.Node                            // Innermost open element of the subtree being built
+	buffered int                                   // Bytes of text and markup in the subtree being built
+	form     *html.Node                            // Open form, holds copies of the fields, buttons and captcha widgets inside it
+	siblings map[*html.Node]map[string]*siblingRun // Runs of children of each open element, by tag
+	started  bool                                  // Content was read, a DOCTYPE is no longer expected
+	baseSeen bool
+}
+
+// The last element of a tag among the children of an open element, and the stand-in which counts it
+type siblingRun struct {
+	last    *html.Node
+	standIn *html.Node
+}
+
+// Read the document token by token and hand its nodes to the visitors without building its tree.
+// Nodes are detached: their parent is the innermost open element, but they are not its children.
+// Elements are linked to stand-ins of their siblings of the same tag, so their location can be told.
+// Titles, links, buttons and headings are built whole and walked once they are closed, or once they hold
+// maxSubtreeBytes. A form only keeps copies
+// of the fields, buttons and captcha widgets inside it, which is what the form analysis reads.
+func (p *parser) stream(body io.Reader, visitors ...dmanl.Visitor) error {
+	document := &html.Node{Type: html.DocumentNode}
+	s := &streamer{
+		parser:   p,
+		visitors: visitors,
+		open:     []*html.Node{document},
+		siblings: make(map[*html.Node]map[string]*siblingRun),
+	}
+	s.enter(document)
+
+	tokenizer := html.NewTokenizer(body)
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				return err
+			}
+			break
+		}
+
+		token := tokenizer.Token()
+		switch tokenType {
+		case html.DoctypeToken:
+			if !s.started {
+				s.leaf(parseDoctype(token.Data))
+			}
+		case html.TextToken:
+			s.started = s.started || strings.TrimSpace(token.Data) != ""
+			s.leaf(&html.Node{Type: html.TextNode, Data: token.Data})
+		case html.CommentToken:
+			s.leaf(&html.Node{Type: html.CommentNode, Data: token.Data})
+		case html.StartTagToken, html.SelfClosingTagToken:
+			s.started = true
+			s.start(token, tokenType == html.SelfClosingTagToken)
+		case html.EndTagToken:
+			s.end(token.Data)
+		}
+	}
+
+	s.closeSubtree()
+	for len(s.open) > 0 {
+		s.pop()
+	}
+
+	return nil
+}
+
+func (s *streamer) start(token html.Token, selfClosing bool) {
+	node := &html.Node{Type: html.ElementNode, Data: token.Data, DataAtom: token.DataAtom, Attr: token.Attr}
+	void := selfClosing || voidElements[node.Data]
+
+	if node.Data == "form" && (s.form != nil || s.within("form")) {
+		// Nested forms are dropped, as the HTML parser does
+		return
+	}
+	if node.Data == "a" && s.within("a") {
+		// A link closes the link it is nested in
+		s.end("a")
+	}
+	if headingElements[node.Data] && s.current != nil && headingElements[s.current.Data] {
+		// A heading closes the heading it is directly nested in
+		s.end(s.current.Data)
+	}
+
+	if s.subtree != nil {
+		s.current.AppendChild(node)
+		if !void {
+			s.current = node
+		}
+		s.buffer(node)
+		return
+	}
+
+	if node.Data == "base" && !s.baseSeen && hasAttr(node, "href") {
+		s.baseSeen = true
+		s.parser.docBaseUrl = resolveBase(getAttr(node, "href"), s.parser.baseUrl)
+	}
+
+	if impliedEndElements[node.Data] && s.top().Data == node.Data {
+		s.pop()
+	}
+
+	node.Parent = s.top()
+	s.linkSibling(node)
+
+	if streamedSubtrees[node.Data] && !void {
+		s.subtree, s.current = node, node
+		return
+	}
+
+	s.enter(node)
+	s.keepInForm(node)
+	if void {
+		s.leave(node)
+		return
+	}
+	s.open = append(s.open, node)
+	if node.Data == "form" {
+		s.form = node
+	}
+}
+
+// Link the element to a stand-in of the previous sibling of the same tag, and the previous sibling to
+// a stand-in of the element, which is what its position among its siblings is computed from
+func (s *streamer) linkSibling(node *html.Node) {
+	runs := s.siblings[node.Parent]
+	if runs == nil {
+		runs = make(map[string]*siblingRun)
+		s.siblings[node.Parent] = runs
+	}
+
+	standIn := &html.Node{Type: html.ElementNode, Data: node.Data}
+	if run := runs[node.Data]; run != nil {
+		node.PrevSibling = run.standIn
+		standIn.PrevSibling = run.standIn
+		run.standIn.NextSibling = standIn
+		run.last.NextSibling = standIn
+	}
+	runs[node.Data] = &siblingRun{last: node, standIn: standIn}
+}
+
+// Copy into the open form the elements of its subtree the form analysis reads
+func (s *streamer) keepInForm(node *html.Node) {
+	if s.form == nil || node.Type != html.ElementNode {
+		return
+	}
+
+	switch {
+	case node.Data == "input" || node.Data == "select" || node.Data == "textarea" || isCaptchaWidget(node):
+		s.form.AppendChild(&html.Node{Type: html.ElementNode, Data: node.Data, DataAtom: node.DataAtom, Attr: node.Attr})
+	case node.Data == "button":
+		button := &html.Node{Type: html.ElementNode, Data: node.Data, DataAtom: node.DataAtom, Attr: node.Attr}
+		button.AppendChild(&html.Node{Type: html.TextNode, Data: getTextContent(node)})
+		s.form.AppendChild(button)
+	}
+}
+
+func (s *streamer) end(name string) {
+	if s.subtree != nil {
+		for node := s.current; ; node = node.Parent {
+			// The end tag of any heading closes the open heading
+			if node.Data == name || (headingElements[name] && headingElements[node.Data]) {
+				if node == s.subtree {
+					s.closeSubtree()
+				} else {
+					s.current = node.Parent
+				}
+				return
+			}
+			if node == s.subtree {
+				break
+			}
+		}
+
+		// The end tag of an element open around the subtree closes the subtree as well
+		if !s.isOpen(name) {
+			return
+		}
+		s.closeSubtree()
+	}
+
+	for i := len(s.open) - 1; i > 0; i-- {
+		if s.open[i].Data == name {
+			for len(s.open) > i {
+				s.pop()
+			}
+			return
+		}
+	}
+}
+
+// Hand a node without children to the visitors, or add it to the subtree being built
+func (s *streamer) leaf(node *html.Node) {
+	if s.subtree != nil {
+		s.current.AppendChild(node)
+		s.buffer(node)
+		return
+	}
+
+	node.Parent = s.top()
+	s.enter(node)
+	s.leave(node)
+}
+
+// Count a node added to the subtree, which is closed once it holds too much
+func (s *streamer) buffer(node *html.Node) {
+	s.buffered += len(node.Data)
+	for _, attr := range node.Attr {
+		s.buffered += len(attr.Key) + len(attr.Val)
+	}
+
+	if s.buffered > maxSubtreeBytes {
+		s.closeSubtree()
+	}
+}
+
+func (s *streamer) closeSubtree() {
+	if s.subtree == nil {
+		return
+	}
+
+	subtree := s.subtree
+	s.subtree, s.current, s.buffered = nil, nil, 0
+	dmanl.Walk(subtree, s.visitors...)
+	if s.form != nil {
+		s.keepSubtreeInForm(subtree)
+	}
+}
+
+func (s *streamer) keepSubtreeInForm(node *html.Node) {
+	s.keepInForm(node)
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		s.keepSubtreeInForm(child)
+	}
+}
+
+func (s *streamer) within(name string) bool {
+	for node := s.current; node != nil; node = node.Parent {
+		if node.Data == name {
+			return true
+		}
+		if node == s.subtree {
+			break
+		}
+	}
+
+	return false
+}
+
+func (s *streamer) isOpen(name string) bool {
+	for _, node := range s.open[1:] {
+		if node.Data == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *streamer) top() *html.Node {
+	return s.open[len(s.open)-1]
+}
+
+func (s *streamer) pop() {
+	node := s.top()
+	s.open = s.open[:len(s.open)-1]
+	delete(s.siblings, node)
+	if node == s.form {
+		s.form = nil
+	}
+	s.leave(node)
+}
+
+func (s *streamer) enter(node *html.Node) {
+	for _, visitor := range s.visitors {
+		visitor.Enter(node)
+	}
+}
+
+func (s *streamer) leave(node *html.Node) {
+	for _, visitor := range s.visitors {
+		visitor.Leave(node)
+	}
+}
+
+// The DOCTYPE node the HTML parser makes of a DOCTYPE token, with its public and system identifiers
+func parseDoctype(data string) *html.Node {
+	document, err := html.Parse(strings.NewReader("<!DOCTYPE " + data + ">"))
+	if err == nil {
+		if doctype := findDoctype(document); doctype != nil {
+			document.RemoveChild(doctype)
+			return doctype
+		}
+	}
+
+	return &html.Node{Type: html.DoctypeNode, Data: strings.ToLower(data)}
+}
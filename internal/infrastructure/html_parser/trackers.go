@@ -0,0 +1,120 @@
+package html_parser
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	dmtrk "web-pages-analyzer/internal/domain/tracker"
+)
+
+// Ids and classes used by consent management platforms and hand-rolled cookie banners
+var consentMarkers = []string{
+	"cookie-consent", "cookieconsent", "cookie-banner", "cookie-notice", "cookie-bar", "cookie-law",
+	"consent-banner", "gdpr", "onetrust", "cybotcookiebotdialog", "usercentrics", "didomi", "truste", "cky-consent",
+}
+
+// Link relations which open a connection to another host without fetching a resource
+var connectionLinkRels = []string{"preconnect", "dns-prefetch"}
+
+// Collect the third-party requests the page makes and the elements which look like a consent banner
+func (p *parser) trackerEvidence(resources *resourcesVisitor, trackers *trackersVisitor) *dmtrk.Evidence {
+	evidence := &dmtrk.Evidence{Requests: []dmtrk.Request{}, ConsentElements: []string{}}
+
+	add := func(rawURL string, kind string) {
+		requestURL, err := url.Parse(rawURL)
+		if err != nil || (requestURL.Scheme != "http" && requestURL.Scheme != "https") ||
+			p.classifier.isFirstParty(requestURL) {
+			return
+		}
+		evidence.Requests = append(evidence.Requests, dmtrk.Request{
+			URL:  rawURL,
+			Host: strings.ToLower(requestURL.Hostname()),
+			Kind: kind,
+		})
+	}
+
+	for _, ref := range resources.refs {
+		if kind := requestKind(ref, false); kind != "" {
+			add(ref.url, kind)
+		}
+	}
+
+	// <noscript> content is kept as text by the parser, tracking pixels often hide there
+	for _, noscript := range trackers.noscripts {
+		fragment, err := html.ParseFragment(strings.NewReader(getTextContent(noscript)),
+			&html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+		if err != nil {
+			continue
+		}
+		for _, node := range fragment {
+			for _, ref := range collectResources(node, p.docBaseUrl) {
+				if kind := requestKind(ref, true); kind != "" {
+					add(ref.url, kind)
+				}
+			}
+		}
+	}
+
+	for _, link := range trackers.connectionLinks {
+		if resolved := resolveURL(strings.TrimSpace(getAttr(link, "href")), p.docBaseUrl); resolved != "" {
+			add(resolved, dmtrk.LinkRequest)
+		}
+	}
+
+	evidence.ConsentElements = append(evidence.ConsentElements, trackers.consentElements...)
+
+	return evidence
+}
+
+// How an element contacts the host it references, empty when it is not tracked
+func requestKind(ref resourceRef, inNoscript bool) string {
+	switch ref.element {
+	case "script":
+		return dmtrk.ScriptRequest
+	case "iframe", "frame":
+		return dmtrk.IframeRequest
+	case "img", "input", "source":
+		if ref.attribute == "style" {
+			return ""
+		}
+		if inNoscript || isPixel(ref.node) {
+			return dmtrk.PixelRequest
+		}
+		return dmtrk.ImageRequest
+	case "link":
+		return dmtrk.LinkRequest
+	}
+
+	return ""
+}
+
+// Invisible images such as 1x1 tracking pixels
+func isPixel(node *html.Node) bool {
+	if isHiddenByStyle(node) {
+		return true
+	}
+
+	width, widthErr := strconv.Atoi(strings.TrimSpace(getAttr(node, "width")))
+	height, heightErr := strconv.Atoi(strings.TrimSpace(getAttr(node, "height")))
+
+	return widthErr == nil && heightErr == nil && width <= 1 && height <= 1
+}
+
+// Whether an id or class of the node names a consent banner. Markers match whole words of the tokens,
+// so "gdpr-banner" is marked while "trusted-badge" is not marked by "truste".
+func isConsentMarked(node *html.Node) bool {
+	for _, token := range strings.Fields(strings.ToLower(getAttr(node, "id") + " " + getAttr(node, "class"))) {
+		words := "-" + strings.ReplaceAll(token, "_", "-") + "-"
+		for _, marker := range consentMarkers {
+			if strings.Contains(words, "-"+marker+"-") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,353 @@
+package html_parser
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+)
+
+// Visitors of the HTML analyzers, shared through the document under their keys
+var (
+	doctypeVisitorSpec = dmanl.VisitorSpec{Key: "html_parser.doctype", Streaming: true, New: func(doc *dmanl.Document) dmanl.Visitor {
+		return &doctypeVisitor{}
+	}}
+	titleVisitorSpec = dmanl.VisitorSpec{Key: "html_parser.title", Streaming: true, New: func(doc *dmanl.Document) dmanl.Visitor {
+		return &titleVisitor{}
+	}}
+	headingsVisitorSpec = dmanl.VisitorSpec{Key: "html_parser.headings", Streaming: true, New: func(doc *dmanl.Document) dmanl.Visitor {
+		return newHeadingsVisitor()
+	}}
+	linksVisitorSpec = dmanl.VisitorSpec{Key: "html_parser.links", Streaming: true, New: func(doc *dmanl.Document) dmanl.Visitor {
+		return parserOf(doc).newLinksVisitor()
+	}}
+	formsVisitorSpec = dmanl.VisitorSpec{Key: "html_parser.forms", Streaming: true, New: func(doc *dmanl.Document) dmanl.Visitor {
+		return newFormsVisitor()
+	}}
+	resourcesVisitorSpec = dmanl.VisitorSpec{Key: "html_parser.resources", New: func(doc *dmanl.Document) dmanl.Visitor {
+		return newResourcesVisitor(parserOf(doc).docBaseUrl)
+	}}
+	trackersVisitorSpec = dmanl.VisitorSpec{Key: "html_parser.trackers", New: func(doc *dmanl.Document) dmanl.Visitor {
+		return &trackersVisitor{}
+	}}
+	fingerprintVisitorSpec = dmanl.VisitorSpec{Key: "html_parser.fingerprint", New: func(doc *dmanl.Document) dmanl.Visitor {
+		return newFingerprintVisitor()
+	}}
+	textVisitorSpec = dmanl.VisitorSpec{Key: "html_parser.text", New: func(doc *dmanl.Document) dmanl.Visitor {
+		return &textVisitor{}
+	}}
+)
+
+// Visitor of the document read back with its concrete type
+func visitorOf[V dmanl.Visitor](doc *dmanl.Document, spec dmanl.VisitorSpec) V {
+	return doc.Visitor(spec).(V)
+}
+
+// Walk the document for visitors outside of a shared traversal
+func (p *parser) walk(visitors ...dmanl.Visitor) {
+	dmanl.Walk(p.node, visitors...)
+}
+
+// Embedded by visitors which only look at nodes on the way down
+type enterOnly struct{}
+
+func (enterOnly) Leave(*html.Node) {}
+
+// The DOCTYPE of the document
+type doctypeVisitor struct {
+	enterOnly
+	doctype *html.Node
+}
+
+func (v *doctypeVisitor) Enter(node *html.Node) {
+	if v.doctype == nil && node.Type == html.DoctypeNode {
+		v.doctype = node
+	}
+}
+
+// Text of the first non-empty title element
+type titleVisitor struct {
+	enterOnly
+	title string
+}
+
+func (v *titleVisitor) Enter(node *html.Node) {
+	if v.title == "" && node.Type == html.ElementNode && node.Data == "title" {
+		v.title = getTextContent(node)
+	}
+}
+
+// Heading elements of every level
+var headingElements = map[string]bool{"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true}
+
+// Number of headings of each level, and the outline they make
+type headingsVisitor struct {
+	enterOnly
+	counts  map[string]int
+	outline []dmhtml.Heading
+}
+
+func newHeadingsVisitor() *headingsVisitor {
+	return &headingsVisitor{
+		counts:  map[string]int{"h1": 0, "h2": 0, "h3": 0, "h4": 0, "h5": 0, "h6": 0},
+		outline: []dmhtml.Heading{},
+	}
+}
+
+func (v *headingsVisitor) Enter(node *html.Node) {
+	if node.Type == html.ElementNode && headingElements[node.Data] {
+		v.counts[node.Data]++
+		v.outline = append(v.outline, dmhtml.Heading{
+			Level: node.Data,
+			Text:  strings.Join(strings.Fields(getTextContent(node)), " "),
+		})
+	}
+}
+
+// Navigable links, fragment links and non-HTTP links of the anchors, and the ids they can point at
+type linksVisitor struct {
+	enterOnly
+	parser      *parser
+	links       []dmhtml.Link
+	fragments   []fragmentRef
+	schemeLinks []schemeLinkRef
+	anchors     map[string]bool
+}
+
+func (p *parser) newLinksVisitor() *linksVisitor {
+	return &linksVisitor{parser: p, anchors: make(map[string]bool)}
+}
+
+func (v *linksVisitor) Enter(node *html.Node) {
+	if node.Type != html.ElementNode {
+		return
+	}
+
+	addAnchors(node, v.anchors)
+
+	if node.Data != "a" {
+		return
+	}
+
+	// The base URL of a streamed document is only known once its <base> element was read
+	base := v.parser.docBaseUrl
+
+	if href := getAttr(node, "href"); href != "" && isHTTPHref(href) {
+		if resolvedURL := resolveURL(href, base); resolvedURL != "" {
+			v.links = append(v.links, newLink(node, resolvedURL))
+		}
+	}
+
+	if ref, ok := newFragmentRef(node, base, v.parser.baseUrl); ok {
+		v.fragments = append(v.fragments, ref)
+	}
+
+	if ref, ok := newSchemeLinkRef(node); ok {
+		v.schemeLinks = append(v.schemeLinks, ref)
+	}
+}
+
+// Forms, the fields associated with them from outside and the page-level login signals
+type formsVisitor struct {
+	forms         []*html.Node
+	associated    map[string][]*html.Node
+	barePassword  *html.Node
+	ssoProvider   string
+	iframes       []*html.Node
+	openFormCount int
+}
+
+func newFormsVisitor() *formsVisitor {
+	return &formsVisitor{associated: make(map[string][]*html.Node)}
+}
+
+func (v *formsVisitor) Enter(node *html.Node) {
+	if node.Type != html.ElementNode {
+		return
+	}
+
+	switch node.Data {
+	case "form":
+		v.forms = append(v.forms, node)
+		v.openFormCount++
+	case "input", "select", "textarea":
+		if v.openFormCount > 0 {
+			return
+		}
+		// Fields can be placed outside a form and associated with it through the form attribute
+		if formID := getAttr(node, "form"); formID != "" {
+			v.associated[formID] = append(v.associated[formID], node)
+		}
+		if v.barePassword == nil && node.Data == "input" && strings.EqualFold(getAttr(node, "type"), "password") {
+			v.barePassword = node
+		}
+	case "a", "button":
+		if v.ssoProvider == "" {
+			v.ssoProvider = ssoProviderOf(node)
+		}
+	case "iframe":
+		v.iframes = append(v.iframes, node)
+	}
+}
+
+func (v *formsVisitor) Leave(node *html.Node) {
+	if node.Type == html.ElementNode && node.Data == "form" {
+		v.openFormCount--
+	}
+}
+
+// Subresources referenced by the document and its inline scripts and styles
+type resourcesVisitor struct {
+	enterOnly
+	base   *url.URL
+	refs   []resourceRef
+	inline []dmhtml.Resource
+}
+
+func newResourcesVisitor(base *url.URL) *resourcesVisitor {
+	return &resourcesVisitor{base: base}
+}
+
+func (v *resourcesVisitor) Enter(node *html.Node) {
+	if node.Type != html.ElementNode {
+		return
+	}
+
+	v.refs = appendResources(v.refs, node, v.base)
+
+	if resource, ok := inlineResource(node); ok {
+		v.inline = append(v.inline, resource)
+	}
+}
+
+// Elements which reveal trackers besides the subresources: <noscript> content, connection hints and
+// the outermost elements of consent banners
+type trackersVisitor struct {
+	noscripts       []*html.Node
+	connectionLinks []*html.Node
+	consentElements []string
+	insideConsent   *html.Node
+}
+
+func (v *trackersVisitor) Enter(node *html.Node) {
+	if node.Type != html.ElementNode {
+		return
+	}
+
+	switch node.Data {
+	case "noscript":
+		v.noscripts = append(v.noscripts, node)
+	case "link":
+		for _, rel := range strings.Fields(strings.ToLower(getAttr(node, "rel"))) {
+			if slices.Contains(connectionLinkRels, rel) {
+				v.connectionLinks = append(v.connectionLinks, node)
+				break
+			}
+		}
+	}
+
+	if v.insideConsent != nil {
+		return
+	}
+
+	if isConsentMarked(node) {
+		v.consentElements = append(v.consentElements, elementPath(node))
+		v.insideConsent = node
+	}
+}
+
+func (v *trackersVisitor) Leave(node *html.Node) {
+	if node == v.insideConsent {
+		v.insideConsent = nil
+	}
+}
+
+// Meta tags, inline scripts and class names technology signatures match against
+type fingerprintVisitor struct {
+	enterOnly
+	meta          map[string][]string
+	inlineScripts []string
+	classes       map[string]bool
+}
+
+func newFingerprintVisitor() *fingerprintVisitor {
+	return &fingerprintVisitor{meta: map[string][]string{}, classes: make(map[string]bool)}
+}
+
+func (v *fingerprintVisitor) Enter(node *html.Node) {
+	if node.Type != html.ElementNode {
+		return
+	}
+
+	switch node.Data {
+	case "meta":
+		if name := strings.ToLower(getAttr(node, "name")); name != "" {
+			v.meta[name] = append(v.meta[name], getAttr(node, "content"))
+		}
+	case "script":
+		if script := strings.TrimSpace(getTextContent(node)); script != "" && !hasAttr(node, "src") {
+			v.inlineScripts = append(v.inlineScripts, script)
+		}
+	}
+
+	for _, class := range strings.Fields(getAttr(node, "class")) {
+		v.classes[class] = true
+	}
+}
+
+// Rendered text of the document, one whitespace-normalized entry per block of text
+type textVisitor struct {
+	blocks  []string
+	current strings.Builder
+	skipped *html.Node
+	depth   int
+}
+
+func (v *textVisitor) Enter(node *html.Node) {
+	v.depth++
+	if v.skipped != nil {
+		return
+	}
+
+	switch node.Type {
+	case html.TextNode:
+		v.current.WriteString(node.Data)
+	case html.ElementNode:
+		if nonTextElements[node.Data] || isHiddenElement(node) {
+			v.skipped = node
+			return
+		}
+		if blockElements[node.Data] {
+			v.flush()
+		}
+	}
+}
+
+func (v *textVisitor) Leave(node *html.Node) {
+	v.depth--
+
+	switch {
+	case node == v.skipped:
+		v.skipped = nil
+	case v.skipped != nil:
+		return
+	case node.Type == html.ElementNode && blockElements[node.Data]:
+		v.flush()
+	}
+
+	// The text after the last block element ends with the walk
+	if v.depth == 0 {
+		v.flush()
+	}
+}
+
+func (v *textVisitor) flush() {
+	if text := strings.Join(strings.Fields(v.current.String()), " "); text != "" {
+		v.blocks = append(v.blocks, text)
+	}
+	v.current.Reset()
+}
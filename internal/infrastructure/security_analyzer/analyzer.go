@@ -0,0 +1,332 @@
+package security_analyzer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	dmsec "web-pages-analyzer/internal/domain/security"
+	utlstr "web-pages-analyzer/internal/utils/string"
+)
+
+const (
+	hstsMinMaxAge     = 180 * 24 * 60 * 60 // 180 days in seconds
+	certExpiryWarning = 30                 // days
+)
+
+// Weight of each check in the overall score, the weights add up to 100
+const (
+	hstsWeight              = 20
+	cspWeight               = 25
+	frameOptionsWeight      = 15
+	contentTypeWeight       = 10
+	referrerPolicyWeight    = 10
+	permissionsPolicyWeight = 5
+	cookiesWeight           = 5
+	tlsWeight               = 10
+)
+
+type securityAnalyzer struct {
+	now func() time.Time
+}
+
+func New() dmsec.SecurityAnalyzer {
+	return &securityAnalyzer{now: time.Now}
+}
+
+func (sa *securityAnalyzer) Analyze(resp *http.Response) *dmsec.SecurityAnalysis {
+	isHttps := resp.Request != nil && resp.Request.URL.Scheme == "https"
+	header := resp.Header
+
+	headers := []dmsec.HeaderCheck{
+		checkHSTS(header, isHttps),
+		checkCSP(header),
+		checkFrameOptions(header),
+		checkContentTypeOptions(header),
+		checkReferrerPolicy(header),
+		checkPermissionsPolicy(header),
+	}
+	weights := []int{
+		hstsWeight,
+		cspWeight,
+		frameOptionsWeight,
+		contentTypeWeight,
+		referrerPolicyWeight,
+		permissionsPolicyWeight,
+	}
+
+	score := 0
+	for i, check := range headers {
+		score += weightedScore(check.Status, weights[i])
+	}
+
+	cookies, cookiesStatus := checkCookies(resp.Cookies(), isHttps)
+	score += weightedScore(cookiesStatus, cookiesWeight)
+
+	tlsAnalysis := sa.checkTLS(resp.TLS)
+	if tlsAnalysis != nil {
+		score += weightedScore(tlsAnalysis.Status, tlsWeight)
+	}
+
+	return &dmsec.SecurityAnalysis{
+		Score:   score,
+		Grade:   grade(score),
+		Headers: headers,
+		Cookies: cookies,
+		TLS:     tlsAnalysis,
+	}
+}
+
+func checkHSTS(header http.Header, isHttps bool) dmsec.HeaderCheck {
+	check := newHeaderCheck(header, "Strict-Transport-Security")
+
+	switch {
+	case !isHttps:
+		check.Status, check.Message = dmsec.StatusFail, "page is not served over HTTPS"
+	case !check.Present:
+		check.Status, check.Message = dmsec.StatusFail, "header is missing"
+	default:
+		maxAge, ok := directiveValue(check.Value, "max-age")
+		age, err := strconv.Atoi(strings.Trim(maxAge, `"`))
+		if !ok || err != nil {
+			check.Status, check.Message = dmsec.StatusFail, "max-age directive is missing or invalid"
+		} else if age < hstsMinMaxAge {
+			check.Status, check.Message = dmsec.StatusWarn, "max-age is shorter than 180 days"
+		} else {
+			check.Status, check.Message = dmsec.StatusPass, "HSTS is enabled"
+		}
+	}
+
+	return check
+}
+
+func checkCSP(header http.Header) dmsec.HeaderCheck {
+	check := newHeaderCheck(header, "Content-Security-Policy")
+
+	if !check.Present {
+		if header.Get("Content-Security-Policy-Report-Only") != "" {
+			check.Status, check.Message = dmsec.StatusWarn, "policy is only reported, not enforced"
+		} else {
+			check.Status, check.Message = dmsec.StatusFail, "header is missing"
+		}
+		return check
+	}
+
+	policy := strings.ToLower(check.Value)
+	if utlstr.ContainsAnySubstring(policy, "'unsafe-inline'", "'unsafe-eval'") {
+		check.Status, check.Message = dmsec.StatusWarn, "policy allows unsafe-inline or unsafe-eval"
+		return check
+	}
+
+	check.Status, check.Message = dmsec.StatusPass, "policy is enforced"
+	return check
+}
+
+func checkFrameOptions(header http.Header) dmsec.HeaderCheck {
+	check := newHeaderCheck(header, "X-Frame-Options")
+
+	_, hasFrameAncestors := directiveValue(header.Get("Content-Security-Policy"), "frame-ancestors")
+
+	switch value := strings.ToUpper(check.Value); {
+	case value == "DENY" || value == "SAMEORIGIN":
+		check.Status, check.Message = dmsec.StatusPass, "framing is restricted"
+	case hasFrameAncestors:
+		check.Status, check.Message = dmsec.StatusPass, "framing is restricted by CSP frame-ancestors"
+	case check.Present:
+		check.Status, check.Message = dmsec.StatusWarn, "unsupported value"
+	default:
+		check.Status, check.Message = dmsec.StatusFail, "header is missing"
+	}
+
+	return check
+}
+
+func checkContentTypeOptions(header http.Header) dmsec.HeaderCheck {
+	check := newHeaderCheck(header, "X-Content-Type-Options")
+
+	switch {
+	case strings.EqualFold(check.Value, "nosniff"):
+		check.Status, check.Message = dmsec.StatusPass, "MIME sniffing is disabled"
+	case check.Present:
+		check.Status, check.Message = dmsec.StatusFail, "value must be nosniff"
+	default:
+		check.Status, check.Message = dmsec.StatusFail, "header is missing"
+	}
+
+	return check
+}
+
+func checkReferrerPolicy(header http.Header) dmsec.HeaderCheck {
+	check := newHeaderCheck(header, "Referrer-Policy")
+
+	// The last supported policy in the list wins
+	policies := strings.Split(strings.ToLower(check.Value), ",")
+	policy := strings.TrimSpace(policies[len(policies)-1])
+
+	switch policy {
+	case "no-referrer", "same-origin", "strict-origin", "strict-origin-when-cross-origin":
+		check.Status, check.Message = dmsec.StatusPass, "referrer leakage is limited"
+	case "unsafe-url":
+		check.Status, check.Message = dmsec.StatusFail, "full URL is sent to every origin"
+	case "":
+		check.Status, check.Message = dmsec.StatusWarn, "header is missing, browser default applies"
+	default:
+		check.Status, check.Message = dmsec.StatusWarn, "policy may leak referrer information"
+	}
+
+	return check
+}
+
+func checkPermissionsPolicy(header http.Header) dmsec.HeaderCheck {
+	check := newHeaderCheck(header, "Permissions-Policy")
+
+	if check.Present {
+		check.Status, check.Message = dmsec.StatusPass, "browser features are restricted"
+	} else {
+		check.Status, check.Message = dmsec.StatusWarn, "header is missing"
+	}
+
+	return check
+}
+
+func checkCookies(cookies []*http.Cookie, isHttps bool) ([]dmsec.CookieCheck, string) {
+	checks := []dmsec.CookieCheck{}
+	status := dmsec.StatusPass
+
+	for _, cookie := range cookies {
+		check := dmsec.CookieCheck{
+			Name:     cookie.Name,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: sameSiteName(cookie.SameSite),
+			Issues:   []string{},
+		}
+
+		if isHttps && !cookie.Secure {
+			check.Issues = append(check.Issues, "missing Secure flag")
+			status = dmsec.StatusFail
+		}
+
+		if !cookie.HttpOnly {
+			check.Issues = append(check.Issues, "missing HttpOnly flag")
+		}
+
+		switch {
+		case cookie.SameSite == 0 || cookie.SameSite == http.SameSiteDefaultMode:
+			check.Issues = append(check.Issues, "missing SameSite attribute")
+		case cookie.SameSite == http.SameSiteNoneMode && !cookie.Secure:
+			check.Issues = append(check.Issues, "SameSite=None requires the Secure flag")
+		}
+
+		if len(check.Issues) > 0 && status == dmsec.StatusPass {
+			status = dmsec.StatusWarn
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks, status
+}
+
+func (sa *securityAnalyzer) checkTLS(state *tls.ConnectionState) *dmsec.TLSAnalysis {
+	if state == nil {
+		return nil
+	}
+
+	analysis := &dmsec.TLSAnalysis{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		Status:      dmsec.StatusPass,
+		Issues:      []string{},
+	}
+
+	if state.Version < tls.VersionTLS12 {
+		analysis.Issues = append(analysis.Issues, fmt.Sprintf("%s is deprecated", analysis.Version))
+		analysis.Status = dmsec.StatusFail
+	}
+
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		analysis.Issuer = cert.Issuer.String()
+		analysis.Subject = cert.Subject.String()
+		analysis.NotAfter = cert.NotAfter
+		analysis.DaysUntilExpiry = int(cert.NotAfter.Sub(sa.now()).Hours() / 24)
+
+		switch {
+		case analysis.DaysUntilExpiry < 0:
+			analysis.Issues = append(analysis.Issues, "certificate has expired")
+			analysis.Status = dmsec.StatusFail
+		case analysis.DaysUntilExpiry < certExpiryWarning:
+			analysis.Issues = append(analysis.Issues, "certificate expires within 30 days")
+			if analysis.Status == dmsec.StatusPass {
+				analysis.Status = dmsec.StatusWarn
+			}
+		}
+	}
+
+	return analysis
+}
+
+func newHeaderCheck(header http.Header, name string) dmsec.HeaderCheck {
+	value := strings.TrimSpace(header.Get(name))
+	return dmsec.HeaderCheck{
+		Name:    name,
+		Present: value != "",
+		Value:   value,
+	}
+}
+
+// Find a directive such as "max-age=31536000" in a ";" separated header value
+func directiveValue(value string, directive string) (string, bool) {
+	for _, part := range strings.Split(value, ";") {
+		name, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		name, _, _ = strings.Cut(name, " ")
+		if strings.EqualFold(name, directive) {
+			return strings.TrimSpace(val), true
+		}
+	}
+
+	return "", false
+}
+
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	default:
+		return ""
+	}
+}
+
+func weightedScore(status string, weight int) int {
+	switch status {
+	case dmsec.StatusPass:
+		return weight
+	case dmsec.StatusWarn:
+		return weight / 2
+	default:
+		return 0
+	}
+}
+
+func grade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
@@ -0,0 +1,220 @@
+package security_analyzer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	dmsec "web-pages-analyzer/internal/domain/security"
+)
+
+var fixedNow = time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+func newResponse(rawURL string, header http.Header) *http.Response {
+	parsedURL, _ := url.Parse(rawURL)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Request:    &http.Request{URL: parsedURL},
+	}
+}
+
+func Test_Analyze_Headers(t *testing.T) {
+	tests := []struct {
+		name             string
+		url              string
+		header           http.Header
+		expectedStatuses map[string]string
+		expectedGrade    string
+	}{
+		{
+			name: "all headers hardened",
+			url:  "https://example.com",
+			header: http.Header{
+				"Strict-Transport-Security": []string{"max-age=31536000; includeSubDomains"},
+				"Content-Security-Policy":   []string{"default-src 'self'"},
+				"X-Frame-Options":           []string{"DENY"},
+				"X-Content-Type-Options":    []string{"nosniff"},
+				"Referrer-Policy":           []string{"strict-origin-when-cross-origin"},
+				"Permissions-Policy":        []string{"camera=()"},
+			},
+			expectedStatuses: map[string]string{
+				"Strict-Transport-Security": dmsec.StatusPass,
+				"Content-Security-Policy":   dmsec.StatusPass,
+				"X-Frame-Options":           dmsec.StatusPass,
+				"X-Content-Type-Options":    dmsec.StatusPass,
+				"Referrer-Policy":           dmsec.StatusPass,
+				"Permissions-Policy":        dmsec.StatusPass,
+			},
+			expectedGrade: "A",
+		},
+		{
+			name: "weak headers",
+			url:  "https://example.com",
+			header: http.Header{
+				"Strict-Transport-Security": []string{"max-age=3600"},
+				"Content-Security-Policy":   []string{"script-src 'self' 'unsafe-inline'; frame-ancestors 'none'"},
+				"Referrer-Policy":           []string{"unsafe-url"},
+			},
+			expectedStatuses: map[string]string{
+				"Strict-Transport-Security": dmsec.StatusWarn,
+				"Content-Security-Policy":   dmsec.StatusWarn,
+				"X-Frame-Options":           dmsec.StatusPass,
+				"X-Content-Type-Options":    dmsec.StatusFail,
+				"Referrer-Policy":           dmsec.StatusFail,
+				"Permissions-Policy":        dmsec.StatusWarn,
+			},
+			expectedGrade: "F",
+		},
+		{
+			name:   "plain HTTP page without headers",
+			url:    "http://example.com",
+			header: http.Header{},
+			expectedStatuses: map[string]string{
+				"Strict-Transport-Security": dmsec.StatusFail,
+				"Content-Security-Policy":   dmsec.StatusFail,
+				"X-Frame-Options":           dmsec.StatusFail,
+				"X-Content-Type-Options":    dmsec.StatusFail,
+				"Referrer-Policy":           dmsec.StatusWarn,
+				"Permissions-Policy":        dmsec.StatusWarn,
+			},
+			expectedGrade: "F",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &securityAnalyzer{now: func() time.Time { return fixedNow }}
+
+			result := analyzer.Analyze(newResponse(tt.url, tt.header))
+
+			for _, check := range result.Headers {
+				if expected := tt.expectedStatuses[check.Name]; check.Status != expected {
+					t.Errorf("expected %s status %q, got %q (%s)", check.Name, expected, check.Status, check.Message)
+				}
+			}
+
+			if result.Grade != tt.expectedGrade {
+				t.Errorf("expected grade %q, got %q (score %d)", tt.expectedGrade, result.Grade, result.Score)
+			}
+
+			if result.TLS != nil {
+				t.Errorf("expected no TLS analysis, got %+v", result.TLS)
+			}
+		})
+	}
+}
+
+func Test_Analyze_Cookies(t *testing.T) {
+	tests := []struct {
+		name           string
+		setCookie      string
+		expectedIssues int
+	}{
+		{
+			name:           "hardened cookie",
+			setCookie:      "session=abc; Secure; HttpOnly; SameSite=Strict",
+			expectedIssues: 0,
+		},
+		{
+			name:           "cookie without flags",
+			setCookie:      "session=abc",
+			expectedIssues: 3,
+		},
+		{
+			name:           "SameSite=None without Secure",
+			setCookie:      "tracking=xyz; HttpOnly; SameSite=None",
+			expectedIssues: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &securityAnalyzer{now: func() time.Time { return fixedNow }}
+
+			header := http.Header{"Set-Cookie": []string{tt.setCookie}}
+			result := analyzer.Analyze(newResponse("https://example.com", header))
+
+			if len(result.Cookies) != 1 {
+				t.Fatalf("expected 1 cookie, got %d", len(result.Cookies))
+			}
+
+			if issues := result.Cookies[0].Issues; len(issues) != tt.expectedIssues {
+				t.Errorf("expected %d cookie issues, got %v", tt.expectedIssues, issues)
+			}
+		})
+	}
+}
+
+func Test_Analyze_TLS(t *testing.T) {
+	tests := []struct {
+		name           string
+		version        uint16
+		notAfter       time.Time
+		expectedStatus string
+	}{
+		{
+			name:           "modern TLS with valid certificate",
+			version:        tls.VersionTLS13,
+			notAfter:       fixedNow.AddDate(1, 0, 0),
+			expectedStatus: dmsec.StatusPass,
+		},
+		{
+			name:           "certificate close to expiry",
+			version:        tls.VersionTLS12,
+			notAfter:       fixedNow.AddDate(0, 0, 10),
+			expectedStatus: dmsec.StatusWarn,
+		},
+		{
+			name:           "deprecated TLS version",
+			version:        tls.VersionTLS10,
+			notAfter:       fixedNow.AddDate(1, 0, 0),
+			expectedStatus: dmsec.StatusFail,
+		},
+		{
+			name:           "expired certificate",
+			version:        tls.VersionTLS13,
+			notAfter:       fixedNow.AddDate(0, 0, -1),
+			expectedStatus: dmsec.StatusFail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &securityAnalyzer{now: func() time.Time { return fixedNow }}
+
+			resp := newResponse("https://example.com", http.Header{})
+			resp.TLS = &tls.ConnectionState{
+				Version:     tt.version,
+				CipherSuite: tls.TLS_AES_128_GCM_SHA256,
+				PeerCertificates: []*x509.Certificate{{
+					Issuer:   pkix.Name{CommonName: "Test CA"},
+					Subject:  pkix.Name{CommonName: "example.com"},
+					NotAfter: tt.notAfter,
+				}},
+			}
+
+			result := analyzer.Analyze(resp)
+
+			if result.TLS == nil {
+				t.Fatal("expected TLS analysis, got nil")
+			}
+
+			if result.TLS.Status != tt.expectedStatus {
+				t.Errorf("expected TLS status %q, got %q (%v)", tt.expectedStatus, result.TLS.Status, result.TLS.Issues)
+			}
+
+			if result.TLS.Issuer != "CN=Test CA" {
+				t.Errorf("expected issuer %q, got %q", "CN=Test CA", result.TLS.Issuer)
+			}
+
+			if result.TLS.CipherSuite != "TLS_AES_128_GCM_SHA256" {
+				t.Errorf("expected cipher suite %q, got %q", "TLS_AES_128_GCM_SHA256", result.TLS.CipherSuite)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package security_analyzer
+
+import (
+	"context"
+
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+	dmsec "web-pages-analyzer/internal/domain/security"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+)
+
+// Report the security posture of the page response as the "security" section
+func NewSectionAnalyzer(sa dmsec.SecurityAnalyzer) dmanl.Analyzer {
+	return dmanl.NewResponseAnalyzer(dmpg.SecuritySection, func(_ context.Context, doc *dmanl.Document) (any, error) {
+		return *sa.Analyze(doc.Response), nil
+	})
+}
@@ -0,0 +1,135 @@
+package tracker_detector
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	dmtrk "web-pages-analyzer/internal/domain/tracker"
+)
+
+//go:embed trackers.json
+var trackerList []byte
+
+type trackerEntry struct {
+	Name     string   `json:"name"`
+	Company  string   `json:"company"`
+	Category string   `json:"category"`
+	Domains  []string `json:"domains"`
+}
+
+// A list domain such as "doubleclick.net", optionally narrowed to a path as in "facebook.com/tr"
+type domainRule struct {
+	host    string
+	path    string
+	tracker *trackerEntry
+}
+
+type trackerDetector struct {
+	rules []domainRule
+}
+
+func New() (dmtrk.TrackerDetector, error) {
+	var list struct {
+		Trackers []trackerEntry `json:"trackers"`
+	}
+	if err := json.Unmarshal(trackerList, &list); err != nil {
+		return nil, err
+	}
+
+	detector := &trackerDetector{}
+	for i := range list.Trackers {
+		for _, domain := range list.Trackers[i].Domains {
+			host, path, _ := strings.Cut(strings.ToLower(domain), "/")
+			path = strings.TrimSuffix(path, "/")
+			detector.rules = append(detector.rules, domainRule{host: host, path: path, tracker: &list.Trackers[i]})
+		}
+	}
+
+	// The most specific rule wins, e.g. "connect.facebook.net" over "facebook.net"
+	sort.SliceStable(detector.rules, func(i, j int) bool {
+		return len(detector.rules[i].host)+len(detector.rules[i].path) > len(detector.rules[j].host)+len(detector.rules[j].path)
+	})
+
+	return detector, nil
+}
+
+func (td *trackerDetector) Detect(evidence *dmtrk.Evidence) *dmtrk.TrackerAnalysis {
+	analysis := &dmtrk.TrackerAnalysis{
+		ThirdParties: []dmtrk.ThirdParty{},
+		Categories:   map[string]int{},
+		Consent:      dmtrk.ConsentBanner{Evidence: []string{}},
+	}
+
+	parties := make(map[string]*dmtrk.ThirdParty)
+	var order []string
+
+	for _, request := range evidence.Requests {
+		tracker := td.match(request)
+
+		// Separate entries for the same host when a path rule identifies a different tracker
+		key := request.Host
+		if tracker != nil {
+			key += "|" + tracker.Name
+		}
+
+		party, ok := parties[key]
+		if !ok {
+			party = &dmtrk.ThirdParty{Host: request.Host, Kinds: []string{}}
+			if tracker != nil {
+				party.Name, party.Company, party.Category = tracker.Name, tracker.Company, tracker.Category
+			}
+			parties[key] = party
+			order = append(order, key)
+		}
+
+		party.Requests++
+		if !slices.Contains(party.Kinds, request.Kind) {
+			party.Kinds = append(party.Kinds, request.Kind)
+		}
+
+		if tracker != nil && tracker.Category == dmtrk.ConsentCategory && analysis.Consent.Provider == "" {
+			analysis.Consent.Provider = tracker.Name
+			analysis.Consent.Evidence = append(analysis.Consent.Evidence, request.Kind+" "+request.URL)
+		}
+	}
+
+	for _, key := range order {
+		party := parties[key]
+		if party.Category != "" && party.Category != dmtrk.ConsentCategory {
+			analysis.Trackers++
+			analysis.Categories[party.Category]++
+		}
+		analysis.ThirdParties = append(analysis.ThirdParties, *party)
+	}
+
+	analysis.Consent.Evidence = append(analysis.Consent.Evidence, evidence.ConsentElements...)
+	analysis.Consent.Detected = len(analysis.Consent.Evidence) > 0
+
+	return analysis
+}
+
+func (td *trackerDetector) match(request dmtrk.Request) *trackerEntry {
+	host := strings.ToLower(request.Host)
+
+	var path string
+	if parsed, err := url.Parse(request.URL); err == nil {
+		path = strings.TrimPrefix(strings.ToLower(parsed.Path), "/")
+	}
+
+	for _, rule := range td.rules {
+		if host != rule.host && !strings.HasSuffix(host, "."+rule.host) {
+			continue
+		}
+		// Paths match whole segments, "facebook.com/tr" covers /tr and /tr/... but not /travel
+		if rule.path != "" && path != rule.path && !strings.HasPrefix(path, rule.path+"/") {
+			continue
+		}
+		return rule.tracker
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package tracker_detector
+
+import (
+	"testing"
+
+	dmtrk "web-pages-analyzer/internal/domain/tracker"
+)
+
+func Test_Detect(t *testing.T) {
+	tests := []struct {
+		name               string
+		evidence           *dmtrk.Evidence
+		expectedParties    []dmtrk.ThirdParty
+		expectedTrackers   int
+		expectedCategories map[string]int
+		expectedConsent    bool
+		expectedProvider   string
+	}{
+		{
+			name: "analytics, advertising pixel and unknown host",
+			evidence: &dmtrk.Evidence{Requests: []dmtrk.Request{
+				{URL: "https://www.googletagmanager.com/gtag/js?id=G-1", Host: "www.googletagmanager.com", Kind: dmtrk.ScriptRequest},
+				{URL: "https://www.google-analytics.com/g/collect", Host: "www.google-analytics.com", Kind: dmtrk.LinkRequest},
+				{URL: "https://www.facebook.com/tr?id=1&ev=PageView", Host: "www.facebook.com", Kind: dmtrk.PixelRequest},
+				{URL: "https://www.facebook.com/plugins/like.php", Host: "www.facebook.com", Kind: dmtrk.IframeRequest},
+				{URL: "https://static.hotjar.com/c/hotjar-1.js", Host: "static.hotjar.com", Kind: dmtrk.ScriptRequest},
+				{URL: "https://fonts.example-cdn.com/a.woff2", Host: "fonts.example-cdn.com", Kind: dmtrk.LinkRequest},
+				{URL: "https://www.googletagmanager.com/gtm.js", Host: "www.googletagmanager.com", Kind: dmtrk.ScriptRequest},
+			}},
+			expectedParties: []dmtrk.ThirdParty{
+				{Host: "www.googletagmanager.com", Name: "Google Tag Manager", Category: dmtrk.TagManagerCategory, Requests: 2},
+				{Host: "www.google-analytics.com", Name: "Google Analytics", Category: dmtrk.AnalyticsCategory, Requests: 1},
+				{Host: "www.facebook.com", Name: "Meta Pixel", Category: dmtrk.AdvertisingCategory, Requests: 1},
+				{Host: "www.facebook.com", Name: "Facebook", Category: dmtrk.SocialCategory, Requests: 1},
+				{Host: "static.hotjar.com", Name: "Hotjar", Category: dmtrk.SessionReplayCategory, Requests: 1},
+				{Host: "fonts.example-cdn.com", Requests: 1},
+			},
+			expectedTrackers: 5,
+			expectedCategories: map[string]int{
+				dmtrk.TagManagerCategory:    1,
+				dmtrk.AnalyticsCategory:     1,
+				dmtrk.AdvertisingCategory:   1,
+				dmtrk.SocialCategory:        1,
+				dmtrk.SessionReplayCategory: 1,
+			},
+		},
+		{
+			name: "path rule matches whole segments",
+			evidence: &dmtrk.Evidence{Requests: []dmtrk.Request{
+				{URL: "https://www.facebook.com/travel", Host: "www.facebook.com", Kind: dmtrk.LinkRequest},
+				{URL: "https://www.facebook.com/trending/", Host: "www.facebook.com", Kind: dmtrk.LinkRequest},
+				{URL: "https://www.facebook.com/tr/", Host: "www.facebook.com", Kind: dmtrk.PixelRequest},
+			}},
+			expectedParties: []dmtrk.ThirdParty{
+				{Host: "www.facebook.com", Name: "Facebook", Category: dmtrk.SocialCategory, Requests: 2},
+				{Host: "www.facebook.com", Name: "Meta Pixel", Category: dmtrk.AdvertisingCategory, Requests: 1},
+			},
+			expectedTrackers: 2,
+			expectedCategories: map[string]int{
+				dmtrk.AdvertisingCategory: 1,
+				dmtrk.SocialCategory:      1,
+			},
+		},
+		{
+			name: "consent management platform",
+			evidence: &dmtrk.Evidence{
+				Requests: []dmtrk.Request{
+					{URL: "https://cdn.cookielaw.org/scripttemplates/otSDKStub.js", Host: "cdn.cookielaw.org", Kind: dmtrk.ScriptRequest},
+				},
+				ConsentElements: []string{"div#onetrust-banner-sdk"},
+			},
+			expectedParties: []dmtrk.ThirdParty{
+				{Host: "cdn.cookielaw.org", Name: "OneTrust", Category: dmtrk.ConsentCategory, Requests: 1},
+			},
+			expectedCategories: map[string]int{},
+			expectedConsent:    true,
+			expectedProvider:   "OneTrust",
+		},
+		{
+			name: "hand-rolled cookie banner",
+			evidence: &dmtrk.Evidence{
+				ConsentElements: []string{"div.cookie-banner"},
+			},
+			expectedParties:    []dmtrk.ThirdParty{},
+			expectedCategories: map[string]int{},
+			expectedConsent:    true,
+		},
+	}
+
+	detector, err := New()
+	if err != nil {
+		t.Fatalf("failed to load the tracker list: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := detector.Detect(tt.evidence)
+
+			if len(result.ThirdParties) != len(tt.expectedParties) {
+				t.Fatalf("expected %d third parties, got %+v", len(tt.expectedParties), result.ThirdParties)
+			}
+
+			for i, expected := range tt.expectedParties {
+				party := result.ThirdParties[i]
+				if party.Host != expected.Host || party.Name != expected.Name ||
+					party.Category != expected.Category || party.Requests != expected.Requests {
+					t.Errorf("expected third party %+v, got %+v", expected, party)
+				}
+			}
+
+			if result.Trackers != tt.expectedTrackers {
+				t.Errorf("expected %d trackers, got %d", tt.expectedTrackers, result.Trackers)
+			}
+
+			if len(result.Categories) != len(tt.expectedCategories) {
+				t.Errorf("expected categories %v, got %v", tt.expectedCategories, result.Categories)
+			}
+			for category, count := range tt.expectedCategories {
+				if result.Categories[category] != count {
+					t.Errorf("expected %d %s trackers, got %d", count, category, result.Categories[category])
+				}
+			}
+
+			if result.Consent.Detected != tt.expectedConsent || result.Consent.Provider != tt.expectedProvider {
+				t.Errorf("expected consent %v (%q), got %+v", tt.expectedConsent, tt.expectedProvider, result.Consent)
+			}
+		})
+	}
+}
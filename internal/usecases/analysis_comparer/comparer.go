@@ -0,0 +1,334 @@
+package analysis_comparer
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"sync"
+
+	dmcmp "web-pages-analyzer/internal/domain/comparison"
+	dmhst "web-pages-analyzer/internal/domain/history"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+)
+
+type analysisComparer struct {
+	analyzer dmpg.WebPageAnalyzer
+	history  dmhst.History // nil when the history is disabled
+}
+
+func New(analyzer dmpg.WebPageAnalyzer, history dmhst.History) dmcmp.Comparer {
+	return &analysisComparer{analyzer: analyzer, history: history}
+}
+
+type side struct {
+	source   dmcmp.Source
+	analysis *dmpg.WebPageAnalysis
+	err      error
+}
+
+func (ac *analysisComparer) Compare(ctx context.Context, before, after dmcmp.Target, opts *dmpg.AnalyzeOptions) (*dmcmp.Diff, error) {
+	targets := []dmcmp.Target{before, after}
+	for _, target := range targets {
+		if err := target.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts == nil {
+		opts = &dmpg.AnalyzeOptions{}
+	}
+
+	// Pages given by URL only compute the compared sections
+	analyzeOpts := &dmpg.AnalyzeOptions{
+		ParserOptions: opts.ParserOptions,
+		Fields:        dmcmp.ComparedSections,
+		ForceRefresh:  opts.ForceRefresh,
+	}
+
+	// Both sides are resolved at once, e.g. a staging and a production page are analyzed concurrently
+	sides := make([]side, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sides[i] = ac.resolve(ctx, target, analyzeOpts)
+		}()
+	}
+	wg.Wait()
+
+	for _, side := range sides {
+		if side.err != nil {
+			return nil, side.err
+		}
+	}
+
+	diff := compare(sides[0].analysis, sides[1].analysis)
+	diff.Before, diff.After = sides[0].source, sides[1].source
+
+	return diff, nil
+}
+
+// The analysis of a target, read from the history or computed from the page
+func (ac *analysisComparer) resolve(ctx context.Context, target dmcmp.Target, opts *dmpg.AnalyzeOptions) side {
+	if target.HistoryID != "" {
+		if ac.history == nil {
+			return side{err: fmt.Errorf("%w: the history is disabled", dmhst.ErrNotFound)}
+		}
+
+		record, err := ac.history.Get(target.HistoryID)
+		if err != nil {
+			return side{err: err}
+		}
+
+		// A failed analysis is recorded without sections, every section is then reported missing
+		analysis := record.Analysis
+		if analysis == nil {
+			analysis = dmpg.NewWebPageAnalysis()
+		}
+
+		return side{
+			source:   dmcmp.Source{URL: record.URL, HistoryID: record.ID, AnalyzedAt: record.CreatedAt},
+			analysis: analysis,
+		}
+	}
+
+	analysis, err := ac.analyzer.Analyze(ctx, target.URL, opts)
+	if err != nil {
+		return side{err: err}
+	}
+
+	return side{source: dmcmp.Source{URL: target.URL, HistoryID: analysis.Meta.HistoryID}, analysis: analysis}
+}
+
+// Diff of the compared sections both analyses have
+func compare(before, after *dmpg.WebPageAnalysis) *dmcmp.Diff {
+	diff := &dmcmp.Diff{}
+
+	if b, a, ok := sections[string](before, after, dmpg.HTMLVersionSection, diff); ok && b != a {
+		diff.HTMLVersion = &dmcmp.Change[string]{Before: b, After: a}
+	}
+
+	if b, a, ok := sections[string](before, after, dmpg.TitleSection, diff); ok && b != a {
+		diff.Title = &dmcmp.Change[string]{Before: b, After: a}
+	}
+
+	// Either the counts or the outline can be compared without the other, e.g. for analyses recorded
+	// before the outline was computed
+	countsBefore, countsAfter, countsOK := sections[map[string]int](before, after, dmpg.HeadingsSection, diff)
+	outlineBefore, outlineAfter, outlineOK := sections[[]dmhtml.Heading](before, after, dmpg.HeadingOutlineSection, diff)
+	if countsOK || outlineOK {
+		diff.Headings = diffHeadings(countsBefore, countsAfter, outlineBefore, outlineAfter)
+	}
+
+	if b, a, ok := sections[dmhtml.LinkAnalysis](before, after, dmpg.LinksSection, diff); ok {
+		// Links which were never checked read as not broken, they would be reported fixed
+		checked := linksChecked(before, b) && linksChecked(after, a)
+		diff.Links = diffLinks(b, a, checked)
+		diff.BrokenLinksUnchecked = !checked
+	}
+
+	if b, a, ok := sections[bool](before, after, dmpg.HasLoginFormSection, diff); ok && b != a {
+		diff.HasLoginForm = &dmcmp.Change[bool]{Before: b, After: a}
+	}
+
+	diff.Changed = diff.HTMLVersion != nil || diff.Title != nil || diff.Headings != nil || diff.Links != nil ||
+		diff.HasLoginForm != nil
+
+	return diff
+}
+
+// The section of both analyses, a section either one lacks is reported missing instead of compared
+func sections[T any](before, after *dmpg.WebPageAnalysis, name string, diff *dmcmp.Diff) (T, T, bool) {
+	b, errBefore := dmpg.DecodeSection[T](before, name)
+	a, errAfter := dmpg.DecodeSection[T](after, name)
+	if errBefore != nil || errAfter != nil {
+		diff.Missing = append(diff.Missing, name)
+		var zero T
+		return zero, zero, false
+	}
+
+	return b, a, true
+}
+
+// Heading levels whose count changed and headings added, removed or moved, nil when none were
+func diffHeadings(countsBefore, countsAfter map[string]int, outlineBefore, outlineAfter []dmhtml.Heading) *dmcmp.HeadingsDiff {
+	levels := make(map[string]dmcmp.Change[int])
+	for level := range maps.Keys(countsBefore) {
+		if countsBefore[level] != countsAfter[level] {
+			levels[level] = dmcmp.Change[int]{Before: countsBefore[level], After: countsAfter[level]}
+		}
+	}
+	for level := range maps.Keys(countsAfter) {
+		if countsBefore[level] != countsAfter[level] {
+			levels[level] = dmcmp.Change[int]{Before: countsBefore[level], After: countsAfter[level]}
+		}
+	}
+
+	diff := diffOutline(outlineBefore, outlineAfter)
+	if len(levels)+len(diff.Added)+len(diff.Removed)+len(diff.Moved) == 0 {
+		return nil
+	}
+
+	diff.Levels = levels
+	return diff
+}
+
+// Largest number of heading pairs compared to tell moved headings apart, beyond it the changed part of
+// the before outline is reported removed and the one of the after outline added
+const maxOutlineComparisons = 1 << 20
+
+// Headings added, removed or moved from one outline to the other. The headings kept in place are the
+// longest sequence both outlines share, the others are moved when they are found in both outlines.
+func diffOutline(before, after []dmhtml.Heading) *dmcmp.HeadingsDiff {
+	diff := &dmcmp.HeadingsDiff{Added: []dmhtml.Heading{}, Removed: []dmhtml.Heading{}, Moved: []dmhtml.Heading{}}
+
+	// Headings around the changes are kept in place
+	for len(before) > 0 && len(after) > 0 && before[0] == after[0] {
+		before, after = before[1:], after[1:]
+	}
+	for len(before) > 0 && len(after) > 0 && before[len(before)-1] == after[len(after)-1] {
+		before, after = before[:len(before)-1], after[:len(after)-1]
+	}
+
+	if len(before)*len(after) > maxOutlineComparisons {
+		diff.Added = append(diff.Added, after...)
+		diff.Removed = append(diff.Removed, before...)
+		return diff
+	}
+	unmatchedBefore, unmatchedAfter := outsideCommonSequence(before, after)
+
+	// A heading left out of the common sequence on both sides moved
+	left := make(map[dmhtml.Heading]int)
+	for _, heading := range unmatchedBefore {
+		left[heading]++
+	}
+	moved := make(map[dmhtml.Heading]int)
+	for _, heading := range unmatchedAfter {
+		if left[heading] > 0 {
+			left[heading]--
+			moved[heading]++
+			diff.Moved = append(diff.Moved, heading)
+			continue
+		}
+		diff.Added = append(diff.Added, heading)
+	}
+	for _, heading := range unmatchedBefore {
+		if moved[heading] > 0 {
+			moved[heading]--
+			continue
+		}
+		diff.Removed = append(diff.Removed, heading)
+	}
+
+	return diff
+}
+
+// Headings of each outline which are not part of the longest sequence both outlines share
+func outsideCommonSequence(before, after []dmhtml.Heading) ([]dmhtml.Heading, []dmhtml.Heading) {
+	// lengths[i][j] is the length of the longest common sequence of before[i:] and after[j:]
+	lengths := make([][]int, len(before)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var unmatchedBefore, unmatchedAfter []dmhtml.Heading
+	i, j := 0, 0
+	for i < len(before) && j < len(after) {
+		switch {
+		case before[i] == after[j]:
+			i, j = i+1, j+1
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			unmatchedBefore = append(unmatchedBefore, before[i])
+			i++
+		default:
+			unmatchedAfter = append(unmatchedAfter, after[j])
+			j++
+		}
+	}
+
+	return append(unmatchedBefore, before[i:]...), append(unmatchedAfter, after[j:]...)
+}
+
+// Whether every link of the analysis was checked: its link section is ok and no check was interrupted
+func linksChecked(analysis *dmpg.WebPageAnalysis, links dmhtml.LinkAnalysis) bool {
+	if status, ok := analysis.Meta.Sections[dmpg.LinksSection]; ok && status.Status != dmpg.SectionOK {
+		return false
+	}
+
+	return !links.Incomplete
+}
+
+// Links added, removed, broken or fixed from one analysis to the other, nil when none were. Broken links
+// are only compared when both analyses checked their links.
+func diffLinks(before, after dmhtml.LinkAnalysis, compareBroken bool) *dmcmp.LinksDiff {
+	wasBroken, isBroken := brokenLinks(before), brokenLinks(after)
+	diff := &dmcmp.LinksDiff{Added: []string{}, Removed: []string{}, NewlyBroken: []string{}, Fixed: []string{}}
+
+	for link, broken := range isBroken {
+		brokenBefore, existed := wasBroken[link]
+		switch {
+		case !existed:
+			diff.Added = append(diff.Added, link)
+		case compareBroken && brokenBefore && !broken:
+			diff.Fixed = append(diff.Fixed, link)
+		}
+		if compareBroken && broken && !brokenBefore {
+			diff.NewlyBroken = append(diff.NewlyBroken, link)
+		}
+	}
+
+	for link := range wasBroken {
+		if _, exists := isBroken[link]; !exists {
+			diff.Removed = append(diff.Removed, link)
+		}
+	}
+
+	if len(diff.Added)+len(diff.Removed)+len(diff.NewlyBroken)+len(diff.Fixed) == 0 {
+		return nil
+	}
+
+	for _, links := range [][]string{diff.Added, diff.Removed, diff.NewlyBroken, diff.Fixed} {
+		slices.Sort(links)
+	}
+
+	return diff
+}
+
+// Whether each distinct link of the analysis is broken
+func brokenLinks(analysis dmhtml.LinkAnalysis) map[string]bool {
+	broken := make(map[string]bool, len(analysis.Details))
+	for _, link := range analysis.Details {
+		key := linkKey(link)
+		broken[key] = broken[key] || link.Inaccessible
+	}
+
+	return broken
+}
+
+// Internal links are identified by their path and query, external links by their URL, without fragments
+func linkKey(link dmhtml.Link) string {
+	parsed, err := url.Parse(link.URL)
+	if err != nil {
+		return link.URL
+	}
+
+	parsed.Fragment, parsed.RawFragment = "", ""
+	if link.Internal {
+		return parsed.RequestURI()
+	}
+
+	return parsed.String()
+}
@@ -0,0 +1,339 @@
+package analysis_comparer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	dmcmp "web-pages-analyzer/internal/domain/comparison"
+	dmhst "web-pages-analyzer/internal/domain/history"
+	dmhtml "web-pages-analyzer/internal/domain/html"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+	hstmocks "web-pages-analyzer/internal/usecases/analysis_history/mocks"
+	mocks "web-pages-analyzer/internal/usecases/webpage_analyzer/mocks"
+)
+
+// Headings of the given levels and texts, e.g. outline("h1", "Home", "h2", "Pricing")
+func outline(levelsAndTexts ...string) []dmhtml.Heading {
+	headings := []dmhtml.Heading{}
+	for i := 0; i+1 < len(levelsAndTexts); i += 2 {
+		headings = append(headings, dmhtml.Heading{Level: levelsAndTexts[i], Text: levelsAndTexts[i+1]})
+	}
+
+	return headings
+}
+
+func newAnalysis(title string, headings []dmhtml.Heading, links []dmhtml.Link, hasLoginForm bool) *dmpg.WebPageAnalysis {
+	counts := make(map[string]int)
+	for _, heading := range headings {
+		counts[heading.Level]++
+	}
+
+	analysis := dmpg.NewWebPageAnalysis()
+	analysis.Set(dmpg.HTMLVersionSection, "HTML5")
+	analysis.Set(dmpg.TitleSection, title)
+	analysis.Set(dmpg.HeadingsSection, counts)
+	analysis.Set(dmpg.HeadingOutlineSection, headings)
+	analysis.Set(dmpg.LinksSection, dmhtml.LinkAnalysis{Details: links})
+	analysis.Set(dmpg.HasLoginFormSection, hasLoginForm)
+	return analysis
+}
+
+func Test_Compare(t *testing.T) {
+	before := newAnalysis("Home", outline("h1", "Home", "h2", "Plans", "h2", "Pricing", "h2", "Blog"), []dmhtml.Link{
+		{URL: "https://staging.example.com/about", Internal: true},
+		{URL: "https://staging.example.com/pricing#plans", Internal: true, Inaccessible: true},
+		{URL: "https://staging.example.com/blog", Internal: true},
+		{URL: "https://other.com/page", Inaccessible: true},
+	}, false)
+
+	after := newAnalysis("Welcome", outline("h1", "Home", "h3", "Pricing", "h3", "Plans"), []dmhtml.Link{
+		{URL: "https://example.com/about", Internal: true, Inaccessible: true},
+		{URL: "https://example.com/pricing", Internal: true},
+		{URL: "https://example.com/contact", Internal: true, Inaccessible: true},
+		{URL: "https://other.com/page"},
+	}, true)
+
+	tests := []struct {
+		name     string
+		before   *dmpg.WebPageAnalysis
+		after    *dmpg.WebPageAnalysis
+		expected *dmcmp.Diff
+	}{
+		{
+			name:   "changed page",
+			before: before,
+			after:  after,
+			expected: &dmcmp.Diff{
+				Changed: true,
+				Title:   &dmcmp.Change[string]{Before: "Home", After: "Welcome"},
+				Headings: &dmcmp.HeadingsDiff{
+					Levels: map[string]dmcmp.Change[int]{
+						"h2": {Before: 3, After: 0},
+						"h3": {Before: 0, After: 2},
+					},
+					Added:   outline("h3", "Pricing", "h3", "Plans"),
+					Removed: outline("h2", "Plans", "h2", "Pricing", "h2", "Blog"),
+					Moved:   outline(),
+				},
+				Links: &dmcmp.LinksDiff{
+					Added:       []string{"/contact"},
+					Removed:     []string{"/blog"},
+					NewlyBroken: []string{"/about", "/contact"},
+					Fixed:       []string{"/pricing", "https://other.com/page"},
+				},
+				HasLoginForm: &dmcmp.Change[bool]{Before: false, After: true},
+			},
+		},
+		{
+			name:     "unchanged page",
+			before:   before,
+			after:    before,
+			expected: &dmcmp.Diff{},
+		},
+		{
+			name:   "links of a partial analysis were not checked",
+			before: before,
+			after: func() *dmpg.WebPageAnalysis {
+				// The link checks timed out, none of the links read as broken
+				analysis := newAnalysis("Home", outline("h1", "Home", "h2", "Plans", "h2", "Pricing", "h2", "Blog"), []dmhtml.Link{
+					{URL: "https://example.com/about", Internal: true},
+					{URL: "https://example.com/pricing", Internal: true},
+					{URL: "https://example.com/contact", Internal: true},
+					{URL: "https://other.com/page"},
+				}, false)
+				analysis.Meta.Sections = map[string]dmpg.SectionStatus{
+					dmpg.LinksSection: {Status: dmpg.SectionIncomplete, Error: "context deadline exceeded"},
+				}
+				return analysis
+			}(),
+			expected: &dmcmp.Diff{
+				Changed: true,
+				Links: &dmcmp.LinksDiff{
+					Added:       []string{"/contact"},
+					Removed:     []string{"/blog"},
+					NewlyBroken: []string{},
+					Fixed:       []string{},
+				},
+				BrokenLinksUnchecked: true,
+			},
+		},
+		{
+			name:   "outline missing from an analysis",
+			before: before,
+			after: func() *dmpg.WebPageAnalysis {
+				// Recorded before the outline was computed
+				analysis := dmpg.NewWebPageAnalysis()
+				analysis.Set(dmpg.HTMLVersionSection, "HTML5")
+				analysis.Set(dmpg.TitleSection, "Home")
+				analysis.Set(dmpg.HeadingsSection, map[string]int{"h1": 1, "h2": 2})
+				analysis.Set(dmpg.LinksSection, dmhtml.LinkAnalysis{})
+				analysis.Set(dmpg.HasLoginFormSection, false)
+				return analysis
+			}(),
+			expected: &dmcmp.Diff{
+				Changed: true,
+				Headings: &dmcmp.HeadingsDiff{
+					Levels:  map[string]dmcmp.Change[int]{"h2": {Before: 3, After: 2}},
+					Added:   outline(),
+					Removed: outline(),
+					Moved:   outline(),
+				},
+				Links: &dmcmp.LinksDiff{
+					Added:       []string{},
+					Removed:     []string{"/about", "/blog", "/pricing", "https://other.com/page"},
+					NewlyBroken: []string{},
+					Fixed:       []string{},
+				},
+				Missing: []string{dmpg.HeadingOutlineSection},
+			},
+		},
+		{
+			name:   "sections missing from an analysis",
+			before: before,
+			after:  dmpg.NewWebPageAnalysis(),
+			expected: &dmcmp.Diff{
+				Missing: dmcmp.ComparedSections,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compare(tt.before, tt.after)
+
+			// Verify results
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected diff %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func Test_DiffOutline(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   []dmhtml.Heading
+		after    []dmhtml.Heading
+		expected *dmcmp.HeadingsDiff
+	}{
+		{
+			name:     "same outline",
+			before:   outline("h1", "Home", "h2", "Plans"),
+			after:    outline("h1", "Home", "h2", "Plans"),
+			expected: &dmcmp.HeadingsDiff{Added: outline(), Removed: outline(), Moved: outline()},
+		},
+		{
+			name:     "headings added and removed",
+			before:   outline("h1", "Home", "h2", "Plans", "h2", "Blog"),
+			after:    outline("h1", "Home", "h2", "Plans", "h2", "Careers", "h2", "Contact"),
+			expected: &dmcmp.HeadingsDiff{Added: outline("h2", "Careers", "h2", "Contact"), Removed: outline("h2", "Blog"), Moved: outline()},
+		},
+		{
+			name:     "heading moved",
+			before:   outline("h1", "Home", "h2", "Plans", "h2", "Pricing", "h2", "Blog"),
+			after:    outline("h1", "Home", "h2", "Pricing", "h2", "Blog", "h2", "Plans"),
+			expected: &dmcmp.HeadingsDiff{Added: outline(), Removed: outline(), Moved: outline("h2", "Plans")},
+		},
+		{
+			name:     "heading of another level",
+			before:   outline("h1", "Home", "h2", "Plans"),
+			after:    outline("h1", "Home", "h3", "Plans"),
+			expected: &dmcmp.HeadingsDiff{Added: outline("h3", "Plans"), Removed: outline("h2", "Plans"), Moved: outline()},
+		},
+		{
+			name:     "repeated headings",
+			before:   outline("h2", "Details", "h2", "Details", "h2", "Summary"),
+			after:    outline("h2", "Summary", "h2", "Details"),
+			expected: &dmcmp.HeadingsDiff{Added: outline(), Removed: outline("h2", "Details"), Moved: outline("h2", "Details")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffOutline(tt.before, tt.after)
+
+			// Verify results
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected diff %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func Test_Compare_Targets(t *testing.T) {
+	createdAt := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		before         dmcmp.Target
+		after          dmcmp.Target
+		withoutHistory bool
+		setupMocks     func(*mocks.MockWebPageAnalyzer, *hstmocks.MockHistory)
+		expectedBefore dmcmp.Source
+		expectedAfter  dmcmp.Source
+		expectedErr    error
+	}{
+		{
+			name:   "history record and URL",
+			before: dmcmp.Target{HistoryID: "record"},
+			after:  dmcmp.Target{URL: "https://example.com"},
+			setupMocks: func(analyzer *mocks.MockWebPageAnalyzer, history *hstmocks.MockHistory) {
+				history.EXPECT().Get("record").Return(&dmhst.Record{
+					ID:        "record",
+					URL:       "https://example.com",
+					CreatedAt: createdAt,
+					Analysis:  newAnalysis("Home", nil, nil, false),
+				}, nil).Times(1)
+
+				analyzer.EXPECT().
+					Analyze(gomock.Any(), "https://example.com", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, opts *dmpg.AnalyzeOptions) (*dmpg.WebPageAnalysis, error) {
+						if !reflect.DeepEqual(opts, &dmpg.AnalyzeOptions{Fields: dmcmp.ComparedSections, ForceRefresh: true}) {
+							t.Errorf("expected only the compared sections, got options %+v", opts)
+						}
+						analysis := newAnalysis("Home", nil, nil, false)
+						analysis.Meta.HistoryID = "new-record"
+						return analysis, nil
+					}).
+					Times(1)
+			},
+			expectedBefore: dmcmp.Source{URL: "https://example.com", HistoryID: "record", AnalyzedAt: createdAt},
+			expectedAfter:  dmcmp.Source{URL: "https://example.com", HistoryID: "new-record"},
+		},
+		{
+			name:   "unknown history record",
+			before: dmcmp.Target{HistoryID: "unknown"},
+			after:  dmcmp.Target{HistoryID: "record"},
+			setupMocks: func(_ *mocks.MockWebPageAnalyzer, history *hstmocks.MockHistory) {
+				history.EXPECT().Get("unknown").Return(nil, dmhst.ErrNotFound).Times(1)
+				history.EXPECT().Get("record").Return(&dmhst.Record{ID: "record"}, nil).Times(1)
+			},
+			expectedErr: dmhst.ErrNotFound,
+		},
+		{
+			name:           "history disabled",
+			before:         dmcmp.Target{HistoryID: "record"},
+			after:          dmcmp.Target{HistoryID: "record"},
+			withoutHistory: true,
+			setupMocks:     func(*mocks.MockWebPageAnalyzer, *hstmocks.MockHistory) {},
+			expectedErr:    dmhst.ErrNotFound,
+		},
+		{
+			name:        "invalid target",
+			before:      dmcmp.Target{HistoryID: "record", URL: "https://example.com"},
+			after:       dmcmp.Target{URL: "https://example.com"},
+			setupMocks:  func(*mocks.MockWebPageAnalyzer, *hstmocks.MockHistory) {},
+			expectedErr: dmcmp.ErrInvalidTarget,
+		},
+		{
+			name:   "analysis error",
+			before: dmcmp.Target{URL: "https://example.com"},
+			after:  dmcmp.Target{URL: "https://example.org"},
+			setupMocks: func(analyzer *mocks.MockWebPageAnalyzer, _ *hstmocks.MockHistory) {
+				analyzer.EXPECT().Analyze(gomock.Any(), "https://example.com", gomock.Any()).
+					Return(newAnalysis("Home", nil, nil, false), nil).Times(1)
+				analyzer.EXPECT().Analyze(gomock.Any(), "https://example.org", gomock.Any()).
+					Return(nil, dmpg.ErrUnknownSection).Times(1)
+			},
+			expectedErr: dmpg.ErrUnknownSection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockAnalyzer := mocks.NewMockWebPageAnalyzer(ctrl)
+			mockHistory := hstmocks.NewMockHistory(ctrl)
+			tt.setupMocks(mockAnalyzer, mockHistory)
+
+			var history dmhst.History = mockHistory
+			if tt.withoutHistory {
+				history = nil
+			}
+
+			opts := &dmpg.AnalyzeOptions{Exclude: []string{dmpg.LinksSection}, ForceRefresh: true}
+			diff, err := New(mockAnalyzer, history).Compare(context.Background(), tt.before, tt.after, opts)
+
+			// Verify results
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+			if diff.Before != tt.expectedBefore || diff.After != tt.expectedAfter {
+				t.Errorf("expected sources %+v and %+v, got %+v and %+v", tt.expectedBefore, tt.expectedAfter, diff.Before, diff.After)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/comparison/comparison.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/domain/comparison/comparison.go -destination=internal/usecases/analysis_comparer/mocks/mock_comparer.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	comparison "web-pages-analyzer/internal/domain/comparison"
+	webpage "web-pages-analyzer/internal/domain/webpage"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockComparer is a mock of Comparer interface.
+type MockComparer struct {
+	ctrl     *gomock.Controller
+	recorder *MockComparerMockRecorder
+	isgomock struct{}
+}
+
+// MockComparerMockRecorder is the mock recorder for MockComparer.
+type MockComparerMockRecorder struct {
+	mock *MockComparer
+}
+
+// NewMockComparer creates a new mock instance.
+func NewMockComparer(ctrl *gomock.Controller) *MockComparer {
+	mock := &MockComparer{ctrl: ctrl}
+	mock.recorder = &MockComparerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockComparer) EXPECT() *MockComparerMockRecorder {
+	return m.recorder
+}
+
+// Compare mocks base method.
+func (m *MockComparer) Compare(ctx context.Context, before, after comparison.Target, opts *webpage.AnalyzeOptions) (*comparison.Diff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Compare", ctx, before, after, opts)
+	ret0, _ := ret[0].(*comparison.Diff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Compare indicates an expected call of Compare.
+func (mr *MockComparerMockRecorder) Compare(ctx, before, after, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compare", reflect.TypeOf((*MockComparer)(nil).Compare), ctx, before, after, opts)
+}
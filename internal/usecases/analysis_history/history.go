@@ -0,0 +1,38 @@
+package analysis_history
+
+import (
+	dmhst "web-pages-analyzer/internal/domain/history"
+)
+
+// Records listed when no limit is requested, and the most listed at once
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type analysisHistory struct {
+	store dmhst.Store
+}
+
+func New(store dmhst.Store) dmhst.History {
+	return &analysisHistory{store: store}
+}
+
+func (ah *analysisHistory) List(url string, limit int) ([]dmhst.Record, error) {
+	switch {
+	case limit <= 0:
+		limit = defaultLimit
+	case limit > maxLimit:
+		limit = maxLimit
+	}
+
+	return ah.store.List(url, limit)
+}
+
+func (ah *analysisHistory) Get(id string) (*dmhst.Record, error) {
+	return ah.store.Get(id)
+}
+
+func (ah *analysisHistory) Delete(id string) error {
+	return ah.store.Delete(id)
+}
@@ -0,0 +1,68 @@
+package analysis_history
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+
+	dmhst "web-pages-analyzer/internal/domain/history"
+	mocks "web-pages-analyzer/internal/infrastructure/history_store/mocks"
+)
+
+func Test_List(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int
+		expectedLimit int
+	}{
+		{name: "default limit", limit: 0, expectedLimit: defaultLimit},
+		{name: "requested limit", limit: 5, expectedLimit: 5},
+		{name: "limit capped", limit: 1000, expectedLimit: maxLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			records := []dmhst.Record{{ID: "record"}}
+
+			mockStore := mocks.NewMockStore(ctrl)
+			mockStore.EXPECT().
+				List("https://example.com", tt.expectedLimit).
+				Return(records, nil).
+				Times(1)
+
+			got, err := New(mockStore).List("https://example.com", tt.limit)
+
+			// Verify results
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+			if len(got) != 1 || got[0].ID != "record" {
+				t.Errorf("expected records %+v, got %+v", records, got)
+			}
+		})
+	}
+}
+
+func Test_GetAndDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockStore.EXPECT().Get("record").Return(&dmhst.Record{ID: "record"}, nil).Times(1)
+	mockStore.EXPECT().Delete("record").Return(dmhst.ErrNotFound).Times(1)
+
+	history := New(mockStore)
+
+	record, err := history.Get("record")
+	if err != nil || record.ID != "record" {
+		t.Errorf("expected the record, got %+v (%v)", record, err)
+	}
+
+	if err = history.Delete("record"); !errors.Is(err, dmhst.ErrNotFound) {
+		t.Errorf("expected error %v, got %v", dmhst.ErrNotFound, err)
+	}
+}
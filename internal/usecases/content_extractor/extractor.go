@@ -0,0 +1,59 @@
+package content_extractor
+
+import (
+	"context"
+	"fmt"
+
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+	clihttp "web-pages-analyzer/internal/domain/clients/http"
+	dmext "web-pages-analyzer/internal/domain/extraction"
+)
+
+type contentExtractor struct {
+	httpClient      clihttp.HttpClient
+	documentParser  dmanl.DocumentParser
+	articleAnalyzer dmanl.Analyzer
+}
+
+func New(httpClient clihttp.HttpClient, documentParser dmanl.DocumentParser, articleAnalyzer dmanl.Analyzer) dmext.ContentExtractor {
+	return &contentExtractor{
+		httpClient:      httpClient,
+		documentParser:  documentParser,
+		articleAnalyzer: articleAnalyzer,
+	}
+}
+
+func (ce *contentExtractor) Extract(ctx context.Context, url string) (*dmext.Article, error) {
+	// Fetch the web page
+	resp, err := ce.httpClient.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Resolve relative links and images against the page we ended up on after redirects
+	redirects := clihttp.NewRedirectChain(url, resp)
+
+	doc, err := ce.documentParser.Parse(resp.Body, redirects.FinalURL, ce.httpClient, nil)
+	if err != nil {
+		return nil, err
+	}
+	doc.Response = resp
+	doc.Redirects = redirects
+
+	section, err := ce.articleAnalyzer.Analyze(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	article, ok := section.(*dmext.Article)
+	if !ok {
+		return nil, fmt.Errorf("%s analyzer returned %T instead of an article", ce.articleAnalyzer.Name(), section)
+	}
+
+	if article.WordCount == 0 {
+		return nil, dmext.ErrNoMainContent
+	}
+
+	return article, nil
+}
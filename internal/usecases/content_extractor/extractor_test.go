@@ -0,0 +1,111 @@
+package content_extractor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+	clihttp "web-pages-analyzer/internal/domain/clients/http"
+	dmext "web-pages-analyzer/internal/domain/extraction"
+	httpmocks "web-pages-analyzer/internal/infrastructure/clients/http/mocks"
+	htmlmocks "web-pages-analyzer/internal/infrastructure/html_parser/mocks"
+)
+
+func Test_Extract(t *testing.T) {
+	tests := []struct {
+		name          string
+		article       *dmext.Article
+		expectedError error
+	}{
+		{
+			name:    "article found",
+			article: &dmext.Article{Title: "Brewing green tea", Text: "Green tea is delicate.", WordCount: 4},
+		},
+		{
+			name:          "page without main content",
+			article:       &dmext.Article{Title: "Empty"},
+			expectedError: dmext.ErrNoMainContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+			mockHttpClient.EXPECT().
+				Get(gomock.Any(), "https://example.com/post").
+				Return(&http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(strings.NewReader("<html></html>")),
+				}, nil).
+				Times(1)
+
+			mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+			mockDocumentParser.EXPECT().
+				Parse(gomock.Any(), "https://example.com/post", mockHttpClient, nil).
+				Return(&dmanl.Document{URL: "https://example.com/post"}, nil).
+				Times(1)
+
+			// The analyzer runs under the request's context, so it stops when the client goes away
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			mockAnalyzer := htmlmocks.NewMockAnalyzer(ctrl)
+			mockAnalyzer.EXPECT().Analyze(ctx, gomock.Any()).Return(tt.article, nil).Times(1)
+
+			extractor := New(mockHttpClient, mockDocumentParser, mockAnalyzer)
+			result, err := extractor.Extract(ctx, "https://example.com/post")
+
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+				}
+				if result != nil {
+					t.Fatal("expected nil result")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+
+			if result.Title != tt.article.Title || result.Text != tt.article.Text {
+				t.Errorf("expected article %+v, got %+v", tt.article, result)
+			}
+		})
+	}
+}
+
+func Test_Extract_HttpClientError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+	mockHttpClient.EXPECT().
+		Get(gomock.Any(), "https://example.com/missing").
+		Return(nil, clihttp.NewHttpError(404, "Not Found")).
+		Times(1)
+
+	mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+	mockAnalyzer := htmlmocks.NewMockAnalyzer(ctrl)
+
+	extractor := New(mockHttpClient, mockDocumentParser, mockAnalyzer)
+	result, err := extractor.Extract(context.Background(), "https://example.com/missing")
+
+	if err == nil || !strings.Contains(err.Error(), "Not Found") {
+		t.Fatalf("expected a Not Found error, got %v", err)
+	}
+
+	if result != nil {
+		t.Fatal("expected nil result")
+	}
+}
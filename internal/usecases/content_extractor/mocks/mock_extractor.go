@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/extraction/extraction.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/domain/extraction/extraction.go -destination=internal/usecases/content_extractor/mocks/mock_extractor.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	extraction "web-pages-analyzer/internal/domain/extraction"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockContentExtractor is a mock of ContentExtractor interface.
+type MockContentExtractor struct {
+	ctrl     *gomock.Controller
+	recorder *MockContentExtractorMockRecorder
+	isgomock struct{}
+}
+
+// MockContentExtractorMockRecorder is the mock recorder for MockContentExtractor.
+type MockContentExtractorMockRecorder struct {
+	mock *MockContentExtractor
+}
+
+// NewMockContentExtractor creates a new mock instance.
+func NewMockContentExtractor(ctrl *gomock.Controller) *MockContentExtractor {
+	mock := &MockContentExtractor{ctrl: ctrl}
+	mock.recorder = &MockContentExtractorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockContentExtractor) EXPECT() *MockContentExtractorMockRecorder {
+	return m.recorder
+}
+
+// Extract mocks base method.
+func (m *MockContentExtractor) Extract(ctx context.Context, url string) (*extraction.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Extract", ctx, url)
+	ret0, _ := ret[0].(*extraction.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Extract indicates an expected call of Extract.
+func (mr *MockContentExtractorMockRecorder) Extract(ctx, url any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Extract", reflect.TypeOf((*MockContentExtractor)(nil).Extract), ctx, url)
+}
@@ -1,41 +1,433 @@
 package webpage_analyzer
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+	dmcch "web-pages-analyzer/internal/domain/cache"
 	clihttp "web-pages-analyzer/internal/domain/clients/http"
+	dmhst "web-pages-analyzer/internal/domain/history"
 	dmhtml "web-pages-analyzer/internal/domain/html"
 	dmpg "web-pages-analyzer/internal/domain/webpage"
+	utlurl "web-pages-analyzer/internal/utils/url"
 )
 
 type webPageAnalyzer struct {
-	httpClient    clihttp.HttpClient
-	parserFactory dmhtml.ParserFactory
+	httpClient         clihttp.HttpClient
+	documentParser     dmanl.DocumentParser
+	registry           *dmanl.Registry
+	timeouts           dmanl.Timeouts
+	streamingThreshold int64       // Size in bytes above which the page is streamed, 0 never streams
+	cache              dmcch.Cache // Analyses of the pages analyzed before, nil disables caching
+	cacheTTL           time.Duration
+	history            dmhst.Store // Records of every analysis computed, nil disables the history
+
+	flights singleflight.Group // Analyses in progress, shared by the identical requests made meanwhile
+	mu      sync.Mutex
+	waiting map[string]*flight
 }
 
-func New(httpClient clihttp.HttpClient, parserFactory dmhtml.ParserFactory) dmpg.WebPageAnalyzer {
+// The context of an analysis in progress and the requests waiting for it
+type flight struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+func New(httpClient clihttp.HttpClient, documentParser dmanl.DocumentParser, registry *dmanl.Registry, timeouts dmanl.Timeouts, streamingThreshold int64, cache dmcch.Cache, cacheTTL time.Duration, history dmhst.Store) dmpg.WebPageAnalyzer {
 	return &webPageAnalyzer{
-		httpClient:    httpClient,
-		parserFactory: parserFactory,
+		httpClient:         httpClient,
+		documentParser:     documentParser,
+		registry:           registry,
+		timeouts:           timeouts,
+		streamingThreshold: streamingThreshold,
+		cache:              cache,
+		cacheTTL:           cacheTTL,
+		history:            history,
+		waiting:            make(map[string]*flight),
 	}
 }
 
-func (wpa *webPageAnalyzer) Analyze(url string) (*dmpg.WebPageAnalysis, error) {
+// How long an interrupted analyzer is given to return the part of its section computed so far
+const partialResultGrace = 100 * time.Millisecond
+
+type analyzerResult struct {
+	section  any
+	err      error
+	duration time.Duration
+}
+
+func (wpa *webPageAnalyzer) Analyze(ctx context.Context, url string, opts *dmpg.AnalyzeOptions) (*dmpg.WebPageAnalysis, error) {
+	if opts == nil {
+		opts = &dmpg.AnalyzeOptions{}
+	}
+
+	for _, name := range opts.RequestedSections() {
+		if !wpa.registry.Has(name) {
+			return nil, fmt.Errorf("%w %q", dmpg.ErrUnknownSection, name)
+		}
+	}
+
+	// Identical requests made while the page is analyzed wait for that analysis instead of starting their own
+	key := cacheKey(url, opts)
+	flightKey := key
+	if opts.ForceRefresh {
+		flightKey = "refresh " + key
+	}
+
+	flight := wpa.join(ctx, flightKey)
+	results := wpa.flights.DoChan(flightKey, func() (any, error) {
+		return wpa.analyze(flight.ctx, key, url, opts)
+	})
+
+	select {
+	case result := <-results:
+		wpa.leave(flightKey, flight)
+		return sharedResult(result)
+	case <-ctx.Done():
+	}
+
+	// The analysis goes on for the other waiters. The last one cancels it, and gets what it computed
+	// until then as a request alone would.
+	if !wpa.leave(flightKey, flight) {
+		return nil, ctx.Err()
+	}
+
+	return sharedResult(<-results)
+}
+
+// Wait for the analysis in progress under the key, its context is cancelled once nobody waits for it anymore
+func (wpa *webPageAnalyzer) join(ctx context.Context, key string) *flight {
+	wpa.mu.Lock()
+	defer wpa.mu.Unlock()
+
+	f, ok := wpa.waiting[key]
+	if !ok {
+		f = &flight{}
+		f.ctx, f.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		wpa.waiting[key] = f
+	}
+	f.waiters++
+
+	return f
+}
+
+// Stop waiting for the analysis, whether it is the last waiter which left and cancelled it
+func (wpa *webPageAnalyzer) leave(key string, f *flight) bool {
+	wpa.mu.Lock()
+	defer wpa.mu.Unlock()
+
+	f.waiters--
+	if f.waiters > 0 {
+		return false
+	}
+
+	f.cancel()
+	delete(wpa.waiting, key)
+	// A request made from now on starts a new analysis instead of joining the cancelled one
+	wpa.flights.Forget(key)
+
+	return true
+}
+
+// The analysis of a waiter, marked when it was shared with other waiters
+func sharedResult(result singleflight.Result) (*dmpg.WebPageAnalysis, error) {
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	analysis := result.Val.(*dmpg.WebPageAnalysis)
+	if !result.Shared {
+		return analysis, nil
+	}
+
+	shared := *analysis
+	shared.Meta.Shared = true
+	return &shared, nil
+}
+
+func (wpa *webPageAnalyzer) analyze(ctx context.Context, key, url string, opts *dmpg.AnalyzeOptions) (*dmpg.WebPageAnalysis, error) {
+	// A cached analysis is served while it is fresh, a stale one once the page is found unchanged
+	var stale *dmcch.Entry
+	if wpa.cache != nil && !opts.ForceRefresh {
+		if entry, ok := wpa.cache.Get(key); ok {
+			if entry.Age(time.Now()) < wpa.cacheTTL {
+				return wpa.cached(entry, dmpg.CacheHit), nil
+			}
+			stale = entry
+		}
+	}
+
+	analysis := dmpg.NewWebPageAnalysis()
+
 	// Fetch the web page
-	resp, err := wpa.httpClient.Get(url)
+	start := time.Now()
+	resp, err := wpa.fetch(ctx, url, stale)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	parser, err := wpa.parserFactory.CreateParser(resp.Body, url, wpa.httpClient)
-	if err != nil {
+	if resp.StatusCode == http.StatusNotModified {
+		return wpa.revalidated(key, stale, resp), nil
+	}
+	analysis.Meta.FetchMs = dmpg.Milliseconds(time.Since(start))
+
+	// Resolve relative links against the page we ended up on after redirects
+	redirects := clihttp.NewRedirectChain(url, resp)
+
+	// Very large pages are tokenized while they are traversed instead of being parsed into a tree. A page
+	// which does not declare a larger Content-Length, e.g. a chunked response, is streamed once more than
+	// the threshold was read.
+	var doc *dmanl.Document
+	start = time.Now()
+	body := io.Reader(resp.Body)
+	if wpa.streamingThreshold > 0 {
+		analysis.Meta.Streamed = resp.ContentLength > wpa.streamingThreshold
+		if !analysis.Meta.Streamed {
+			head, err := io.ReadAll(io.LimitReader(resp.Body, wpa.streamingThreshold+1))
+			if err != nil {
+				return nil, err
+			}
+			analysis.Meta.Streamed = int64(len(head)) > wpa.streamingThreshold
+			body = io.MultiReader(bytes.NewReader(head), resp.Body)
+		}
+	}
+	if analysis.Meta.Streamed {
+		doc, err = wpa.documentParser.Stream(body, redirects.FinalURL, wpa.httpClient, &opts.ParserOptions)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		doc, err = wpa.documentParser.Parse(body, redirects.FinalURL, wpa.httpClient, &opts.ParserOptions)
+		if err != nil {
+			return nil, err
+		}
+		analysis.Meta.ParseMs = dmpg.Milliseconds(time.Since(start))
+	}
+	doc.Response = resp
+	doc.Redirects = redirects
+
+	// The selected analyzers are independent of each other and run concurrently, the others never run
+	var selected []dmanl.Analyzer
+	for _, analyzer := range wpa.registry.Analyzers() {
+		if !opts.Selects(analyzer.Name()) || (analysis.Meta.Streamed && !dmanl.Streams(analyzer)) {
+			analysis.Meta.Skipped = append(analysis.Meta.Skipped, analyzer.Name())
+			continue
+		}
+		selected = append(selected, analyzer)
+	}
+
+	// Walk the document once for every visiting analyzer, they then only read what their visitors collected
+	start = time.Now()
+	if err = doc.Traverse(selected...); err != nil {
 		return nil, err
 	}
+	analysis.Meta.TraversalMs = dmpg.Milliseconds(time.Since(start))
+
+	// A failed analyzer only costs its own section
+	results := make([]analyzerResult, len(selected))
+	var wg sync.WaitGroup
+	for i, analyzer := range selected {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			section, err := wpa.run(ctx, analyzer, doc)
+			results[i] = analyzerResult{section: section, err: err, duration: time.Since(start)}
+		}()
+	}
+	wg.Wait()
+
+	// Every analyzer contributes the section named after it, in registration order, unless it failed
+	// without computing any of it
+	analysis.Meta.Sections = make(map[string]dmpg.SectionStatus, len(selected))
+	analysis.Meta.TimingsMs = make(map[string]float64, len(selected))
+	for i, analyzer := range selected {
+		result := results[i]
+		analysis.Meta.TimingsMs[analyzer.Name()] = dmpg.Milliseconds(result.duration)
+
+		switch {
+		case result.err == nil:
+			analysis.Set(analyzer.Name(), result.section)
+			analysis.Meta.Sections[analyzer.Name()] = dmpg.SectionStatus{Status: dmpg.SectionOK}
+		case result.section != nil:
+			analysis.Set(analyzer.Name(), result.section)
+			analysis.Meta.Sections[analyzer.Name()] = dmpg.SectionStatus{Status: dmpg.SectionIncomplete, Error: result.err.Error()}
+		default:
+			analysis.Meta.Sections[analyzer.Name()] = dmpg.SectionStatus{Status: dmpg.SectionFailed, Error: result.err.Error()}
+		}
+	}
+	analysis.Meta.Status = dmpg.OverallStatus(analysis.Meta.Sections)
+
+	wpa.record(url, opts, analysis)
+	wpa.store(key, analysis, resp, opts.ForceRefresh)
+
+	return analysis, nil
+}
+
+// Add the analysis to the history of the page, analyses served from the cache were recorded when computed
+func (wpa *webPageAnalyzer) record(url string, opts *dmpg.AnalyzeOptions, analysis *dmpg.WebPageAnalysis) {
+	if wpa.history == nil {
+		return
+	}
+
+	record := &dmhst.Record{
+		URL:       url,
+		Options:   *opts,
+		CreatedAt: time.Now(),
+		Status:    analysis.Meta.Status,
+		Analysis:  analysis,
+	}
+	if err := wpa.history.Save(record); err != nil {
+		log.Println("[ERROR] Error recording analysis: ", err.Error())
+		return
+	}
+
+	analysis.Meta.HistoryID = record.ID
+}
+
+// Fetch the page, only to learn whether it changed when a stale analysis of it can be revalidated
+func (wpa *webPageAnalyzer) fetch(ctx context.Context, url string, stale *dmcch.Entry) (*http.Response, error) {
+	if stale != nil && !stale.Validators.IsEmpty() {
+		return wpa.httpClient.GetConditional(ctx, url, stale.Validators)
+	}
+
+	return wpa.httpClient.Get(ctx, url)
+}
+
+// Keep the analysis of a page which was fully analyzed, partial results are computed again next time
+func (wpa *webPageAnalyzer) store(key string, analysis *dmpg.WebPageAnalysis, resp *http.Response, refreshed bool) {
+	if wpa.cache == nil {
+		return
+	}
+
+	analysis.Meta.Cache = dmpg.CacheMiss
+	if refreshed {
+		analysis.Meta.Cache = dmpg.CacheRefreshed
+	}
+	if analysis.Meta.Status != dmpg.StatusSuccess {
+		return
+	}
+
+	entry := &dmcch.Entry{Analysis: analysis, Validators: clihttp.ValidatorsOf(resp), StoredAt: time.Now()}
+	analysis.Meta.CachedAt = entry.StoredAt
+	analysis.Meta.ExpiresAt = entry.StoredAt.Add(wpa.cacheTTL)
+
+	if err := wpa.cache.Set(key, entry); err != nil {
+		log.Println("[ERROR] Error caching analysis: ", err.Error())
+	}
+}
+
+// Keep serving the stale analysis of a page which did not change, for another TTL
+func (wpa *webPageAnalyzer) revalidated(key string, stale *dmcch.Entry, resp *http.Response) *dmpg.WebPageAnalysis {
+	validators := clihttp.ValidatorsOf(resp)
+	if validators.IsEmpty() {
+		validators = stale.Validators
+	}
+
+	entry := &dmcch.Entry{Analysis: stale.Analysis, Validators: validators, StoredAt: time.Now()}
+	if err := wpa.cache.Set(key, entry); err != nil {
+		log.Println("[ERROR] Error caching analysis: ", err.Error())
+	}
+
+	return wpa.cached(entry, dmpg.CacheRevalidated)
+}
+
+// The cached analysis as served, the rest of its metadata describes the run which computed it
+func (wpa *webPageAnalyzer) cached(entry *dmcch.Entry, how string) *dmpg.WebPageAnalysis {
+	analysis := *entry.Analysis
+	analysis.Meta.Cache = how
+	analysis.Meta.CachedAt = entry.StoredAt
+	analysis.Meta.ExpiresAt = entry.StoredAt.Add(wpa.cacheTTL)
+
+	return &analysis
+}
+
+// Key of the cached analysis of a page, requests for the same page and the same sections computed with
+// the same options share it whatever the order of their lists
+func cacheKey(url string, opts *dmpg.AnalyzeOptions) string {
+	sorted := func(values []string) []string {
+		values = slices.Clone(values)
+		slices.Sort(values)
+		return slices.Compact(values)
+	}
+
+	parserOptions := opts.ParserOptions
+	parserOptions.FirstPartyDomains = sorted(parserOptions.FirstPartyDomains)
+
+	options, _ := json.Marshal(struct {
+		Parser  dmhtml.ParserOptions `json:"parser"`
+		Fields  []string             `json:"fields"`
+		Exclude []string             `json:"exclude"`
+	}{
+		Parser:  parserOptions,
+		Fields:  sorted(slices.Concat(opts.Fields, opts.Include)),
+		Exclude: sorted(opts.Exclude),
+	})
+
+	return utlurl.Normalize(url) + " " + string(options)
+}
+
+// Run an analyzer within its time limit. An analyzer which runs over or is cancelled gets a short grace
+// period to return the part of its section computed so far, then it is abandoned.
+func (wpa *webPageAnalyzer) run(ctx context.Context, analyzer dmanl.Analyzer, doc *dmanl.Document) (any, error) {
+	timeout := wpa.timeouts.For(analyzer.Name())
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type outcome struct {
+		section any
+		err     error
+	}
+
+	done := make(chan outcome, 1)
+	go func() {
+		section, err := analyzer.Analyze(ctx, doc)
+		done <- outcome{section: section, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err != nil && ctx.Err() != nil {
+			result.err = interruption(ctx, timeout)
+		}
+		return result.section, result.err
+	case <-ctx.Done():
+	}
+
+	select {
+	case result := <-done:
+		if result.err == nil {
+			return result.section, nil
+		}
+		return result.section, interruption(ctx, timeout)
+	case <-time.After(partialResultGrace):
+		return nil, interruption(ctx, timeout)
+	}
+}
+
+// Error of an analyzer stopped by its context
+func interruption(ctx context.Context, timeout time.Duration) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && timeout > 0 {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
 
-	return &dmpg.WebPageAnalysis{
-		HTMLVersion:  parser.GetHtmlVersion(),
-		Title:        parser.GetTitle(),
-		Headings:     parser.CountHeadingLevels(),
-		Links:        *parser.AnalyzeLinks(),
-		HasLoginForm: parser.HasLoginForm(),
-	}, nil
+	return ctx.Err()
 }
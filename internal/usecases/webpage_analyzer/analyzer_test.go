@@ -1,73 +1,86 @@
 package webpage_analyzer
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
+	neturl "net/url"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"go.uber.org/mock/gomock"
+	"golang.org/x/net/html"
 
+	dmanl "web-pages-analyzer/internal/domain/analyzer"
+	dmcch "web-pages-analyzer/internal/domain/cache"
 	clihttp "web-pages-analyzer/internal/domain/clients/http"
+	dmhst "web-pages-analyzer/internal/domain/history"
 	dmhtml "web-pages-analyzer/internal/domain/html"
+	dmpg "web-pages-analyzer/internal/domain/webpage"
+	cachemocks "web-pages-analyzer/internal/infrastructure/analysis_cache/mocks"
 	httpmocks "web-pages-analyzer/internal/infrastructure/clients/http/mocks"
+	hstmocks "web-pages-analyzer/internal/infrastructure/history_store/mocks"
 	htmlmocks "web-pages-analyzer/internal/infrastructure/html_parser/mocks"
 )
 
 // Helper methods
-func equalHeadings(a, b map[string]int) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	for k, v := range a {
-		if b[k] != v {
-			return false
-		}
+func newMockAnalyzer(ctrl *gomock.Controller, name string, section any, err error) *htmlmocks.MockAnalyzer {
+	analyzer := htmlmocks.NewMockAnalyzer(ctrl)
+	analyzer.EXPECT().Name().Return(name).AnyTimes()
+	analyzer.EXPECT().Analyze(gomock.Any(), gomock.Any()).Return(section, err).Times(1)
+	return analyzer
+}
+
+func newRegistry(t *testing.T, analyzers ...dmanl.Analyzer) *dmanl.Registry {
+	registry := dmanl.NewRegistry()
+	if err := registry.Register(analyzers...); err != nil {
+		t.Fatalf("failed to register analyzers: %v", err)
 	}
-	return true
+	return registry
+}
+
+type namedSection struct {
+	name    string
+	section any
 }
 
 func Test_Analyze_Success(t *testing.T) {
 	tests := []struct {
-		name                string
-		url                 string
-		responseBody        string
-		expectedHTMLVersion string
-		expectedTitle       string
-		expectedHeadings    map[string]int
-		expectedLinks       dmhtml.LinkAnalysis
-		expectedLoginForm   bool
+		name         string
+		url          string
+		responseBody string
+		sections     []namedSection
 	}{
 		{
-			name:                "HTML page 1",
-			url:                 "https://example.com",
-			responseBody:        "<html><head><title>Test Page</title></head><body><h1>Header-1</h1><form action='/login'><input type='password'/></form></body></html>",
-			expectedHTMLVersion: "HTML5",
-			expectedTitle:       "Test Page",
-			expectedHeadings:    map[string]int{"h1": 1, "h2": 0, "h3": 0, "h4": 0, "h5": 0, "h6": 0},
-			expectedLinks:       dmhtml.LinkAnalysis{Internal: 2, External: 1, Inaccessible: 0},
-			expectedLoginForm:   true,
+			name:         "HTML page 1",
+			url:          "https://example.com",
+			responseBody: "<html><head><title>Test Page</title></head><body><h1>Header-1</h1><form action='/login'><input type='password'/></form></body></html>",
+			sections: []namedSection{
+				{dmpg.HTMLVersionSection, "HTML5"},
+				{dmpg.TitleSection, "Test Page"},
+				{dmpg.HeadingsSection, map[string]int{"h1": 1, "h2": 0, "h3": 0, "h4": 0, "h5": 0, "h6": 0}},
+				{dmpg.LinksSection, dmhtml.LinkAnalysis{Internal: 2, External: 1, Inaccessible: 0}},
+				{dmpg.HasLoginFormSection, true},
+			},
 		},
 		{
-			name:                "HTML page 2",
-			url:                 "https://example.com",
-			responseBody:        "<!DOCTYPE html><html><head><title>Simple</title></head><body><h2>Content</h2></body></html>",
-			expectedHTMLVersion: "HTML5",
-			expectedTitle:       "Simple",
-			expectedHeadings:    map[string]int{"h1": 0, "h2": 1, "h3": 0, "h4": 0, "h5": 0, "h6": 0},
-			expectedLinks:       dmhtml.LinkAnalysis{Internal: 0, External: 0, Inaccessible: 0},
-			expectedLoginForm:   false,
+			name:         "HTML page 2",
+			url:          "https://example.com",
+			responseBody: "<!DOCTYPE html><html><head><title>Simple</title></head><body><h2>Content</h2></body></html>",
+			sections: []namedSection{
+				{dmpg.DoctypeSection, dmhtml.DoctypeAnalysis{Name: "html", DTD: "HTML5", RenderingMode: dmhtml.StandardsMode}},
+				{dmpg.TitleSection, "Simple"},
+				{dmpg.HasLoginFormSection, false},
+			},
 		},
 		{
-			name:                "HTML page 3",
-			url:                 "https://example.com",
-			responseBody:        "<html><head><title>Blog</title></head><body><h1>Main</h1><h2>Sub1</h2><h2>Sub2</h2><h3>Detail</h3></body></html>",
-			expectedHTMLVersion: "Unknown HTML Version",
-			expectedTitle:       "Blog",
-			expectedHeadings:    map[string]int{"h1": 1, "h2": 2, "h3": 1, "h4": 0, "h5": 0, "h6": 0},
-			expectedLinks:       dmhtml.LinkAnalysis{Internal: 1, External: 2, Inaccessible: 1},
-			expectedLoginForm:   false,
+			name:         "no analyzers registered",
+			url:          "https://example.com",
+			responseBody: "<html><head><title>Blog</title></head></html>",
 		},
 	}
 
@@ -78,29 +91,26 @@ func Test_Analyze_Success(t *testing.T) {
 
 			mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
 			mockHttpClient.EXPECT().
-				Get(tt.url).
+				Get(gomock.Any(), tt.url).
 				Return(&http.Response{
 					StatusCode: 200,
 					Body:       io.NopCloser(strings.NewReader(tt.responseBody)),
 				}, nil).
 				Times(1)
 
-			mockParserFactory := htmlmocks.NewMockParserFactory(ctrl)
-			mockParser := htmlmocks.NewMockHtmlParser(ctrl)
-
-			mockParserFactory.EXPECT().
-				CreateParser(gomock.Any(), tt.url, mockHttpClient).
-				Return(mockParser, nil).
+			mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+			mockDocumentParser.EXPECT().
+				Parse(gomock.Any(), tt.url, mockHttpClient, gomock.Any()).
+				Return(&dmanl.Document{URL: tt.url}, nil).
 				Times(1)
 
-			mockParser.EXPECT().GetHtmlVersion().Return(tt.expectedHTMLVersion).Times(1)
-			mockParser.EXPECT().GetTitle().Return(tt.expectedTitle).Times(1)
-			mockParser.EXPECT().CountHeadingLevels().Return(tt.expectedHeadings).Times(1)
-			mockParser.EXPECT().AnalyzeLinks().Return(&tt.expectedLinks).Times(1)
-			mockParser.EXPECT().HasLoginForm().Return(tt.expectedLoginForm).Times(1)
+			var analyzers []dmanl.Analyzer
+			for _, s := range tt.sections {
+				analyzers = append(analyzers, newMockAnalyzer(ctrl, s.name, s.section, nil))
+			}
 
-			analyzer := New(mockHttpClient, mockParserFactory)
-			result, err := analyzer.Analyze(tt.url)
+			analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t, analyzers...), dmanl.Timeouts{}, 0, nil, 0, nil)
+			result, err := analyzer.Analyze(context.Background(), tt.url, nil)
 
 			// Verify results
 			if err != nil {
@@ -111,34 +121,87 @@ func Test_Analyze_Success(t *testing.T) {
 				t.Fatal("expected result to be non-nil")
 			}
 
-			if result.HTMLVersion != tt.expectedHTMLVersion {
-				t.Errorf("expected HTML version %q, got %q", tt.expectedHTMLVersion, result.HTMLVersion)
+			if len(result.Names()) != len(tt.sections) {
+				t.Fatalf("expected sections %v, got %v", tt.sections, result.Names())
 			}
 
-			if result.Title != tt.expectedTitle {
-				t.Errorf("expected title %q, got %q", tt.expectedTitle, result.Title)
-			}
+			for i, s := range tt.sections {
+				if result.Names()[i] != s.name {
+					t.Errorf("expected section %d to be %q, got %q", i, s.name, result.Names()[i])
+				}
 
-			if !equalHeadings(result.Headings, tt.expectedHeadings) {
-				t.Errorf("expected headings %v, got %v", tt.expectedHeadings, result.Headings)
+				section, ok := result.Section(s.name)
+				if !ok || !reflect.DeepEqual(section, s.section) {
+					t.Errorf("expected %s section %+v, got %+v", s.name, s.section, section)
+				}
 			}
+		})
+	}
+}
 
-			if result.Links.Internal != tt.expectedLinks.Internal {
-				t.Errorf("expected %d internal links, got %d", tt.expectedLinks.Internal, result.Links.Internal)
-			}
+func Test_Analyze_Redirected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-			if result.Links.External != tt.expectedLinks.External {
-				t.Errorf("expected %d external links, got %d", tt.expectedLinks.External, result.Links.External)
-			}
+	startURL, _ := neturl.Parse("https://example.com")
+	finalURL, _ := neturl.Parse("https://www.example.com/home")
 
-			if result.Links.Inaccessible != tt.expectedLinks.Inaccessible {
-				t.Errorf("expected %d inaccessible links, got %d", tt.expectedLinks.Inaccessible, result.Links.Inaccessible)
-			}
+	mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+	mockHttpClient.EXPECT().
+		Get(gomock.Any(), "https://example.com").
+		Return(&http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(strings.NewReader("<html></html>")),
+			Request: &http.Request{
+				URL: finalURL,
+				Response: &http.Response{
+					StatusCode: http.StatusMovedPermanently,
+					Header:     http.Header{"Location": []string{finalURL.String()}},
+					Request:    &http.Request{URL: startURL},
+				},
+			},
+		}, nil).
+		Times(1)
+
+	// Links must be resolved against the final URL
+	mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+	mockDocumentParser.EXPECT().
+		Parse(gomock.Any(), "https://www.example.com/home", mockHttpClient, gomock.Any()).
+		Return(&dmanl.Document{URL: "https://www.example.com/home"}, nil).
+		Times(1)
 
-			if result.HasLoginForm != tt.expectedLoginForm {
-				t.Errorf("expected login form %v, got %v", tt.expectedLoginForm, result.HasLoginForm)
+	// Analyzers receive the response and the redirect chain with the document
+	redirectsAnalyzer := htmlmocks.NewMockAnalyzer(ctrl)
+	redirectsAnalyzer.EXPECT().Name().Return(dmpg.RedirectsSection).AnyTimes()
+	redirectsAnalyzer.EXPECT().
+		Analyze(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, doc *dmanl.Document) (any, error) {
+			if doc.Response == nil {
+				t.Error("expected the response to be handed to the analyzer")
 			}
-		})
+			return *doc.Redirects, nil
+		}).
+		Times(1)
+
+	analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t, redirectsAnalyzer), dmanl.Timeouts{}, 0, nil, 0, nil)
+	result, err := analyzer.Analyze(context.Background(), "https://example.com", nil)
+
+	// Verify results
+	if err != nil {
+		t.Fatalf("expected nil error: got %v", err)
+	}
+
+	redirects, err := dmpg.DecodeSection[clihttp.RedirectChain](result, dmpg.RedirectsSection)
+	if err != nil {
+		t.Fatalf("expected a redirects section: %v", err)
+	}
+
+	if redirects.FinalURL != "https://www.example.com/home" {
+		t.Errorf("expected final URL %q, got %q", "https://www.example.com/home", redirects.FinalURL)
+	}
+
+	if len(redirects.Hops) != 1 {
+		t.Errorf("expected 1 redirect hop, got %d", len(redirects.Hops))
 	}
 }
 
@@ -170,14 +233,14 @@ func Test_Analyze_HttpClientError(t *testing.T) {
 
 			mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
 			mockHttpClient.EXPECT().
-				Get(tt.url).
+				Get(gomock.Any(), tt.url).
 				Return(nil, tt.httpError).
 				Times(1)
 
-			mockParserFactory := htmlmocks.NewMockParserFactory(ctrl)
+			mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
 
-			analyzer := New(mockHttpClient, mockParserFactory)
-			result, err := analyzer.Analyze(tt.url)
+			analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t), dmanl.Timeouts{}, 0, nil, 0, nil)
+			result, err := analyzer.Analyze(context.Background(), tt.url, nil)
 
 			// Verify results
 			if err == nil {
@@ -195,7 +258,7 @@ func Test_Analyze_HttpClientError(t *testing.T) {
 	}
 }
 
-func TestAnalyze_ParserFactoryError(t *testing.T) {
+func TestAnalyze_DocumentParserError(t *testing.T) {
 	tests := []struct {
 		name          string
 		url           string
@@ -226,21 +289,21 @@ func TestAnalyze_ParserFactoryError(t *testing.T) {
 
 			mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
 			mockHttpClient.EXPECT().
-				Get(tt.url).
+				Get(gomock.Any(), tt.url).
 				Return(&http.Response{
 					StatusCode: 200,
 					Body:       io.NopCloser(strings.NewReader(tt.responseBody)),
 				}, nil).
 				Times(1)
 
-			mockParserFactory := htmlmocks.NewMockParserFactory(ctrl)
-			mockParserFactory.EXPECT().
-				CreateParser(gomock.Any(), tt.url, mockHttpClient).
+			mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+			mockDocumentParser.EXPECT().
+				Parse(gomock.Any(), tt.url, mockHttpClient, gomock.Any()).
 				Return(nil, tt.parserError).
 				Times(1)
 
-			analyzer := New(mockHttpClient, mockParserFactory)
-			result, err := analyzer.Analyze(tt.url)
+			analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t), dmanl.Timeouts{}, 0, nil, 0, nil)
+			result, err := analyzer.Analyze(context.Background(), tt.url, nil)
 
 			// Verify results
 			if err == nil {
@@ -257,3 +320,1250 @@ func TestAnalyze_ParserFactoryError(t *testing.T) {
 		})
 	}
 }
+
+func Test_Analyze_AnalyzerError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+	mockHttpClient.EXPECT().
+		Get(gomock.Any(), "https://example.com").
+		Return(&http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(strings.NewReader("<html></html>")),
+		}, nil).
+		Times(1)
+
+	mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+	mockDocumentParser.EXPECT().
+		Parse(gomock.Any(), "https://example.com", mockHttpClient, gomock.Any()).
+		Return(&dmanl.Document{}, nil).
+		Times(1)
+
+	registry := newRegistry(t,
+		newMockAnalyzer(ctrl, dmpg.TitleSection, "Title", nil),
+		newMockAnalyzer(ctrl, "broken", nil, errors.New("boom")),
+	)
+
+	analyzer := New(mockHttpClient, mockDocumentParser, registry, dmanl.Timeouts{}, 0, nil, 0, nil)
+	result, err := analyzer.Analyze(context.Background(), "https://example.com", nil)
+
+	// Verify results
+	if err != nil {
+		t.Fatalf("expected nil error: got %v", err)
+	}
+
+	// The failure only costs the section of the failed analyzer
+	if !reflect.DeepEqual(result.Names(), []string{dmpg.TitleSection}) {
+		t.Errorf("expected only the title section, got %v", result.Names())
+	}
+
+	expectedSections := map[string]dmpg.SectionStatus{
+		dmpg.TitleSection: {Status: dmpg.SectionOK},
+		"broken":          {Status: dmpg.SectionFailed, Error: "boom"},
+	}
+	if !reflect.DeepEqual(result.Meta.Sections, expectedSections) {
+		t.Errorf("expected section statuses %v, got %v", expectedSections, result.Meta.Sections)
+	}
+
+	if result.Meta.Status != dmpg.StatusPartial {
+		t.Errorf("expected status %q, got %q", dmpg.StatusPartial, result.Meta.Status)
+	}
+}
+
+func Test_Analyze_SelectedFields(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             *dmpg.AnalyzeOptions
+		expectedSections []string
+		expectedSkipped  []string
+	}{
+		{
+			name:             "fields",
+			opts:             &dmpg.AnalyzeOptions{Fields: []string{dmpg.TitleSection}},
+			expectedSections: []string{dmpg.TitleSection},
+			expectedSkipped:  []string{dmpg.HeadingsSection, dmpg.LinksSection},
+		},
+		{
+			name:             "fields and include are merged",
+			opts:             &dmpg.AnalyzeOptions{Fields: []string{dmpg.TitleSection}, Include: []string{dmpg.LinksSection}},
+			expectedSections: []string{dmpg.TitleSection, dmpg.LinksSection},
+			expectedSkipped:  []string{dmpg.HeadingsSection},
+		},
+		{
+			name:             "exclude",
+			opts:             &dmpg.AnalyzeOptions{Exclude: []string{dmpg.LinksSection}},
+			expectedSections: []string{dmpg.TitleSection, dmpg.HeadingsSection},
+			expectedSkipped:  []string{dmpg.LinksSection},
+		},
+		{
+			name:             "no selection",
+			opts:             &dmpg.AnalyzeOptions{},
+			expectedSections: []string{dmpg.TitleSection, dmpg.HeadingsSection, dmpg.LinksSection},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+			mockHttpClient.EXPECT().
+				Get(gomock.Any(), "https://example.com").
+				Return(&http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(strings.NewReader("<html></html>")),
+				}, nil).
+				Times(1)
+
+			mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+			mockDocumentParser.EXPECT().
+				Parse(gomock.Any(), "https://example.com", mockHttpClient, gomock.Any()).
+				Return(&dmanl.Document{}, nil).
+				Times(1)
+
+			// Skipped analyzers must never run
+			var analyzers []dmanl.Analyzer
+			for _, name := range []string{dmpg.TitleSection, dmpg.HeadingsSection, dmpg.LinksSection} {
+				analyzer := htmlmocks.NewMockAnalyzer(ctrl)
+				analyzer.EXPECT().Name().Return(name).AnyTimes()
+				calls := 0
+				if tt.opts.Selects(name) {
+					calls = 1
+				}
+				analyzer.EXPECT().Analyze(gomock.Any(), gomock.Any()).Return(name+" section", nil).Times(calls)
+				analyzers = append(analyzers, analyzer)
+			}
+
+			analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t, analyzers...), dmanl.Timeouts{}, 0, nil, 0, nil)
+			result, err := analyzer.Analyze(context.Background(), "https://example.com", tt.opts)
+
+			// Verify results
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+
+			if !reflect.DeepEqual(result.Names(), tt.expectedSections) {
+				t.Errorf("expected sections %v, got %v", tt.expectedSections, result.Names())
+			}
+
+			if !reflect.DeepEqual(result.Meta.Skipped, tt.expectedSkipped) {
+				t.Errorf("expected skipped sections %v, got %v", tt.expectedSkipped, result.Meta.Skipped)
+			}
+		})
+	}
+}
+
+func Test_Analyze_UnknownSection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The page is not fetched for an invalid selection
+	mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+	mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+
+	titleAnalyzer := htmlmocks.NewMockAnalyzer(ctrl)
+	titleAnalyzer.EXPECT().Name().Return(dmpg.TitleSection).AnyTimes()
+
+	analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t, titleAnalyzer), dmanl.Timeouts{}, 0, nil, 0, nil)
+	result, err := analyzer.Analyze(context.Background(), "https://example.com", &dmpg.AnalyzeOptions{Exclude: []string{"favicon"}})
+
+	if !errors.Is(err, dmpg.ErrUnknownSection) || !strings.Contains(err.Error(), `"favicon"`) {
+		t.Fatalf("expected an unknown section error for favicon, got %v", err)
+	}
+
+	if result != nil {
+		t.Fatal("expected nil result")
+	}
+}
+
+func newPageMocks(ctrl *gomock.Controller, url string) (*httpmocks.MockHttpClient, *htmlmocks.MockDocumentParser) {
+	mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+	mockHttpClient.EXPECT().
+		Get(gomock.Any(), url).
+		Return(&http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(strings.NewReader("<html></html>")),
+		}, nil).
+		Times(1)
+
+	mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+	mockDocumentParser.EXPECT().
+		Parse(gomock.Any(), url, mockHttpClient, gomock.Any()).
+		Return(&dmanl.Document{URL: url}, nil).
+		Times(1)
+
+	return mockHttpClient, mockDocumentParser
+}
+
+func newSlowAnalyzer(ctrl *gomock.Controller, name string, delay time.Duration) *htmlmocks.MockAnalyzer {
+	analyzer := htmlmocks.NewMockAnalyzer(ctrl)
+	analyzer.EXPECT().Name().Return(name).AnyTimes()
+	analyzer.EXPECT().
+		Analyze(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, doc *dmanl.Document) (any, error) {
+			time.Sleep(delay)
+			return name + " section", nil
+		}).
+		Times(1)
+	return analyzer
+}
+
+func Test_Analyze_Concurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHttpClient, mockDocumentParser := newPageMocks(ctrl, "https://example.com")
+
+	// Each analyzer waits for the other one to start, which only happens when they run concurrently
+	var started sync.WaitGroup
+	started.Add(2)
+
+	var analyzers []dmanl.Analyzer
+	for _, name := range []string{dmpg.TitleSection, dmpg.LinksSection} {
+		analyzer := htmlmocks.NewMockAnalyzer(ctrl)
+		analyzer.EXPECT().Name().Return(name).AnyTimes()
+		analyzer.EXPECT().
+			Analyze(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, doc *dmanl.Document) (any, error) {
+				started.Done()
+
+				waited := make(chan struct{})
+				go func() {
+					started.Wait()
+					close(waited)
+				}()
+
+				select {
+				case <-waited:
+					return name + " section", nil
+				case <-time.After(time.Second):
+					return nil, errors.New("analyzers did not run concurrently")
+				}
+			}).
+			Times(1)
+		analyzers = append(analyzers, analyzer)
+	}
+
+	analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t, analyzers...), dmanl.Timeouts{}, 0, nil, 0, nil)
+	result, err := analyzer.Analyze(context.Background(), "https://example.com", nil)
+
+	// Verify results
+	if err != nil {
+		t.Fatalf("expected nil error: got %v", err)
+	}
+
+	// Sections keep the registration order whichever analyzer finishes first
+	expectedSections := []string{dmpg.TitleSection, dmpg.LinksSection}
+	if !reflect.DeepEqual(result.Names(), expectedSections) {
+		t.Errorf("expected sections %v, got %v", expectedSections, result.Names())
+	}
+
+	for _, name := range expectedSections {
+		if _, ok := result.Meta.TimingsMs[name]; !ok {
+			t.Errorf("expected a timing for the %s analyzer, got %v", name, result.Meta.TimingsMs)
+		}
+	}
+}
+
+func Test_Analyze_Timeouts(t *testing.T) {
+	tests := []struct {
+		name           string
+		timeouts       dmanl.Timeouts
+		expectedStatus dmpg.SectionStatus
+	}{
+		{
+			name:           "default timeout exceeded",
+			timeouts:       dmanl.Timeouts{Default: 20 * time.Millisecond},
+			expectedStatus: dmpg.SectionStatus{Status: dmpg.SectionFailed, Error: "timed out after 20ms"},
+		},
+		{
+			name:           "analyzer timeout exceeded",
+			timeouts:       dmanl.Timeouts{Default: time.Second, PerAnalyzer: map[string]time.Duration{dmpg.LinksSection: 20 * time.Millisecond}},
+			expectedStatus: dmpg.SectionStatus{Status: dmpg.SectionFailed, Error: "timed out after 20ms"},
+		},
+		{
+			name:           "analyzer timeout overrides the default",
+			timeouts:       dmanl.Timeouts{Default: 20 * time.Millisecond, PerAnalyzer: map[string]time.Duration{dmpg.LinksSection: time.Second}},
+			expectedStatus: dmpg.SectionStatus{Status: dmpg.SectionOK},
+		},
+		{
+			name:           "no timeout",
+			expectedStatus: dmpg.SectionStatus{Status: dmpg.SectionOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockHttpClient, mockDocumentParser := newPageMocks(ctrl, "https://example.com")
+
+			// The slow analyzer ignores its context, it is abandoned when it runs over
+			registry := newRegistry(t,
+				newMockAnalyzer(ctrl, dmpg.TitleSection, "Title", nil),
+				newSlowAnalyzer(ctrl, dmpg.LinksSection, 300*time.Millisecond),
+			)
+
+			analyzer := New(mockHttpClient, mockDocumentParser, registry, tt.timeouts, 0, nil, 0, nil)
+			result, err := analyzer.Analyze(context.Background(), "https://example.com", nil)
+
+			// Verify results
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+
+			if status := result.Meta.Sections[dmpg.LinksSection]; status != tt.expectedStatus {
+				t.Errorf("expected links status %+v, got %+v", tt.expectedStatus, status)
+			}
+
+			if section, _ := result.Section(dmpg.TitleSection); section != "Title" {
+				t.Errorf("expected the title section, got %v", section)
+			}
+
+			if tt.expectedStatus.Status == dmpg.SectionFailed {
+				if _, ok := result.Section(dmpg.LinksSection); ok {
+					t.Error("expected no links section")
+				}
+				if result.Meta.TimingsMs[dmpg.LinksSection] >= 300 {
+					t.Errorf("expected the links analyzer to be abandoned, got %vms", result.Meta.TimingsMs[dmpg.LinksSection])
+				}
+				return
+			}
+
+			if section, _ := result.Section(dmpg.LinksSection); section != "links section" {
+				t.Errorf("expected the links section, got %v", section)
+			}
+
+			if result.Meta.TimingsMs[dmpg.LinksSection] < 300 {
+				t.Errorf("expected the links analyzer to take at least 300ms, got %vms", result.Meta.TimingsMs[dmpg.LinksSection])
+			}
+		})
+	}
+}
+
+// Returns how many of its items it got to before its context was done
+func newInterruptibleAnalyzer(name string, items int) dmanl.Analyzer {
+	return dmanl.NewAnalyzer(name, func(ctx context.Context, doc *dmanl.Document) (any, error) {
+		for i := 0; i < items; i++ {
+			select {
+			case <-ctx.Done():
+				return i, ctx.Err()
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		return items, nil
+	})
+}
+
+func Test_Analyze_PartialResults(t *testing.T) {
+	tests := []struct {
+		name             string
+		timeouts         dmanl.Timeouts
+		cancelAfter      time.Duration
+		expectedStatus   string
+		expectedSections map[string]dmpg.SectionStatus
+	}{
+		{
+			name:           "interrupted analyzer times out",
+			timeouts:       dmanl.Timeouts{PerAnalyzer: map[string]time.Duration{dmpg.LinksSection: 50 * time.Millisecond}},
+			expectedStatus: dmpg.StatusPartial,
+			expectedSections: map[string]dmpg.SectionStatus{
+				dmpg.TitleSection: {Status: dmpg.SectionOK},
+				dmpg.LinksSection: {Status: dmpg.SectionIncomplete, Error: "timed out after 50ms"},
+				"broken":          {Status: dmpg.SectionFailed, Error: "boom"},
+			},
+		},
+		{
+			name:           "analysis cancelled",
+			cancelAfter:    50 * time.Millisecond,
+			expectedStatus: dmpg.StatusPartial,
+			expectedSections: map[string]dmpg.SectionStatus{
+				dmpg.TitleSection: {Status: dmpg.SectionOK},
+				dmpg.LinksSection: {Status: dmpg.SectionIncomplete, Error: "context canceled"},
+				"broken":          {Status: dmpg.SectionFailed, Error: "boom"},
+			},
+		},
+		{
+			name:           "links analyzer completes",
+			expectedStatus: dmpg.StatusPartial,
+			expectedSections: map[string]dmpg.SectionStatus{
+				dmpg.TitleSection: {Status: dmpg.SectionOK},
+				dmpg.LinksSection: {Status: dmpg.SectionOK},
+				"broken":          {Status: dmpg.SectionFailed, Error: "boom"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockHttpClient, mockDocumentParser := newPageMocks(ctrl, "https://example.com")
+
+			registry := newRegistry(t,
+				newMockAnalyzer(ctrl, dmpg.TitleSection, "Title", nil),
+				newInterruptibleAnalyzer(dmpg.LinksSection, 20),
+				newMockAnalyzer(ctrl, "broken", nil, errors.New("boom")),
+			)
+
+			ctx := context.Background()
+			if tt.cancelAfter > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				time.AfterFunc(tt.cancelAfter, cancel)
+			}
+
+			analyzer := New(mockHttpClient, mockDocumentParser, registry, tt.timeouts, 0, nil, 0, nil)
+			result, err := analyzer.Analyze(ctx, "https://example.com", nil)
+
+			// Verify results
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+
+			if result.Meta.Status != tt.expectedStatus {
+				t.Errorf("expected status %q, got %q", tt.expectedStatus, result.Meta.Status)
+			}
+			if !reflect.DeepEqual(result.Meta.Sections, tt.expectedSections) {
+				t.Errorf("expected section statuses %v, got %v", tt.expectedSections, result.Meta.Sections)
+			}
+
+			// The interrupted analyzer keeps the part of its section it computed
+			section, ok := result.Section(dmpg.LinksSection)
+			if !ok {
+				t.Fatal("expected the links section")
+			}
+			if items := section.(int); (tt.expectedSections[dmpg.LinksSection].Status == dmpg.SectionOK) != (items == 20) {
+				t.Errorf("expected a links section matching its status, got %d of 20 items", items)
+			}
+		})
+	}
+}
+
+func Test_Analyze_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHttpClient, mockDocumentParser := newPageMocks(ctrl, "https://example.com")
+
+	registry := newRegistry(t,
+		newMockAnalyzer(ctrl, dmpg.TitleSection, nil, errors.New("no title")),
+		newMockAnalyzer(ctrl, dmpg.LinksSection, nil, errors.New("no links")),
+	)
+
+	analyzer := New(mockHttpClient, mockDocumentParser, registry, dmanl.Timeouts{}, 0, nil, 0, nil)
+	result, err := analyzer.Analyze(context.Background(), "https://example.com", nil)
+
+	// Verify results
+	if err != nil {
+		t.Fatalf("expected nil error: got %v", err)
+	}
+
+	if result.Meta.Status != dmpg.StatusFailure {
+		t.Errorf("expected status %q, got %q", dmpg.StatusFailure, result.Meta.Status)
+	}
+	if len(result.Names()) != 0 {
+		t.Errorf("expected no sections, got %v", result.Names())
+	}
+}
+
+// Counts the element nodes it sees
+type elementCounter struct {
+	elements int
+}
+
+func (c *elementCounter) Enter(node *html.Node) {
+	if node.Type == html.ElementNode {
+		c.elements++
+	}
+}
+
+func (c *elementCounter) Leave(*html.Node) {}
+
+func Test_Analyze_SingleTraversal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root, err := html.Parse(strings.NewReader("<html><head><title>Title</title></head><body><h1>Heading</h1></body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+	mockHttpClient.EXPECT().
+		Get(gomock.Any(), "https://example.com").
+		Return(&http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil).
+		Times(1)
+
+	mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+	mockDocumentParser.EXPECT().
+		Parse(gomock.Any(), "https://example.com", mockHttpClient, gomock.Any()).
+		Return(&dmanl.Document{URL: "https://example.com", Root: root}, nil).
+		Times(1)
+
+	// Both analyzers read the same visitor, which must be created and walked once for the two of them
+	var created int
+	spec := dmanl.VisitorSpec{Key: "elements", New: func(doc *dmanl.Document) dmanl.Visitor {
+		created++
+		return &elementCounter{}
+	}}
+
+	var analyzers []dmanl.Analyzer
+	for _, name := range []string{dmpg.TitleSection, dmpg.HeadingsSection} {
+		analyzers = append(analyzers, dmanl.NewVisitingAnalyzer(name, []dmanl.VisitorSpec{spec}, func(_ context.Context, doc *dmanl.Document) (any, error) {
+			return doc.Visitor(spec).(*elementCounter).elements, nil
+		}))
+	}
+
+	analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t, analyzers...), dmanl.Timeouts{}, 0, nil, 0, nil)
+	result, err := analyzer.Analyze(context.Background(), "https://example.com", nil)
+
+	// Verify results
+	if err != nil {
+		t.Fatalf("expected nil error: got %v", err)
+	}
+
+	if created != 1 {
+		t.Errorf("expected the shared visitor to be created once, got %d", created)
+	}
+
+	for _, name := range []string{dmpg.TitleSection, dmpg.HeadingsSection} {
+		if section, _ := result.Section(name); section != 5 {
+			t.Errorf("expected the %s analyzer to see 5 elements, got %v", name, section)
+		}
+	}
+}
+
+func Test_Analyze_Streamed(t *testing.T) {
+	tests := []struct {
+		name             string
+		contentLength    int64
+		bodySize         int
+		threshold        int64
+		expectedStreamed bool
+		expectedSections []string
+		expectedSkipped  []string
+	}{
+		{
+			name:             "content length above the threshold",
+			contentLength:    2048,
+			bodySize:         2048,
+			threshold:        1024,
+			expectedStreamed: true,
+			expectedSections: []string{dmpg.HeadingsSection, dmpg.RedirectsSection},
+			expectedSkipped:  []string{dmpg.ContentSection},
+		},
+		{
+			name:             "content length below the threshold",
+			contentLength:    512,
+			bodySize:         512,
+			threshold:        1024,
+			expectedSections: []string{dmpg.HeadingsSection, dmpg.ContentSection, dmpg.RedirectsSection},
+		},
+		{
+			name:             "unknown content length below the threshold",
+			contentLength:    -1,
+			bodySize:         1024,
+			threshold:        1024,
+			expectedSections: []string{dmpg.HeadingsSection, dmpg.ContentSection, dmpg.RedirectsSection},
+		},
+		{
+			name:             "unknown content length above the threshold",
+			contentLength:    -1,
+			bodySize:         1025,
+			threshold:        1024,
+			expectedStreamed: true,
+			expectedSections: []string{dmpg.HeadingsSection, dmpg.RedirectsSection},
+			expectedSkipped:  []string{dmpg.ContentSection},
+		},
+		{
+			name:             "content length below the size read",
+			contentLength:    512,
+			bodySize:         2048,
+			threshold:        1024,
+			expectedStreamed: true,
+			expectedSections: []string{dmpg.HeadingsSection, dmpg.RedirectsSection},
+			expectedSkipped:  []string{dmpg.ContentSection},
+		},
+		{
+			name:             "streaming disabled",
+			contentLength:    2048,
+			bodySize:         2048,
+			expectedSections: []string{dmpg.HeadingsSection, dmpg.ContentSection, dmpg.RedirectsSection},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+			mockHttpClient.EXPECT().
+				Get(gomock.Any(), "https://example.com").
+				Return(&http.Response{StatusCode: 200, ContentLength: tt.contentLength, Body: io.NopCloser(strings.NewReader(strings.Repeat("a", tt.bodySize)))}, nil).
+				Times(1)
+
+			root, err := html.Parse(strings.NewReader("<h1>Heading</h1>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The bytes read to decide whether to stream are handed over with the rest of the body
+			readBody := func(body io.Reader) {
+				if data, err := io.ReadAll(body); err != nil || len(data) != tt.bodySize {
+					t.Errorf("expected the document parser to read %d bytes, got %d (%v)", tt.bodySize, len(data), err)
+				}
+			}
+
+			mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+			if tt.expectedStreamed {
+				mockDocumentParser.EXPECT().
+					Stream(gomock.Any(), "https://example.com", mockHttpClient, gomock.Any()).
+					DoAndReturn(func(body io.Reader, _ string, _ clihttp.HttpClient, _ *dmhtml.ParserOptions) (*dmanl.Document, error) {
+						readBody(body)
+						return &dmanl.Document{URL: "https://example.com", Stream: func(visitors ...dmanl.Visitor) error {
+							dmanl.Walk(root, visitors...)
+							return nil
+						}}, nil
+					}).
+					Times(1)
+			} else {
+				mockDocumentParser.EXPECT().
+					Parse(gomock.Any(), "https://example.com", mockHttpClient, gomock.Any()).
+					DoAndReturn(func(body io.Reader, _ string, _ clihttp.HttpClient, _ *dmhtml.ParserOptions) (*dmanl.Document, error) {
+						readBody(body)
+						return &dmanl.Document{URL: "https://example.com", Root: root}, nil
+					}).
+					Times(1)
+			}
+
+			spec := dmanl.VisitorSpec{Key: "elements", Streaming: true, New: func(doc *dmanl.Document) dmanl.Visitor {
+				return &elementCounter{}
+			}}
+
+			registry := newRegistry(t,
+				dmanl.NewVisitingAnalyzer(dmpg.HeadingsSection, []dmanl.VisitorSpec{spec}, func(_ context.Context, doc *dmanl.Document) (any, error) {
+					return doc.Visitor(spec).(*elementCounter).elements, nil
+				}),
+				dmanl.NewAnalyzer(dmpg.ContentSection, func(_ context.Context, doc *dmanl.Document) (any, error) {
+					return "content", nil
+				}),
+				dmanl.NewResponseAnalyzer(dmpg.RedirectsSection, func(_ context.Context, doc *dmanl.Document) (any, error) {
+					return doc.Redirects.FinalURL, nil
+				}),
+			)
+
+			analyzer := New(mockHttpClient, mockDocumentParser, registry, dmanl.Timeouts{}, tt.threshold, nil, 0, nil)
+			result, err := analyzer.Analyze(context.Background(), "https://example.com", nil)
+
+			// Verify results
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+
+			if result.Meta.Streamed != tt.expectedStreamed {
+				t.Errorf("expected streamed %v, got %v", tt.expectedStreamed, result.Meta.Streamed)
+			}
+			if !reflect.DeepEqual(result.Names(), tt.expectedSections) {
+				t.Errorf("expected sections %v, got %v", tt.expectedSections, result.Names())
+			}
+			if !reflect.DeepEqual(result.Meta.Skipped, tt.expectedSkipped) {
+				t.Errorf("expected skipped sections %v, got %v", tt.expectedSkipped, result.Meta.Skipped)
+			}
+
+			// html, head, body and h1
+			if section, _ := result.Section(dmpg.HeadingsSection); section != 4 {
+				t.Errorf("expected the visitor to see 4 elements, got %v", section)
+			}
+		})
+	}
+}
+
+func newCacheEntry(title string, age time.Duration, validators clihttp.Validators) *dmcch.Entry {
+	analysis := dmpg.NewWebPageAnalysis()
+	analysis.Set(dmpg.TitleSection, title)
+	analysis.Meta.Status = dmpg.StatusSuccess
+
+	return &dmcch.Entry{Analysis: analysis, Validators: validators, StoredAt: time.Now().Add(-age)}
+}
+
+func Test_Analyze_Cache(t *testing.T) {
+	const ttl = 10 * time.Minute
+	validators := clihttp.Validators{ETag: `"v1"`}
+
+	tests := []struct {
+		name           string
+		cached         *dmcch.Entry
+		forceRefresh   bool
+		fetch          string // "get", "conditional" or none
+		notModified    bool
+		analyzerErr    error
+		expectedStored bool
+		expectedCache  string
+		expectedTitle  string
+	}{
+		{
+			name:           "miss analyzes and stores the page",
+			fetch:          "get",
+			expectedStored: true,
+			expectedCache:  dmpg.CacheMiss,
+			expectedTitle:  "Fresh",
+		},
+		{
+			name:          "fresh entry is served without fetching",
+			cached:        newCacheEntry("Cached", time.Minute, validators),
+			expectedCache: dmpg.CacheHit,
+			expectedTitle: "Cached",
+		},
+		{
+			name:           "stale entry of an unchanged page is revalidated",
+			cached:         newCacheEntry("Cached", time.Hour, validators),
+			fetch:          "conditional",
+			notModified:    true,
+			expectedStored: true,
+			expectedCache:  dmpg.CacheRevalidated,
+			expectedTitle:  "Cached",
+		},
+		{
+			name:           "stale entry of a changed page is replaced",
+			cached:         newCacheEntry("Cached", time.Hour, validators),
+			fetch:          "conditional",
+			expectedStored: true,
+			expectedCache:  dmpg.CacheMiss,
+			expectedTitle:  "Fresh",
+		},
+		{
+			name:           "stale entry without validators is replaced",
+			cached:         newCacheEntry("Cached", time.Hour, clihttp.Validators{}),
+			fetch:          "get",
+			expectedStored: true,
+			expectedCache:  dmpg.CacheMiss,
+			expectedTitle:  "Fresh",
+		},
+		{
+			name:           "force refresh ignores a fresh entry",
+			forceRefresh:   true,
+			fetch:          "get",
+			expectedStored: true,
+			expectedCache:  dmpg.CacheRefreshed,
+			expectedTitle:  "Fresh",
+		},
+		{
+			name:          "partial results are not stored",
+			fetch:         "get",
+			analyzerErr:   errors.New("interrupted"),
+			expectedCache: dmpg.CacheMiss,
+			expectedTitle: "Fresh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			url := "https://example.com/"
+			page := func(statusCode int) *http.Response {
+				return &http.Response{
+					StatusCode: statusCode,
+					Header:     http.Header{"Etag": []string{`"v2"`}},
+					Body:       io.NopCloser(strings.NewReader("<html></html>")),
+				}
+			}
+
+			mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+			switch tt.fetch {
+			case "get":
+				mockHttpClient.EXPECT().Get(gomock.Any(), url).Return(page(http.StatusOK), nil).Times(1)
+			case "conditional":
+				statusCode := http.StatusOK
+				if tt.notModified {
+					statusCode = http.StatusNotModified
+				}
+				mockHttpClient.EXPECT().GetConditional(gomock.Any(), url, validators).Return(page(statusCode), nil).Times(1)
+			}
+
+			analyzed := tt.fetch != "" && !tt.notModified
+
+			mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+			titleAnalyzer := htmlmocks.NewMockAnalyzer(ctrl)
+			titleAnalyzer.EXPECT().Name().Return(dmpg.TitleSection).AnyTimes()
+			if analyzed {
+				mockDocumentParser.EXPECT().
+					Parse(gomock.Any(), url, mockHttpClient, gomock.Any()).
+					Return(&dmanl.Document{URL: url}, nil).
+					Times(1)
+				titleAnalyzer.EXPECT().Analyze(gomock.Any(), gomock.Any()).Return("Fresh", tt.analyzerErr).Times(1)
+			}
+
+			var stored *dmcch.Entry
+			mockCache := cachemocks.NewMockCache(ctrl)
+			if !tt.forceRefresh {
+				mockCache.EXPECT().Get(gomock.Any()).Return(tt.cached, tt.cached != nil).Times(1)
+			}
+			if tt.expectedStored {
+				mockCache.EXPECT().
+					Set(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ string, entry *dmcch.Entry) error {
+						stored = entry
+						return nil
+					}).
+					Times(1)
+			}
+
+			analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t, titleAnalyzer), dmanl.Timeouts{}, 0, mockCache, ttl, nil)
+			result, err := analyzer.Analyze(context.Background(), url, &dmpg.AnalyzeOptions{ForceRefresh: tt.forceRefresh})
+
+			// Verify results
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+
+			if result.Meta.Cache != tt.expectedCache {
+				t.Errorf("expected cache %q, got %q", tt.expectedCache, result.Meta.Cache)
+			}
+			if title, _ := result.Section(dmpg.TitleSection); title != tt.expectedTitle {
+				t.Errorf("expected title %q, got %v", tt.expectedTitle, title)
+			}
+
+			if !tt.expectedStored {
+				if tt.cached == nil && !result.Meta.ExpiresAt.IsZero() {
+					t.Errorf("expected no expiry for an analysis which was not cached, got %v", result.Meta.ExpiresAt)
+				}
+				return
+			}
+
+			if time.Since(stored.StoredAt) > time.Second {
+				t.Errorf("expected the entry to be stored now, got %v", stored.StoredAt)
+			}
+			if stored.Validators.ETag != `"v2"` {
+				t.Errorf("expected the validators of the last response, got %+v", stored.Validators)
+			}
+			if !result.Meta.ExpiresAt.Equal(stored.StoredAt.Add(ttl)) {
+				t.Errorf("expected expiry %v, got %v", stored.StoredAt.Add(ttl), result.Meta.ExpiresAt)
+			}
+		})
+	}
+}
+
+func Test_CacheKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		url1, url2    string
+		opts1, opts2  dmpg.AnalyzeOptions
+		expectedEqual bool
+	}{
+		{
+			name:          "spellings of the same URL",
+			url1:          "HTTPS://Example.com:443/?b=2&a=1#top",
+			url2:          "https://example.com/?a=1&b=2",
+			expectedEqual: true,
+		},
+		{
+			name:          "fields in another order and through include",
+			url1:          "https://example.com",
+			url2:          "https://example.com",
+			opts1:         dmpg.AnalyzeOptions{Fields: []string{"title", "links"}},
+			opts2:         dmpg.AnalyzeOptions{Fields: []string{"links"}, Include: []string{"title"}},
+			expectedEqual: true,
+		},
+		{
+			name:          "force refresh shares the entry",
+			url1:          "https://example.com",
+			url2:          "https://example.com",
+			opts2:         dmpg.AnalyzeOptions{ForceRefresh: true},
+			expectedEqual: true,
+		},
+		{
+			name:  "other sections",
+			url1:  "https://example.com",
+			url2:  "https://example.com",
+			opts1: dmpg.AnalyzeOptions{Fields: []string{"title"}},
+		},
+		{
+			name:  "other parser options",
+			url1:  "https://example.com",
+			url2:  "https://example.com",
+			opts2: dmpg.AnalyzeOptions{ParserOptions: dmhtml.ParserOptions{CheckResources: true}},
+		},
+		{
+			name: "other paths",
+			url1: "https://example.com/a",
+			url2: "https://example.com/b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key1, key2 := cacheKey(tt.url1, &tt.opts1), cacheKey(tt.url2, &tt.opts2)
+			if (key1 == key2) != tt.expectedEqual {
+				t.Errorf("expected keys equal to be %v, got %q and %q", tt.expectedEqual, key1, key2)
+			}
+		})
+	}
+}
+
+// Blocks until released, or returns a partial section once interrupted
+func newBlockingAnalyzer(name string, release <-chan struct{}) dmanl.Analyzer {
+	return dmanl.NewAnalyzer(name, func(ctx context.Context, doc *dmanl.Document) (any, error) {
+		select {
+		case <-release:
+			return "complete", nil
+		case <-ctx.Done():
+			return "partial", ctx.Err()
+		}
+	})
+}
+
+func newCoalescingMocks(ctrl *gomock.Controller, url string, fetches int) (*httpmocks.MockHttpClient, *htmlmocks.MockDocumentParser) {
+	mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+	mockHttpClient.EXPECT().
+		Get(gomock.Any(), url).
+		DoAndReturn(func(context.Context, string) (*http.Response, error) {
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("<html></html>"))}, nil
+		}).
+		Times(fetches)
+
+	mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+	mockDocumentParser.EXPECT().
+		Parse(gomock.Any(), url, mockHttpClient, gomock.Any()).
+		DoAndReturn(func(io.Reader, string, clihttp.HttpClient, *dmhtml.ParserOptions) (*dmanl.Document, error) {
+			return &dmanl.Document{URL: url}, nil
+		}).
+		Times(fetches)
+
+	return mockHttpClient, mockDocumentParser
+}
+
+// Wait until the requests wait for an analysis, and give the last one the time to join it
+func waitForWaiters(t *testing.T, analyzer dmpg.WebPageAnalyzer, waiters int) {
+	wpa := analyzer.(*webPageAnalyzer)
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		wpa.mu.Lock()
+		count := 0
+		for _, f := range wpa.waiting {
+			count += f.waiters
+		}
+		wpa.mu.Unlock()
+
+		if count == waiters {
+			time.Sleep(20 * time.Millisecond)
+			return
+		}
+	}
+
+	t.Fatalf("expected %d waiting requests", waiters)
+}
+
+func Test_Analyze_Coalesced(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            []*dmpg.AnalyzeOptions
+		expectedFetches int
+		expectedShared  bool
+	}{
+		{
+			name:            "identical requests share one analysis",
+			opts:            []*dmpg.AnalyzeOptions{nil, nil, {}, {}, nil},
+			expectedFetches: 1,
+			expectedShared:  true,
+		},
+		{
+			name:            "requests for other sections are analyzed apart",
+			opts:            []*dmpg.AnalyzeOptions{nil, {Exclude: []string{"other"}}},
+			expectedFetches: 2,
+		},
+		{
+			name:            "forced refreshes are not shared with plain requests",
+			opts:            []*dmpg.AnalyzeOptions{nil, {ForceRefresh: true}},
+			expectedFetches: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			url := "https://example.com"
+			mockHttpClient, mockDocumentParser := newCoalescingMocks(ctrl, url, tt.expectedFetches)
+
+			release := make(chan struct{})
+			registry := newRegistry(t,
+				newBlockingAnalyzer(dmpg.TitleSection, release),
+				dmanl.NewAnalyzer("other", func(context.Context, *dmanl.Document) (any, error) { return "other", nil }),
+			)
+
+			analyzer := New(mockHttpClient, mockDocumentParser, registry, dmanl.Timeouts{}, 0, nil, 0, nil)
+
+			results := make([]*dmpg.WebPageAnalysis, len(tt.opts))
+			errs := make([]error, len(tt.opts))
+			var wg sync.WaitGroup
+			for i, opts := range tt.opts {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					results[i], errs[i] = analyzer.Analyze(context.Background(), url, opts)
+				}()
+			}
+
+			waitForWaiters(t, analyzer, len(tt.opts))
+			close(release)
+			wg.Wait()
+
+			// Verify results
+			for i, result := range results {
+				if errs[i] != nil {
+					t.Fatalf("expected nil error: got %v", errs[i])
+				}
+				if title, _ := result.Section(dmpg.TitleSection); title != "complete" {
+					t.Errorf("expected the complete title section, got %v", title)
+				}
+				if result.Meta.Shared != tt.expectedShared {
+					t.Errorf("expected shared to be %v, got %v", tt.expectedShared, result.Meta.Shared)
+				}
+			}
+		})
+	}
+}
+
+func Test_Analyze_CoalescedCancellation(t *testing.T) {
+	tests := []struct {
+		name           string
+		cancelled      []bool // Whether each waiter is cancelled, in the order they joined
+		expectedTitles []any  // Title section received by each waiter, nil for an error
+	}{
+		{
+			name:           "cancelled waiter leaves, the analysis goes on for the others",
+			cancelled:      []bool{true, false},
+			expectedTitles: []any{nil, "complete"},
+		},
+		{
+			name:           "last waiter to leave cancels the analysis and gets its partial result",
+			cancelled:      []bool{true, true},
+			expectedTitles: []any{nil, "partial"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			url := "https://example.com"
+			mockHttpClient, mockDocumentParser := newCoalescingMocks(ctrl, url, 1)
+
+			release := make(chan struct{})
+			analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t, newBlockingAnalyzer(dmpg.TitleSection, release)), dmanl.Timeouts{}, 0, nil, 0, nil)
+
+			results := make([]*dmpg.WebPageAnalysis, len(tt.cancelled))
+			errs := make([]error, len(tt.cancelled))
+			done := make([]chan struct{}, len(tt.cancelled))
+			cancels := make([]context.CancelFunc, len(tt.cancelled))
+			for i := range tt.cancelled {
+				var ctx context.Context
+				ctx, cancels[i] = context.WithCancel(context.Background())
+				defer cancels[i]()
+
+				done[i] = make(chan struct{})
+				go func() {
+					defer close(done[i])
+					results[i], errs[i] = analyzer.Analyze(ctx, url, nil)
+				}()
+				waitForWaiters(t, analyzer, i+1)
+			}
+
+			// Waiters are cancelled in the order they joined, each one returns before the next is cancelled
+			for i, cancelled := range tt.cancelled {
+				if !cancelled {
+					continue
+				}
+				cancels[i]()
+				select {
+				case <-done[i]:
+				case <-time.After(time.Second):
+					t.Fatalf("expected waiter %d to return once cancelled", i)
+				}
+			}
+			close(release)
+			for i := range done {
+				<-done[i]
+			}
+
+			// Verify results
+			for i, expectedTitle := range tt.expectedTitles {
+				if expectedTitle == nil {
+					if !errors.Is(errs[i], context.Canceled) {
+						t.Errorf("expected waiter %d to fail with %v, got %v", i, context.Canceled, errs[i])
+					}
+					continue
+				}
+
+				if errs[i] != nil {
+					t.Fatalf("expected nil error for waiter %d: got %v", i, errs[i])
+				}
+				if title, _ := results[i].Section(dmpg.TitleSection); title != expectedTitle {
+					t.Errorf("expected waiter %d to get title %v, got %v", i, expectedTitle, title)
+				}
+			}
+		})
+	}
+}
+
+func Test_Analyze_CoalescedCancellation_BlockedFetch(t *testing.T) {
+	tests := []struct {
+		name    string
+		waiters int
+	}{
+		{
+			name:    "lone waiter returns once cancelled",
+			waiters: 1,
+		},
+		{
+			name:    "last waiter to leave returns once cancelled",
+			waiters: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			url := "https://example.com"
+
+			// The page never answers, the fetch only ends with its context
+			mockHttpClient := httpmocks.NewMockHttpClient(ctrl)
+			mockHttpClient.EXPECT().
+				Get(gomock.Any(), url).
+				DoAndReturn(func(ctx context.Context, _ string) (*http.Response, error) {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}).
+				Times(1)
+			mockDocumentParser := htmlmocks.NewMockDocumentParser(ctrl)
+
+			analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t, newBlockingAnalyzer(dmpg.TitleSection, nil)), dmanl.Timeouts{}, 0, nil, 0, nil)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			errs := make(chan error, tt.waiters)
+			for i := 0; i < tt.waiters; i++ {
+				go func() {
+					_, err := analyzer.Analyze(ctx, url, nil)
+					errs <- err
+				}()
+				waitForWaiters(t, analyzer, i+1)
+			}
+			cancel()
+
+			// Verify results
+			for i := 0; i < tt.waiters; i++ {
+				select {
+				case err := <-errs:
+					if !errors.Is(err, context.Canceled) {
+						t.Errorf("expected %v, got %v", context.Canceled, err)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("expected every waiter to return once cancelled")
+				}
+			}
+		})
+	}
+}
+
+func Test_Analyze_History(t *testing.T) {
+	tests := []struct {
+		name              string
+		cached            bool
+		saveErr           error
+		expectedRecorded  bool
+		expectedHistoryID string
+	}{
+		{
+			name:              "computed analysis is recorded",
+			expectedRecorded:  true,
+			expectedHistoryID: "record",
+		},
+		{
+			name:             "analysis is returned when it cannot be recorded",
+			saveErr:          errors.New("disk full"),
+			expectedRecorded: true,
+		},
+		{
+			name:   "cached analysis is not recorded again",
+			cached: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			url := "https://example.com"
+			opts := &dmpg.AnalyzeOptions{Fields: []string{dmpg.TitleSection}}
+
+			var mockHttpClient *httpmocks.MockHttpClient
+			var mockDocumentParser *htmlmocks.MockDocumentParser
+			titleAnalyzer := htmlmocks.NewMockAnalyzer(ctrl)
+			titleAnalyzer.EXPECT().Name().Return(dmpg.TitleSection).AnyTimes()
+
+			mockCache := cachemocks.NewMockCache(ctrl)
+			if tt.cached {
+				mockHttpClient = httpmocks.NewMockHttpClient(ctrl)
+				mockDocumentParser = htmlmocks.NewMockDocumentParser(ctrl)
+				mockCache.EXPECT().Get(gomock.Any()).Return(newCacheEntry("Cached", 0, clihttp.Validators{}), true).Times(1)
+			} else {
+				mockHttpClient, mockDocumentParser = newPageMocks(ctrl, url)
+				titleAnalyzer.EXPECT().Analyze(gomock.Any(), gomock.Any()).Return("Title", nil).Times(1)
+				mockCache.EXPECT().Get(gomock.Any()).Return(nil, false).Times(1)
+				mockCache.EXPECT().Set(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			}
+
+			var recorded *dmhst.Record
+			mockStore := hstmocks.NewMockStore(ctrl)
+			if tt.expectedRecorded {
+				mockStore.EXPECT().
+					Save(gomock.Any()).
+					DoAndReturn(func(record *dmhst.Record) error {
+						if tt.saveErr == nil {
+							record.ID = "record"
+						}
+						recorded = record
+						return tt.saveErr
+					}).
+					Times(1)
+			}
+
+			analyzer := New(mockHttpClient, mockDocumentParser, newRegistry(t, titleAnalyzer), dmanl.Timeouts{}, 0, mockCache, time.Minute, mockStore)
+			result, err := analyzer.Analyze(context.Background(), url, opts)
+
+			// Verify results
+			if err != nil {
+				t.Fatalf("expected nil error: got %v", err)
+			}
+
+			if result.Meta.HistoryID != tt.expectedHistoryID {
+				t.Errorf("expected history ID %q, got %q", tt.expectedHistoryID, result.Meta.HistoryID)
+			}
+
+			if !tt.expectedRecorded {
+				return
+			}
+			if recorded.URL != url || !reflect.DeepEqual(recorded.Options, *opts) || recorded.Status != dmpg.StatusSuccess {
+				t.Errorf("expected a record of the request, got %+v", recorded)
+			}
+			if title, _ := recorded.Analysis.Section(dmpg.TitleSection); title != "Title" {
+				t.Errorf("expected the recorded analysis, got title %v", title)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 	webpage "web-pages-analyzer/internal/domain/webpage"
 
@@ -41,16 +42,16 @@ func (m *MockWebPageAnalyzer) EXPECT() *MockWebPageAnalyzerMockRecorder {
 }
 
 // Analyze mocks base method.
-func (m *MockWebPageAnalyzer) Analyze(url string) (*webpage.WebPageAnalysis, error) {
+func (m *MockWebPageAnalyzer) Analyze(ctx context.Context, url string, opts *webpage.AnalyzeOptions) (*webpage.WebPageAnalysis, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Analyze", url)
+	ret := m.ctrl.Call(m, "Analyze", ctx, url, opts)
 	ret0, _ := ret[0].(*webpage.WebPageAnalysis)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Analyze indicates an expected call of Analyze.
-func (mr *MockWebPageAnalyzerMockRecorder) Analyze(url any) *gomock.Call {
+func (mr *MockWebPageAnalyzerMockRecorder) Analyze(ctx, url, opts any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Analyze", reflect.TypeOf((*MockWebPageAnalyzer)(nil).Analyze), url)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Analyze", reflect.TypeOf((*MockWebPageAnalyzer)(nil).Analyze), ctx, url, opts)
 }
@@ -0,0 +1,56 @@
+package url
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Validate that the string is an absolute HTTP or HTTPS URL
+func ValidateHTTPURL(urlStr string) error {
+	if urlStr == "" {
+		return fmt.Errorf("URL is required")
+	}
+
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return fmt.Errorf("invalid URL format: %s", err.Error())
+	}
+
+	scheme := strings.ToLower(parsedURL.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return fmt.Errorf("only HTTP and HTTPS are supported")
+	}
+
+	if parsedURL.Host == "" {
+		return fmt.Errorf("host cannot be empty")
+	}
+
+	return nil
+}
+
+// Normalize a URL so that the spellings of the same page compare equal: the scheme and host are lower
+// cased, default ports and fragments are dropped, an empty path becomes "/" and query parameters are
+// sorted. A URL which cannot be parsed is returned as is.
+func Normalize(urlStr string) string {
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return urlStr
+	}
+
+	parsedURL.Scheme = strings.ToLower(parsedURL.Scheme)
+	parsedURL.Host = strings.ToLower(parsedURL.Host)
+	if port := parsedURL.Port(); (parsedURL.Scheme == "http" && port == "80") || (parsedURL.Scheme == "https" && port == "443") {
+		parsedURL.Host = strings.TrimSuffix(parsedURL.Host, ":"+port)
+	}
+	if parsedURL.Path == "" {
+		parsedURL.Path = "/"
+	}
+	parsedURL.Fragment = ""
+	parsedURL.RawFragment = ""
+	if parsedURL.RawQuery != "" {
+		parsedURL.RawQuery = parsedURL.Query().Encode()
+	}
+
+	return parsedURL.String()
+}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"os"
+
+	"web-pages-analyzer/internal/cmd/compare"
 	"web-pages-analyzer/internal/cmd/server"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		os.Exit(compare.Run(os.Args[2:]))
+	}
+
 	server.Start()
 }
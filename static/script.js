@@ -59,12 +59,19 @@ async function analyzeURL(url) {
             body: JSON.stringify({ url })
         });
 
-        if (!resp.ok) {
-            const text = await resp.text();
+        const text = await resp.text();
+
+        // A failed analysis still comes with the status of every section
+        let result = null;
+        try {
+            result = JSON.parse(text);
+        } catch (_) {
+            result = null;
+        }
+        if (!resp.ok && !(result && result.meta && result.meta.status)) {
             throw new Error(`${resp.status}: ${text}`);
         }
 
-        const result = await resp.json();
         displayResults(result);
     } catch (err) {
         showError(`Analysis failed: ${err.message}`);
@@ -101,17 +108,71 @@ function hideError() {
 function displayResults(data) {
     resultsContainer.innerHTML = '';
 
-    resultsContainer.appendChild(createResultCard('HTML Version', data.html_version));
-    resultsContainer.appendChild(createResultCard('Page Title', data.title || 'No title found'));
+    // Sections whose analyzer failed are left out of the response, the status card says why
+    const status = createStatusCard(data.meta);
+    if (status) resultsContainer.appendChild(status);
+
+    if (data.html_version !== undefined) resultsContainer.appendChild(createResultCard('HTML Version', data.html_version));
+    if (data.doctype) resultsContainer.appendChild(createResultCard('DOCTYPE', `${data.doctype.dtd} (${data.doctype.rendering_mode} mode)`));
+    if (data.title !== undefined) resultsContainer.appendChild(createResultCard('Page Title', data.title || 'No title found'));
+
+    if (data.headings) resultsContainer.appendChild(createHeadingsCard(data.headings));
 
-    resultsContainer.appendChild(createHeadingsCard(data.headings));
+    if (data.links) resultsContainer.appendChild(createLinksCard(data.links));
+    if (data.has_login_form !== undefined) resultsContainer.appendChild(createLoginFormCard(data.has_login_form));
+    if (data.forms) resultsContainer.appendChild(createFormsCard(data.forms));
+    if (data.redirects) resultsContainer.appendChild(createRedirectsCard(data.redirects));
+    if (data.security) resultsContainer.appendChild(createSecurityCard(data.security));
 
-    resultsContainer.appendChild(createLinksCard(data.links));
-    resultsContainer.appendChild(createLoginFormCard(data.has_login_form));
+    // Sections which need the document tree are skipped for pages too large to parse
+    if (data.mixed_content) resultsContainer.appendChild(createMixedContentCard(data.mixed_content));
+    if (data.resources) resultsContainer.appendChild(createResourcesCard(data.resources));
+    if (data.technologies) resultsContainer.appendChild(createTechnologiesCard(data.technologies));
+    if (data.trackers) resultsContainer.appendChild(createTrackersCard(data.trackers));
+    if (data.content) resultsContainer.appendChild(createContentCard(data.content));
+
+    if (data.meta && data.meta.streamed) {
+        resultsContainer.appendChild(createResultCard('Large Page',
+            `Streamed instead of parsed, not analyzed: ${escapeHtml((data.meta.skipped || []).join(', '))}`));
+    }
+
+    if (data.meta && (data.meta.cache === 'hit' || data.meta.cache === 'revalidated')) {
+        resultsContainer.appendChild(createResultCard('Cached Analysis',
+            `Analysis cached at ${escapeHtml(new Date(data.meta.cached_at).toLocaleString())}`));
+    }
 
     showResults();
 }
 
+function createStatusCard(meta) {
+    if (!meta || !meta.status || meta.status === 'success') return null;
+
+    const problems = Object.entries(meta.sections || {})
+        .filter(([, section]) => section.status !== 'ok')
+        .map(([name, section]) => `
+            <div class="link-row">
+                <div class="link-row-label">${escapeHtml(name)} (${escapeHtml(section.status)})</div>
+                <div class="link-row-value">${escapeHtml(section.error || '')}</div>
+            </div>`)
+        .join('');
+
+    const el = document.createElement('div');
+    el.className = 'result-card';
+    el.innerHTML = `
+        <h3>Analysis Status</h3>
+        <div class="result-value">${meta.status === 'partial' ? 'Partial results' : 'Analysis failed'}</div>
+        <div class="links-grid">${problems}</div>
+    `;
+
+    return el;
+}
+
+function escapeHtml(value) {
+    const el = document.createElement('div');
+    el.textContent = value;
+    return el.innerHTML;
+}
+
 function createResultCard(label, value) {
     const el = document.createElement('div');
     el.className = 'result-card';
@@ -160,6 +221,10 @@ function createLinksCard(links) {
         <h3>Links Analysis</h3>
         <div class="result-value">Total: ${total} links</div>
         <div class="links-grid">
+            <div class="link-row">
+                <div class="link-row-label">Classification</div>
+                <div class="link-row-value">${links.classification}</div>
+            </div>
             <div class="link-row">
                 <div class="link-row-label">Internal</div>
                 <div class="link-row-value">${links.internal}</div>
@@ -172,6 +237,35 @@ function createLinksCard(links) {
                 <div class="link-row-label">Inaccessible</div>
                 <div class="link-row-value">${links.inaccessible}</div>
             </div>
+            <div class="link-row">
+                <div class="link-row-label">Redirected</div>
+                <div class="link-row-value">${links.redirected}</div>
+            </div>
+            ${links.incomplete ? `
+            <div class="link-row">
+                <div class="link-row-label">Unchecked</div>
+                <div class="link-row-value">${links.unchecked}</div>
+            </div>` : ''}
+            <div class="link-row">
+                <div class="link-row-label">Nofollow / Sponsored / UGC</div>
+                <div class="link-row-value">${links.hygiene.nofollow} / ${links.hygiene.sponsored} / ${links.hygiene.ugc}</div>
+            </div>
+            <div class="link-row">
+                <div class="link-row-label">Broken Anchors</div>
+                <div class="link-row-value">${links.fragments.broken} of ${links.fragments.checked}</div>
+            </div>
+            <div class="link-row">
+                <div class="link-row-label">mailto / tel / javascript / data / other</div>
+                <div class="link-row-value">${links.non_http.counts.mailto} / ${links.non_http.counts.tel} / ${links.non_http.counts.javascript} / ${links.non_http.counts.data} / ${links.non_http.counts.other}</div>
+            </div>
+            <div class="link-row">
+                <div class="link-row-label">Invalid non-HTTP links</div>
+                <div class="link-row-value">${links.non_http.invalid}</div>
+            </div>
+            <div class="link-row">
+                <div class="link-row-label">target=_blank without noopener</div>
+                <div class="link-row-value">${links.hygiene.unsafe_target_blank}</div>
+            </div>
         </div>
     `;
     return el;
@@ -193,6 +287,207 @@ function createLoginFormCard(hasLogin) {
     return el;
 }
 
+function createRedirectsCard(redirects) {
+    const el = document.createElement('div');
+    el.className = 'result-card';
+
+    const flags = [];
+    if (redirects.loop) flags.push('redirect loop');
+    if (redirects.https_downgrade) flags.push('HTTPS to HTTP downgrade');
+    if (redirects.excessive) flags.push('excessive chain');
+
+    el.innerHTML = `
+        <h3>Redirects</h3>
+        <div class="result-value">${redirects.hops.length} hops</div>
+        <div class="links-grid">
+            <div class="link-row">
+                <div class="link-row-label">Final URL</div>
+                <div class="link-row-value">${redirects.final_url}</div>
+            </div>
+            <div class="link-row">
+                <div class="link-row-label">Warnings</div>
+                <div class="link-row-value">${flags.length ? flags.join(', ') : 'None'}</div>
+            </div>
+        </div>
+    `;
+    return el;
+}
+
+function createSecurityCard(security) {
+    const el = document.createElement('div');
+    el.className = 'result-card';
+
+    let rowsHTML = '';
+    for (const check of security.headers) {
+        rowsHTML += `
+            <div class="link-row">
+                <div class="link-row-label">${check.name}</div>
+                <div class="link-row-value">${check.status}</div>
+            </div>
+        `;
+    }
+
+    const tls = security.tls ? `${security.tls.version}, expires in ${security.tls.days_until_expiry} days` : 'Not served over TLS';
+
+    el.innerHTML = `
+        <h3>Security</h3>
+        <div class="result-value">Grade ${security.grade} (${security.score}/100)</div>
+        <div class="links-grid">
+            ${rowsHTML}
+            <div class="link-row">
+                <div class="link-row-label">TLS</div>
+                <div class="link-row-value">${tls}</div>
+            </div>
+        </div>
+    `;
+    return el;
+}
+
+function createFormsCard(forms) {
+    const el = document.createElement('div');
+    el.className = 'result-card';
+
+    const rows = forms.forms.map(form => `
+        <div class="link-row">
+            <div class="link-row-label">${form.purpose} (${form.method.toUpperCase()} ${escapeHtml(form.action)})</div>
+            <div class="link-row-value">${form.fields.length} fields${form.insecure_action ? ', insecure action' : ''}${form.third_party ? ', third-party' : ''}${form.has_csrf_token ? ', CSRF token' : ''}${form.has_captcha ? ', CAPTCHA' : ''}</div>
+        </div>
+    `).join('');
+
+    el.innerHTML = `
+        <h3>Forms</h3>
+        <div class="result-value">Total: ${forms.count} forms</div>
+        <div class="result-value">Login confidence: ${forms.login.score}/100 (threshold ${forms.login.threshold})</div>
+        <div class="links-grid">${rows}</div>
+    `;
+    return el;
+}
+
+function createTechnologiesCard(technologies) {
+    const el = document.createElement('div');
+    el.className = 'result-card';
+
+    const rows = technologies.map(tech => `
+        <div class="link-row">
+            <div class="link-row-label">${escapeHtml(tech.name)}${tech.version ? ' ' + escapeHtml(tech.version) : ''}</div>
+            <div class="link-row-value">${escapeHtml(tech.category)}</div>
+        </div>
+    `).join('');
+
+    el.innerHTML = `
+        <h3>Technologies</h3>
+        <div class="result-value">Detected: ${technologies.length}</div>
+        <div class="links-grid">${rows}</div>
+    `;
+    return el;
+}
+
+function createTrackersCard(trackers) {
+    const el = document.createElement('div');
+    el.className = 'result-card';
+
+    const rows = trackers.third_parties.map(party => `
+        <div class="link-row">
+            <div class="link-row-label">${escapeHtml(party.name || party.host)}</div>
+            <div class="link-row-value">${party.category ? escapeHtml(party.category) : 'unknown'} (${escapeHtml(party.kinds.join(', '))})</div>
+        </div>
+    `).join('');
+
+    const consent = trackers.consent.detected
+        ? `Consent banner: ${trackers.consent.provider ? escapeHtml(trackers.consent.provider) : 'detected'}`
+        : 'Consent banner: not found';
+
+    el.innerHTML = `
+        <h3>Trackers</h3>
+        <div class="result-value">${trackers.trackers} trackers, ${trackers.third_parties.length} third parties</div>
+        <div class="result-value">${consent}</div>
+        <div class="links-grid">${rows}</div>
+    `;
+    return el;
+}
+
+function createContentCard(content) {
+    const el = document.createElement('div');
+    el.className = 'result-card';
+
+    const rows = content.keywords.concat(content.bigrams).map(keyword => `
+        <div class="link-row">
+            <div class="link-row-label">${escapeHtml(keyword.term)}</div>
+            <div class="link-row-value">${keyword.count} (${keyword.density}%)</div>
+        </div>
+    `).join('');
+
+    el.innerHTML = `
+        <h3>Content</h3>
+        <div class="result-value">${content.word_count} words, ${content.sentence_count} sentences, ${Math.ceil(content.reading_time_seconds / 60)} min read</div>
+        <div class="result-value">Flesch reading ease: ${content.flesch_reading_ease}, text-to-HTML ratio: ${content.text_to_html_ratio}%</div>
+        <div class="links-grid">${rows}</div>
+    `;
+    return el;
+}
+
+function createMixedContentCard(mixedContent) {
+    const el = document.createElement('div');
+    el.className = 'result-card';
+
+    if (!mixedContent.applicable) {
+        el.innerHTML = `
+            <h3>Mixed Content</h3>
+            <div class="result-value">Not applicable (page is not served over HTTPS)</div>
+        `;
+        return el;
+    }
+
+    el.innerHTML = `
+        <h3>Mixed Content</h3>
+        <div class="result-value">Total: ${mixedContent.items.length} insecure resources</div>
+        <div class="links-grid">
+            <div class="link-row">
+                <div class="link-row-label">Active</div>
+                <div class="link-row-value">${mixedContent.active}</div>
+            </div>
+            <div class="link-row">
+                <div class="link-row-label">Passive</div>
+                <div class="link-row-value">${mixedContent.passive}</div>
+            </div>
+        </div>
+    `;
+    return el;
+}
+
+function createResourcesCard(resources) {
+    const el = document.createElement('div');
+    el.className = 'result-card';
+
+    const groups = {
+        Scripts: resources.scripts,
+        Stylesheets: resources.stylesheets,
+        Images: resources.images,
+        Iframes: resources.iframes,
+        Fonts: resources.fonts,
+        Media: resources.media,
+    };
+
+    let rowsHTML = '';
+    for (const [label, items] of Object.entries(groups)) {
+        rowsHTML += `
+            <div class="link-row">
+                <div class="link-row-label">${label}</div>
+                <div class="link-row-value">${items.length}</div>
+            </div>
+        `;
+    }
+
+    el.innerHTML = `
+        <h3>Resources</h3>
+        <div class="result-value">${resources.first_party} first-party, ${resources.third_party} third-party</div>
+        <div class="links-grid">
+            ${rowsHTML}
+        </div>
+    `;
+    return el;
+}
+
 document.addEventListener('DOMContentLoaded', () => {
     inputEl.focus();
 });